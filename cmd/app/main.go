@@ -1,77 +1,131 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
-
-	"github.com/mitchellh/mapstructure"
-	"github.com/spf13/viper"
-	"sigs.k8s.io/yaml"
+	"os"
+	"strings"
 
 	"github.com/aliok/best-go-config-setup/pkg"
 )
 
+// configFiles is a repeatable flag that collects the paths of the configuration files.
+type configFiles []string
+
+func (c *configFiles) String() string {
+	return strings.Join(*c, ",")
+}
+
+func (c *configFiles) Set(value string) error {
+	*c = append(*c, value)
+	return nil
+}
+
+// validateResult prints the result of loading the configuration for the `-validate` mode and returns the exit code:
+// 0 if the configuration is valid, 1 otherwise.
+func validateResult(w io.Writer, err error) int {
+	if err != nil {
+		fmt.Fprintf(w, "config is not valid:\n%v\n", err)
+		return 1
+	}
+	fmt.Fprintln(w, "config OK")
+	return 0
+}
+
+// printDefaults prints the blank configuration with only the defaults applied, in the given format, for the
+// `-print-defaults` mode. No config file is read, so this shows exactly the defaults a user would get.
+func printDefaults(w io.Writer, format string) error {
+	cfg := &pkg.Config{}
+	if err := pkg.ApplyDefaults(cfg); err != nil {
+		return fmt.Errorf("failed to apply defaults: %w", err)
+	}
+	out, err := pkg.Marshal(cfg, format)
+	if err != nil {
+		return fmt.Errorf("failed to marshal defaults: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
+
 // this is the main function for the application, which would run some business logic with the loaded configuration.
 func main() {
 	// viper should use app-config.yaml file as the configuration file in the current directory by default.
+	// if there is none, it is searched in the other default search paths, such as `/etc/app`, see pkg.DefaultSearchPaths.
 	// the user can override this by passing the `-config` flag.
-	configFile := flag.String("config", "", "Path to the configuration file")
+	// the flag can be passed multiple times to layer the files, e.g. a base config and an environment-specific overlay.
+	// `-config -` reads the config from stdin, which is useful for piping the config in containerized workflows.
+	// `-config https://...` fetches the config from a URL, which is useful for the centrally managed configs.
+	var paths configFiles
+	flag.Var(&paths, "config", "Path or HTTP(S) URL of the configuration file, or - for stdin. Can be passed multiple times, later files override earlier ones")
+	cfgType := flag.String("config-type", "", "Type of the configuration read from stdin or a URL: yaml, json or toml. Stdin is yaml and the URLs are detected from the extension by default")
+	strict := flag.Bool("strict", false, "Fail if the config files contain unknown keys")
+	requireEnv := flag.Bool("require-env", false, "Fail if the config references undefined environment variables")
+	output := flag.String("output", "yaml", "Format to print the loaded configuration in: yaml, json or toml")
+	failFast := flag.Bool("fail-fast", false, "Report only the first validation error")
+	validateOnly := flag.Bool("validate", false, "Only validate the configuration, print the result and exit with 0 if it is valid, 1 otherwise")
+	environment := flag.String("env", "", "Deployment environment whose defaults to use, such as dev or prod. APP_ENV is used by default")
+	profile := flag.String("profile", "", "Name of the profile in the config to merge over the base config, such as prod. APP_PROFILE is used by default")
+	printDefaultsOnly := flag.Bool("print-defaults", false, "Only print the default configuration, without reading any config, in the -output format and exit")
 	flag.Parse()
 
-	configFlagPassed := false
-
-	if *configFile != "" {
-		configFlagPassed = true
-		log.Printf("Using config file: %s", *configFile)
-		viper.SetConfigFile(*configFile)
-	} else {
-		// default to app-config.yaml
-		viper.SetConfigName("app-config")
-		viper.SetConfigType("yaml")
-		viper.AddConfigPath(".")
-	}
-
-	// read the config file (the location of the file should be set by the caller)
-	if err := viper.ReadInConfig(); err != nil {
-		if configFlagPassed {
-			log.Printf("Failed to read config file: %v", err)
-			flag.Usage()
-			log.Fatal("Please provide a valid configuration file")
-		} else {
-			// ok to not have a config file
-			log.Printf("Failed to read the default config file, going to use defaults: %v", err)
+	// print the defaults before reading the config, so that a broken config doesn't get in the way
+	if *printDefaultsOnly {
+		if err := printDefaults(os.Stdout, *output); err != nil {
+			log.Fatal(err)
 		}
-	} else {
-		log.Printf("Read config file: %s", viper.ConfigFileUsed())
+		return
 	}
 
-	// optionally, override the config with environment variables
-	// viper.AutomaticEnv()
+	// the first config file is the main one, the rest are merged on top of it
+	var path string
+	var overlays []string
+	if len(paths) > 0 {
+		path, overlays = paths[0], paths[1:]
+	}
 
-	// configure viper to use the `json` tag
-	viperOpt := func(dc *mapstructure.DecoderConfig) {
-		dc.TagName = "json"
+	stdinType := *cfgType
+	if stdinType == "" {
+		stdinType = "yaml"
 	}
-	// Unmarshal into struct using Viper
-	var cfg pkg.Config
-	if err := viper.Unmarshal(&cfg, viperOpt); err != nil {
-		log.Fatalf("Failed to unmarshal config: %v", err)
+
+	// read the config files, override with the environment variables, set default values and validate
+	cfg, err := pkg.LoadConfig(path,
+		pkg.WithOverlays(overlays...),
+		pkg.WithStdin(os.Stdin, stdinType),
+		pkg.WithURLConfigType(*cfgType),
+		pkg.WithStrict(*strict),
+		pkg.WithRequireEnv(*requireEnv),
+		pkg.WithProfile(*profile),
+		pkg.WithEnvironment(*environment),
+		pkg.WithHandleOptions(pkg.WithFailFast(*failFast)),
+	)
+
+	// in the validate-only mode, such as in CI, report the result and exit without running the business logic
+	if *validateOnly {
+		os.Exit(validateResult(os.Stdout, err))
 	}
 
-	// Set default values for the configuration and validate it
-	if err := pkg.HandleConfig(&cfg); err != nil {
-		log.Fatalf("Failed to handle config: %v", err)
+	if err != nil {
+		if errors.Is(err, pkg.ErrReadConfig) {
+			log.Print(err)
+			flag.Usage()
+			log.Fatal("Please provide a valid configuration file")
+		}
+		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	// output the loaded configuration
-	cfgYaml, err := yaml.Marshal(cfg)
+	// output the loaded configuration, with the secrets masked
+	cfgOut, err := pkg.Marshal(pkg.Redacted(cfg), *output)
 	if err != nil {
-		log.Fatalf("Failed to marshal config to yaml: %v", err)
+		log.Fatalf("Failed to marshal config: %v", err)
 	}
-	fmt.Printf("Read config\n%s\n", string(cfgYaml))
+	// only the config goes to stdout, so that it can be piped into other tools such as `jq`
+	log.Print("Read config")
+	fmt.Println(string(cfgOut))
 	// Outputs as:
-	// Read config
 	// features:
 	//  enabled_features:
 	//  - feature3
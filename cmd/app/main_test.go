@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aliok/best-go-config-setup/pkg"
+)
+
+func TestConfigFilesFlag(t *testing.T) {
+	var files configFiles
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Var(&files, "config", "")
+
+	if err := fs.Parse([]string{"-config", "base.yaml", "-config", "overlay.yaml"}); err != nil {
+		t.Fatalf("failed to parse the flags: %v", err)
+	}
+	if expected := []string{"base.yaml", "overlay.yaml"}; !slices.Equal(files, expected) {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+	if files.String() != "base.yaml,overlay.yaml" {
+		t.Errorf("unexpected string %q", files.String())
+	}
+}
+
+func TestValidateResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantCode int
+		wantOut  string
+	}{
+		{name: "good config", content: "http_server:\n  port: 9090\n", wantCode: 0, wantOut: "config OK\n"},
+		{name: "bad config", content: "http_server:\n  port: 70000\n", wantCode: 1, wantOut: "config is not valid:\nhttp_server.port: "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := pkg.LoadConfig(path)
+
+			var out bytes.Buffer
+			if code := validateResult(&out, err); code != tt.wantCode {
+				t.Errorf("expected the exit code %d, got %d", tt.wantCode, code)
+			}
+			if !strings.HasPrefix(out.String(), tt.wantOut) {
+				t.Errorf("expected the output to start with %q, got %q", tt.wantOut, out.String())
+			}
+		})
+	}
+}
+
+func TestPrintDefaults(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{format: "yaml", expected: "port: 8080"},
+		{format: "json", expected: `"port": 8080`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := printDefaults(&out, tt.format); err != nil {
+				t.Fatalf("failed to print the defaults: %v", err)
+			}
+			if !strings.Contains(out.String(), tt.expected) {
+				t.Errorf("expected the output to contain %q, got:\n%s", tt.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestPrintDefaultsUnsupportedFormat(t *testing.T) {
+	if err := printDefaults(&bytes.Buffer{}, "xml"); err == nil {
+		t.Error("expected an error for the unsupported format")
+	}
+}
@@ -1,43 +1,49 @@
 package main
 
 import (
-	"encoding/json"
-	"github.com/aliok/best-go-config-setup/util"
+	"flag"
 	"log"
 	"os"
-
-	"github.com/invopop/jsonschema"
-	"sigs.k8s.io/yaml"
+	"path/filepath"
+	"strings"
 
 	"github.com/aliok/best-go-config-setup/pkg"
+	"github.com/aliok/best-go-config-setup/util"
 )
 
 // this is the main function for the configbuilder, which would generate the configuration JSON schema and the reference configuration file.
 func main() {
+	schemaOut := flag.String("schema-out", "configuration-schema.gen.json", "Path to write the JSON schema to")
+	configOut := flag.String("config-out", "default-config.gen.yaml", "Path to write the reference configuration to")
+	schemaID := flag.String("schema-id", "", "The $id of the JSON schema, such as a canonical URL. Derived from the Go package by default")
+	schemaVersion := flag.String("schema-version", "", "The $schema of the JSON schema, which is the URL of the draft version. Draft 2020-12 by default")
+	inlineRefs := flag.Bool("inline-refs", false, "Inline the definitions in place of the $refs, for the schema consumers that don't support references")
+	markdownOut := flag.String("markdown-out", "", "Path to write the Markdown documentation of the config fields to. Not written by default")
+	flag.Parse()
+
+	// the reference config is a blank config with the defaults applied, so the required fields must have defaults
+	if problems := util.CheckRequiredHaveDefaults(pkg.Config{}); len(problems) > 0 {
+		for _, problem := range problems {
+			log.Print(problem)
+		}
+		log.Fatal("Found required fields without defaults")
+	}
+
 	//
 	// CREATE THE JSON SCHEMA FOR THE CONFIGURATION
 	//
 
-	// we are going to generate the JSON schema for the configuration and write it to configuration-schema.gen.json
-	reflector := new(jsonschema.Reflector)
-	// treat code comments as JSON schema descriptions
-	if err := reflector.AddGoComments("github.com/aliok/best-go-config-setup", "pkg"); err != nil {
-		log.Fatalf("Failed to add comments: %v", err)
+	schemaOpts := []pkg.SchemaOption{pkg.WithSchemaID(*schemaID), pkg.WithSchemaVersion(*schemaVersion)}
+	if *inlineRefs {
+		schemaOpts = append(schemaOpts, pkg.WithInlineRefs())
 	}
-	// generate the JSON schema
-	schema := reflector.Reflect(&pkg.Config{})
-
-	// fix the schema for arrays
-	util.VisitSchema(schema, "array", util.FixArrayDefaultValues)
-
-	// marshal the schema to JSON
-	schemaJSON, err := json.MarshalIndent(schema, "", "  ")
+	schemaJSON, err := pkg.GenerateSchema(schemaOpts...)
 	if err != nil {
-		log.Fatalf("Failed to marshal schema: %v", err)
+		log.Fatalf("Failed to generate schema: %v", err)
 	}
 
 	// write the schema to a file
-	if err := os.WriteFile("configuration-schema.gen.json", schemaJSON, 0644); err != nil {
+	if err := os.WriteFile(*schemaOut, schemaJSON, 0644); err != nil {
 		log.Fatalf("Failed to write schema to file: %v", err)
 	}
 
@@ -45,23 +51,45 @@ func main() {
 	// CREATE THE DEFAULT CONFIG FILE (reference config)
 	//
 
-	// create a blank Config instance, then set defaults.
-	// this is the reference configuration.
-	cfg := pkg.Config{}
-	if err := pkg.HandleConfig(&cfg); err != nil {
-		log.Fatalf("Error while defaulting or validating the blank config. Are you sure the default values for fields are good?: %v", err)
+	cfgYaml, err := pkg.GenerateReferenceConfig()
+	if err != nil {
+		log.Fatalf("Failed to generate reference config: %v", err)
 	}
 
-	// write default config (reference config) to default-config.gen.yaml
-	cfgYaml, err := yaml.Marshal(cfg)
+	// prepend the JSON schema header for IDE support.
+	// the schema path is relative to the reference config file, so it is not always the same as pkg.SchemaHeader.
+	schemaPath, err := filepath.Rel(filepath.Dir(*configOut), *schemaOut)
 	if err != nil {
-		log.Fatalf("Failed to marshal config to yaml: %v", err)
+		log.Fatalf("Failed to find the schema path relative to the reference config: %v", err)
+	}
+	schemaPath = filepath.ToSlash(schemaPath)
+	if !strings.HasPrefix(schemaPath, "../") {
+		schemaPath = "./" + schemaPath
+	}
+	header := "# yaml-language-server: $schema=" + schemaPath + " \n"
+	cfgYaml = append([]byte(header), cfgYaml...)
+
+	// make sure the reference config agrees with the schema, such as a default not violating its own constraint
+	if err := util.ValidateAgainstSchema(cfgYaml, schemaJSON); err != nil {
+		log.Fatalf("Reference config doesn't match the schema: %v", err)
 	}
-	// prepend the JSON schema header for IDE support
-	cfgYaml = append([]byte("# yaml-language-server: $schema=./configuration-schema.gen.json \n"), cfgYaml...)
 
 	// write to file
-	if err := os.WriteFile("default-config.gen.yaml", cfgYaml, 0644); err != nil {
+	if err := os.WriteFile(*configOut, cfgYaml, 0644); err != nil {
 		log.Fatalf("Failed to write config to file: %v", err)
 	}
+
+	//
+	// CREATE THE MARKDOWN DOCUMENTATION OF THE CONFIG FIELDS
+	//
+
+	if *markdownOut != "" {
+		markdown, err := util.GenerateMarkdown(pkg.Config{})
+		if err != nil {
+			log.Fatalf("Failed to generate Markdown documentation: %v", err)
+		}
+		if err := os.WriteFile(*markdownOut, markdown, 0644); err != nil {
+			log.Fatalf("Failed to write Markdown documentation to file: %v", err)
+		}
+	}
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/aliok/best-go-config-setup/pkg"
+)
+
+// printFindings prints the lint findings and returns the exit code: 1 if there is a finding with the warning severity,
+// 0 otherwise. The findings with the info severity are printed, but don't fail the lint.
+func printFindings(w io.Writer, findings []pkg.LintFinding) int {
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "no findings")
+		return 0
+	}
+
+	code := 0
+	for _, finding := range findings {
+		fmt.Fprintln(w, finding)
+		if finding.Severity == pkg.SeverityWarning {
+			code = 1
+		}
+	}
+	return code
+}
+
+// this is the main function for the configlint, which checks a configuration for the settings that are valid but
+// likely a mistake, such as binding to all network interfaces without TLS. It is meant to be run before deploying.
+func main() {
+	path := flag.String("config", "", "Path to the configuration file to lint. The default config file is searched if not set, like the application does")
+	flag.Parse()
+
+	// load the config like the application does, so that the defaults and the environment variables are considered
+	cfg, err := pkg.LoadConfig(*path)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	os.Exit(printFindings(os.Stdout, pkg.Lint(cfg, *path, pkg.DefaultLintRules)))
+}
@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// SchemaHeader is the first line of the YAML config files, which tells the YAML language server in the IDEs
+// where to find the JSON schema for auto-completion and validation.
+const SchemaHeader = "# yaml-language-server: $schema=./configuration-schema.gen.json \n"
+
+// WriteAnnotatedConfig writes the reference configuration as YAML, where each field is preceded by a comment
+// from its Go doc comment. This gives users a self-documenting starter config.
+//
+// The output starts with SchemaHeader, for IDE support.
+// Like GenerateSchema, this function must be called from the root directory of the module to read the Go comments.
+func WriteAnnotatedConfig(w io.Writer) error {
+	cfgYaml, err := GenerateReferenceConfig()
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, SchemaHeader); err != nil {
+		return err
+	}
+	_, err = w.Write(cfgYaml)
+	return err
+}
+
+// annotate adds the Go comments of the config fields to the given YAML as comments.
+func annotate(cfgYaml []byte) ([]byte, error) {
+	reflector, err := newReflector()
+	if err != nil {
+		return nil, err
+	}
+
+	// sigs.k8s.io/yaml doesn't support comments, so we go through the yaml.v3 node API
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(cfgYaml, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse the config yaml: %w", err)
+	}
+	if len(doc.Content) > 0 {
+		annotateNode(doc.Content[0], reflect.TypeOf(Config{}), reflector.CommentMap)
+	}
+
+	var buf bytes.Buffer
+	encoder := yamlv3.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal the annotated config yaml: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal the annotated config yaml: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// annotateNode sets the comments of the keys in the given mapping node, recursively.
+// The comments are looked up from the comment map of the reflector, which has keys like
+// `github.com/aliok/best-go-config-setup/pkg.HTTPServerConfig.Port`.
+func annotateNode(node *yamlv3.Node, t reflect.Type, comments map[string]string) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind != yamlv3.MappingNode || t.Kind() != reflect.Struct {
+		return
+	}
+
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" {
+			fields[name] = t.Field(i)
+		}
+	}
+
+	// content of a mapping node is the list of keys and values: key1, value1, key2, value2, ...
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		field, ok := fields[key.Value]
+		if !ok {
+			continue
+		}
+		if comment := comments[t.PkgPath()+"."+t.Name()+"."+field.Name]; comment != "" {
+			key.HeadComment = "# " + strings.ReplaceAll(comment, "\n", "\n# ")
+		}
+		annotateNode(value, field.Type, comments)
+	}
+}
@@ -0,0 +1,60 @@
+package pkg
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// TestWriteAnnotatedConfig compares the annotated config with the reference config of the repository, which is the
+// golden file. Run `go test ./pkg -run TestWriteAnnotatedConfig -update`, or the configbuilder, to update it.
+func TestWriteAnnotatedConfig(t *testing.T) {
+	chdirModuleRoot(t)
+	const golden = "default-config.gen.yaml"
+
+	var buf bytes.Buffer
+	if err := WriteAnnotatedConfig(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(expected) {
+		t.Errorf("the annotated config doesn't match %s, run the configbuilder to update it:\n%s", golden, buf.String())
+	}
+}
+
+func TestWriteAnnotatedConfigNested(t *testing.T) {
+	chdirModuleRoot(t)
+
+	var buf bytes.Buffer
+	if err := WriteAnnotatedConfig(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, SchemaHeader) {
+		t.Errorf("expected the output to start with the schema header, got:\n%s", out)
+	}
+	// the comments of the nested fields are indented with the fields
+	for _, expected := range []string{
+		"# HTTPServerConfig is the configuration for the HTTP server.\nhttp_server:\n",
+		"\n  # TLSConfig is the TLS configuration for the HTTP server.\n  tls:\n",
+		"\n    # MinVersion is the minimum TLS version to accept. Can be `1.2` or `1.3`.\n    min_version: \"1.3\"\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in the output:\n%s", expected, out)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package pkg
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/aliok/go-defaultz"
+	"github.com/invopop/jsonschema"
+)
+
+// ByteSize is a number of bytes that is represented as a size string with a unit, such as `10MB` or `512KiB`,
+// in the config files, in the JSON schema and in the output. Plain numbers, such as `1024`, are bytes.
+//
+// Both the decimal units (`KB`, `MB`, `GB`, `TB`, powers of 1000) and the binary units (`KiB`, `MiB`, `GiB`, `TiB`,
+// powers of 1024) are supported.
+//
+// Use the `bytesize_gte` validation rule for setting a minimum, such as `validate:"bytesize_gte=1KB"`.
+type ByteSize int64
+
+// byteSizePattern is the pattern of the size strings, as accepted by ParseByteSize.
+const byteSizePattern = `^([0-9]+(\.[0-9]+)?|\.[0-9]+)(B|KB|MB|GB|TB|KiB|MiB|GiB|TiB)?$`
+
+var byteSizeRegex = regexp.MustCompile(byteSizePattern)
+
+// byteSizeUnits are the multipliers of the units, from the largest to the smallest.
+var byteSizeUnits = []struct {
+	unit       string
+	multiplier int64
+}{
+	{"TiB", 1 << 40},
+	{"TB", 1000 * 1000 * 1000 * 1000},
+	{"GiB", 1 << 30},
+	{"GB", 1000 * 1000 * 1000},
+	{"MiB", 1 << 20},
+	{"MB", 1000 * 1000},
+	{"KiB", 1 << 10},
+	{"KB", 1000},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size string, such as `10MB`, `512KiB` or `1024`, into a number of bytes.
+// Fractional sizes, such as `1.5GB`, are rounded down to the whole bytes.
+func ParseByteSize(s string) (ByteSize, error) {
+	match := byteSizeRegex.FindStringSubmatch(s)
+	if match == nil {
+		return 0, fmt.Errorf("invalid byte size %q, must be a number with an optional unit such as 10MB or 512KiB", s)
+	}
+
+	number, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q: %w", s, err)
+	}
+	multiplier := int64(1)
+	for _, u := range byteSizeUnits {
+		if u.unit == match[3] {
+			multiplier = u.multiplier
+			break
+		}
+	}
+
+	size := number * float64(multiplier)
+	// math.MaxInt64 is rounded up to 2^63 as a float64, which already overflows
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("byte size %q is too large", s)
+	}
+	return ByteSize(size), nil
+}
+
+// Bytes returns the size as a number of bytes.
+func (b ByteSize) Bytes() int64 {
+	return int64(b)
+}
+
+// String returns the size with the largest unit that represents it exactly, such as `4MB` or `512KiB`.
+func (b ByteSize) String() string {
+	if b == 0 {
+		return "0B"
+	}
+	for _, u := range byteSizeUnits {
+		if int64(b)%u.multiplier == 0 {
+			return strconv.FormatInt(int64(b)/u.multiplier, 10) + u.unit
+		}
+	}
+	// not reachable, as every size is a multiple of a byte
+	return strconv.FormatInt(int64(b), 10) + "B"
+}
+
+// MarshalText marshals the size as a size string. This is used for JSON and YAML as well.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText parses a size string. This is used by Viper as well, see Unmarshal.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	parsed, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = parsed
+	return nil
+}
+
+// byteSizeSchema is the JSON schema of ByteSize fields. See durationSchema for why it is not a `JSONSchema()` method.
+func byteSizeSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:    "string",
+		Pattern: byteSizePattern,
+	}
+}
+
+var byteSizeType = reflect.TypeOf(ByteSize(0))
+
+// byteSizeDefaulter sets the default values of the ByteSize fields from size strings, such as `default=4MB`.
+// The basic defaulters of go-defaultz only handle plain numbers for integer fields.
+type byteSizeDefaulter struct{}
+
+var _ defaultz.Defaulter = &byteSizeDefaulter{}
+
+func (d *byteSizeDefaulter) Name() string {
+	return "pkg.byteSizeDefaulter"
+}
+
+func (d *byteSizeDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{reflect.Int64}
+}
+
+func (d *byteSizeDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	// other int64 fields, such as Duration, are handled by the other defaulters
+	if field.Type != byteSizeType {
+		return true, false, nil
+	}
+
+	size, err := ParseByteSize(value)
+	if err != nil {
+		return true, false, defaultz.NewError(d, defaultz.ErrInvalidDefaultValue, path, field, err.Error())
+	}
+	fieldValue.SetInt(int64(size))
+	return true, true, nil
+}
@@ -0,0 +1,139 @@
+package pkg
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected ByteSize
+		wantErr  bool
+	}{
+		// decimal units
+		{input: "10MB", expected: 10 * 1000 * 1000},
+		{input: "1GB", expected: 1000 * 1000 * 1000},
+		{input: "1.5KB", expected: 1500},
+		// binary units
+		{input: "512KiB", expected: 512 * 1024},
+		{input: "1GiB", expected: 1 << 30},
+		{input: "2TiB", expected: 2 << 40},
+		// plain bytes
+		{input: "1024", expected: 1024},
+		{input: "1024B", expected: 1024},
+		{input: ".5KiB", expected: 512},
+		// rounded down to the whole bytes
+		{input: "1.0005KB", expected: 1000},
+		{input: "10mb", wantErr: true},
+		{input: "10 MB", wantErr: true},
+		{input: "-1MB", wantErr: true},
+		{input: "10PB", wantErr: true},
+		{input: "MB", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "100000000TB", wantErr: true},
+		// the largest sizes below and at 2^63
+		{input: "8388607TiB", expected: 8388607 << 40},
+		{input: "8388608TiB", wantErr: true},
+		{input: "9223372036854775808", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			size, err := ParseByteSize(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", size)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if size != tt.expected {
+				t.Errorf("expected %d bytes, got %d", tt.expected, size)
+			}
+		})
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	tests := []struct {
+		size     ByteSize
+		expected string
+	}{
+		{size: 0, expected: "0B"},
+		{size: 4 * 1000 * 1000, expected: "4MB"},
+		{size: 512 * 1024, expected: "512KiB"},
+		{size: 1 << 30, expected: "1GiB"},
+		{size: 1500, expected: "1500B"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if s := tt.size.String(); s != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, s)
+			}
+			// the string parses back to the same size
+			if parsed, err := ParseByteSize(tt.size.String()); err != nil || parsed != tt.size {
+				t.Errorf("expected %q to parse back to %d, got %d, %v", tt.expected, tt.size, parsed, err)
+			}
+		})
+	}
+}
+
+func TestByteSizePattern(t *testing.T) {
+	pattern := regexp.MustCompile(byteSizePattern)
+	for _, s := range []string{"10MB", "512KiB", "1024", "1.5GB", ".5KiB"} {
+		if !pattern.MatchString(s) {
+			t.Errorf("expected the pattern to match %q", s)
+		}
+	}
+	for _, s := range []string{"10mb", "10 MB", "-1MB", "MB", ""} {
+		if pattern.MatchString(s) {
+			t.Errorf("expected the pattern not to match %q", s)
+		}
+	}
+}
+
+func TestLoadConfigByteSize(t *testing.T) {
+	cfg := mustLoadYAML(t, "http_server:\n  max_request_body_size: 10MiB\n")
+	if cfg.HTTPServerConfig.MaxRequestBodySize.Bytes() != 10<<20 {
+		t.Errorf("expected 10MiB, got %v", cfg.HTTPServerConfig.MaxRequestBodySize)
+	}
+
+	// the default
+	cfg = mustLoadYAML(t, "")
+	if cfg.HTTPServerConfig.MaxRequestBodySize.Bytes() != 4*1000*1000 {
+		t.Errorf("expected the default 4MB, got %v", cfg.HTTPServerConfig.MaxRequestBodySize)
+	}
+
+	_, err := LoadConfigFromBytes([]byte("http_server:\n  max_request_body_size: 10 megabytes\n"), "yaml")
+	assertErrorContains(t, err, "max_request_body_size")
+}
+
+func TestValidateByteSizeGte(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.HTTPServerConfig.MaxRequestBodySize = 999
+
+	errs := validationErrorsOf(Validate(cfg))
+	if len(errs) != 1 || errs[0].Path != "http_server.max_request_body_size" || errs[0].Tag != "bytesize_gte" {
+		t.Fatalf("expected the bytesize_gte rule to fail for http_server.max_request_body_size, got %v", errs)
+	}
+	if errs[0].Message != "max_request_body_size must be 1KB or larger" {
+		t.Errorf("unexpected message %q", errs[0].Message)
+	}
+
+	cfg.HTTPServerConfig.MaxRequestBodySize = 1000
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected the minimum to be valid: %v", err)
+	}
+}
+
+func TestByteSizeSchema(t *testing.T) {
+	schema := generateSchema(t, withoutComments())
+	prop := schemaProperty(t, schema, "HTTPServerConfig", "max_request_body_size")
+	if prop["type"] != "string" || prop["pattern"] != byteSizePattern || prop["default"] != "4MB" {
+		t.Errorf("unexpected schema of max_request_body_size: %v", prop)
+	}
+}
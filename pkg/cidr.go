@@ -0,0 +1,44 @@
+package pkg
+
+import (
+	"net"
+	"reflect"
+
+	"github.com/invopop/jsonschema"
+)
+
+// CIDR is an IP network in the CIDR notation, such as `10.0.0.0/8` or `fd00::/8`, as in the allowlists.
+// It is a string in the config files, in the JSON schema and in the output.
+//
+// Use the `cidr` validation rule for checking the notation, such as `validate:"dive,cidr"` for a slice of CIDRs.
+// The validated values can be used with IPNet and Contains.
+type CIDR string
+
+// cidrPattern is a loose pattern of the CIDR notation for the IDEs. The validation rule checks the exact notation.
+const cidrPattern = `^[0-9a-fA-F.:]+/[0-9]{1,3}$`
+
+// IPNet parses the value into a net.IPNet. Returns nil if the value is not in the CIDR notation.
+func (c CIDR) IPNet() *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(string(c))
+	if err != nil {
+		return nil
+	}
+	return ipNet
+}
+
+// Contains returns true if the network contains the given IP address.
+// Returns false if the value is not in the CIDR notation.
+func (c CIDR) Contains(ip net.IP) bool {
+	ipNet := c.IPNet()
+	return ipNet != nil && ipNet.Contains(ip)
+}
+
+// cidrSchema is the JSON schema of CIDR fields. See durationSchema for why it is not a `JSONSchema()` method.
+func cidrSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:    "string",
+		Pattern: cidrPattern,
+	}
+}
+
+var cidrType = reflect.TypeOf(CIDR(""))
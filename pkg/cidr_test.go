@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"net"
+	"slices"
+	"testing"
+)
+
+func TestCIDRIPNet(t *testing.T) {
+	tests := []struct {
+		cidr     CIDR
+		expected string
+	}{
+		{cidr: "10.0.0.0/8", expected: "10.0.0.0/8"},
+		{cidr: "10.1.2.3/8", expected: "10.0.0.0/8"},
+		{cidr: "fd00::/8", expected: "fd00::/8"},
+		{cidr: "10.0.0.1", expected: ""},
+		{cidr: "10.0.0.0/33", expected: ""},
+		{cidr: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.cidr), func(t *testing.T) {
+			ipNet := tt.cidr.IPNet()
+			if tt.expected == "" {
+				if ipNet != nil {
+					t.Errorf("expected nil, got %v", ipNet)
+				}
+				return
+			}
+			if ipNet == nil || ipNet.String() != tt.expected {
+				t.Errorf("expected %s, got %v", tt.expected, ipNet)
+			}
+		})
+	}
+}
+
+func TestCIDRContains(t *testing.T) {
+	tests := []struct {
+		cidr     CIDR
+		ip       string
+		expected bool
+	}{
+		{cidr: "10.0.0.0/8", ip: "10.1.2.3", expected: true},
+		{cidr: "10.0.0.0/8", ip: "11.0.0.1", expected: false},
+		{cidr: "::1/128", ip: "::1", expected: true},
+		{cidr: "::1/128", ip: "127.0.0.1", expected: false},
+		{cidr: "invalid", ip: "10.0.0.1", expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.cidr.Contains(net.ParseIP(tt.ip)); got != tt.expected {
+			t.Errorf("expected %s contains %s to be %t, got %t", tt.cidr, tt.ip, tt.expected, got)
+		}
+	}
+}
+
+func TestValidateTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name     string
+		proxies  []CIDR
+		expected []string
+	}{
+		{name: "empty", proxies: []CIDR{}},
+		{name: "valid", proxies: []CIDR{"10.0.0.0/8", "fd00::/8"}},
+		{name: "address without prefix length", proxies: []CIDR{"10.0.0.0/8", "10.0.0.1"}, expected: []string{"http_server.trusted_proxies[1]"}},
+		{name: "prefix length too long", proxies: []CIDR{"10.0.0.0/33"}, expected: []string{"http_server.trusted_proxies[0]"}},
+		{name: "hostname", proxies: []CIDR{"proxy.local/24"}, expected: []string{"http_server.trusted_proxies[0]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.HTTPServerConfig.TrustedProxies = tt.proxies
+
+			paths := validationPaths(Validate(cfg))
+			if !slices.Equal(paths, tt.expected) {
+				t.Errorf("expected the failing fields %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+}
+
+func TestTrustedProxiesDefaults(t *testing.T) {
+	expected := []CIDR{"127.0.0.1/32", "::1/128"}
+	if proxies := defaultConfig(t).HTTPServerConfig.TrustedProxies; !slices.Equal(proxies, expected) {
+		t.Errorf("expected the default trusted proxies %v, got %v", expected, proxies)
+	}
+
+	// a list in the file replaces the default list
+	cfg := mustLoadYAML(t, "http_server:\n  trusted_proxies: [10.0.0.0/8]\n")
+	if expected := []CIDR{"10.0.0.0/8"}; !slices.Equal(cfg.HTTPServerConfig.TrustedProxies, expected) {
+		t.Errorf("expected %v, got %v", expected, cfg.HTTPServerConfig.TrustedProxies)
+	}
+
+	// as well as a comma-separated list in an environment variable
+	t.Setenv("APP_HTTP_SERVER_TRUSTED_PROXIES", "10.0.0.0/8, 192.168.0.0/16")
+	cfg = mustLoadYAML(t, "")
+	if expected := []CIDR{"10.0.0.0/8", "192.168.0.0/16"}; !slices.Equal(cfg.HTTPServerConfig.TrustedProxies, expected) {
+		t.Errorf("expected %v, got %v", expected, cfg.HTTPServerConfig.TrustedProxies)
+	}
+}
+
+func TestCIDRSchema(t *testing.T) {
+	schema := generateSchema(t, withoutComments())
+
+	prop := schemaProperty(t, schema, "HTTPServerConfig", "trusted_proxies")
+	items, _ := prop["items"].(map[string]interface{})
+	if items["type"] != "string" || items["pattern"] != cidrPattern {
+		t.Errorf("expected the items to be strings with the CIDR pattern, got %v", prop["items"])
+	}
+}
@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone returns a deep copy of the configuration.
+// Mutations on the clone, including its slices and pointers, never affect the original, and vice versa.
+//
+// Clone is implemented explicitly, without reflection, for correctness and speed.
+// New slice, map and pointer fields must be copied here as well.
+func (c *Config) Clone() *Config {
+	if c == nil {
+		return nil
+	}
+
+	// copy all the value fields at once, then deep copy the reference fields
+	clone := *c
+
+	clone.HTTPServerConfig.TrustedProxies = slices.Clone(c.HTTPServerConfig.TrustedProxies)
+	clone.HTTPServerConfig.AllowedContentTypes = slices.Clone(c.HTTPServerConfig.AllowedContentTypes)
+	clone.HTTPServerConfig.CORSConfig.AllowedOrigins = slices.Clone(c.HTTPServerConfig.CORSConfig.AllowedOrigins)
+	clone.HTTPServerConfig.CORSConfig.AllowedMethods = slices.Clone(c.HTTPServerConfig.CORSConfig.AllowedMethods)
+	clone.HTTPServerConfig.CORSConfig.AllowedHeaders = slices.Clone(c.HTTPServerConfig.CORSConfig.AllowedHeaders)
+
+	clone.FeatureConfig.EnabledFeatures = slices.Clone(c.FeatureConfig.EnabledFeatures)
+	clone.FeatureConfig.Flags = maps.Clone(c.FeatureConfig.Flags)
+
+	clone.HTTPServerConfig.Compression = clonePtr(c.HTTPServerConfig.Compression)
+
+	clone.LoggingConfig.LogLevel = clonePtr(c.LoggingConfig.LogLevel)
+
+	clone.MetricsConfig.Enabled = clonePtr(c.MetricsConfig.Enabled)
+
+	clone.TracingConfig.SampleRatio = clonePtr(c.TracingConfig.SampleRatio)
+	clone.HealthConfig.Enabled = clonePtr(c.HealthConfig.Enabled)
+
+	clone.StorageConfig.LocalConfig = clonePtr(c.StorageConfig.LocalConfig)
+	clone.StorageConfig.S3Config = clonePtr(c.StorageConfig.S3Config)
+	clone.StorageConfig.GCSConfig = clonePtr(c.StorageConfig.GCSConfig)
+
+	return &clone
+}
+
+// clonePtr returns a pointer to a copy of the value the given pointer points to. Returns nil for nil.
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	return Ptr(*p)
+}
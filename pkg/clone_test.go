@@ -0,0 +1,119 @@
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// fill sets every field of the given value, recursively, to a non-zero value derived from the seed. The pointers,
+// slices and maps are allocated, so that the test of Clone covers all of them.
+func fill(v reflect.Value, seed int) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem(), seed)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i), seed+i)
+			}
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 2, 2))
+		for i := 0; i < v.Len(); i++ {
+			fill(v.Index(i), seed+i)
+		}
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		for i := 0; i < 2; i++ {
+			key, value := reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+			fill(key, seed+i)
+			fill(value, seed+i)
+			v.SetMapIndex(key, value)
+		}
+	default:
+		mutate(v, seed)
+	}
+}
+
+// mutate changes every field of the given value in place, recursively: the values the pointers point to, the items
+// of the slices and the values of the maps are changed, instead of replacing the pointers, slices and maps.
+// So, if the value shares any of them with another value, the other value changes as well.
+func mutate(v reflect.Value, seed int) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			mutate(v.Elem(), seed)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				mutate(v.Field(i), seed+i)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			mutate(v.Index(i), seed+i)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(v.MapIndex(key))
+			mutate(value, seed)
+			v.SetMapIndex(key, value)
+		}
+	case reflect.String:
+		v.SetString(fmt.Sprintf("%s-%d", v.String(), seed))
+	case reflect.Bool:
+		v.SetBool(!v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(v.Int() + int64(seed) + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(v.Uint() + uint64(seed) + 1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(v.Float() + float64(seed) + 1)
+	default:
+		panic(fmt.Sprintf("unsupported kind %s", v.Kind()))
+	}
+}
+
+// filledConfig returns a config with every field set, see fill.
+func filledConfig() *Config {
+	cfg := &Config{}
+	fill(reflect.ValueOf(cfg).Elem(), 1)
+	return cfg
+}
+
+func TestClone(t *testing.T) {
+	original := filledConfig()
+	clone := original.Clone()
+	if !reflect.DeepEqual(clone, original) {
+		t.Fatalf("expected the clone to be equal to the original, got the differences %v", Diff(original, clone))
+	}
+
+	mutate(reflect.ValueOf(clone).Elem(), 100)
+	if !reflect.DeepEqual(original, filledConfig()) {
+		t.Errorf("expected the original not to change when the clone is mutated, got the differences %v", Diff(filledConfig(), original))
+	}
+
+	// and the other way around
+	clone = original.Clone()
+	mutate(reflect.ValueOf(original).Elem(), 100)
+	if !reflect.DeepEqual(clone, filledConfig()) {
+		t.Errorf("expected the clone not to change when the original is mutated, got the differences %v", Diff(filledConfig(), clone))
+	}
+}
+
+func TestCloneNil(t *testing.T) {
+	var cfg *Config
+	if cfg.Clone() != nil {
+		t.Error("expected the clone of nil to be nil")
+	}
+
+	// the nil pointers, slices and maps stay nil
+	clone := (&Config{}).Clone()
+	if !reflect.DeepEqual(clone, &Config{}) {
+		t.Errorf("expected the clone of the blank config to be blank, got %v", clone)
+	}
+}
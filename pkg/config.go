@@ -1,64 +1,573 @@
 package pkg
 
 import (
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"reflect"
+	"slices"
+	"strings"
+
 	"github.com/aliok/go-defaultz"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 )
 
 // `json`: Used for marshalling and unmarshalling JSON and YAML, plus used by Viper
-// `jsonschema`: Used for generating JSON schema and defaulting
+// `jsonschema`: Used for generating JSON schema and defaulting. Defaults can reference env variables, see resolveDefault
 // `validate`: Used for validating the configuration
+// `sensitive`: Used for masking secrets when outputting the configuration, see Redacted
+// `env`: Used for overriding the field with a custom environment variable name, see BindEnvTags
+// `description`: Used for overriding the Go comment of the field as the description in the JSON schema
+// `union`: Used for marking the discriminator and the variants of the tagged unions, see TaggedUnion
+// `reloadable`: Used for marking the fields and the sections that can be changed without a restart, see Reload
+// `example`: Used for the comma-separated examples of the field in the JSON schema
+// `enumdesc`: Used for the `|`-separated descriptions of the enum values in the JSON schema, in the order of the values
+// `format`: Used for the format of the string field in the JSON schema, such as `uri`
+// `deprecated`: Used for marking the field as deprecated, with the suggested replacement as the value, see WarnDeprecated
 
 type Config struct {
+	// Version is the version of the config format. Older configs are migrated to the current version when loaded.
+	Version int `json:"version,omitempty" jsonschema:"default=2" validate:"required,min=1,max=2"`
+	// the default and the maximum above must be kept in sync with CurrentConfigVersion
+
 	// HTTPServerConfig is the configuration for the HTTP server.
 	HTTPServerConfig HTTPServerConfig `json:"http_server"`
 
 	// FeatureConfig is the configuration for the features.
-	FeatureConfig FeatureConfig `json:"features"`
+	FeatureConfig FeatureConfig `json:"features" reloadable:"true"`
 
 	// LoggingConfig is the configuration for the logging.
-	LoggingConfig LoggingConfig `json:"logging"`
+	LoggingConfig LoggingConfig `json:"logging" reloadable:"true"`
+
+	// DatabaseConfig is the configuration for the database connection.
+	DatabaseConfig DatabaseConfig `json:"database"`
+
+	// MetricsConfig is the configuration for the Prometheus metrics.
+	MetricsConfig MetricsConfig `json:"metrics"`
+
+	// AuthConfig is the configuration for the authentication.
+	AuthConfig AuthConfig `json:"auth"`
+
+	// RateLimitConfig is the configuration for the rate limiting of the requests.
+	RateLimitConfig RateLimitConfig `json:"rate_limit"`
+
+	// TracingConfig is the configuration for the OpenTelemetry tracing.
+	TracingConfig TracingConfig `json:"tracing"`
+
+	// HealthConfig is the configuration for the health endpoints.
+	HealthConfig HealthConfig `json:"health"`
+
+	// ProfilingConfig is the configuration for the pprof profiling endpoints.
+	ProfilingConfig ProfilingConfig `json:"profiling"`
+
+	// GRPCConfig is the configuration for the gRPC server.
+	GRPCConfig GRPCConfig `json:"grpc"`
+
+	// StorageConfig is the configuration for the storage of the files.
+	StorageConfig StorageConfig `json:"storage"`
 }
 
 type HTTPServerConfig struct {
 	// Port is the port number for the HTTP server
-	Port int `json:"port,omitempty" jsonschema:"default=8080" validate:"required,min=1,max=65535"`
+	Port int `json:"port,omitempty" jsonschema:"default=8080" example:"8080,9090" validate:"required,min=1,max=65535"`
+
+	// BindAddress is the address to bind to. Can be an IPv4 address, an IPv6 address or a hostname.
+	BindAddress string `json:"bind_address,omitempty" jsonschema:"default=0.0.0.0" validate:"required,bind_address"`
+
+	// PublicURL is the URL the HTTP server is reachable at by the clients, such as `https://api.example.com`.
+	// Used for building absolute links, such as in redirects. Derived from the request if not set.
+	PublicURL string `json:"public_url,omitempty" format:"uri" validate:"omitempty,url"`
+
+	// ReadTimeout is the maximum duration for reading the entire request, as a Go duration such as `15s`.
+	ReadTimeout Duration `json:"read_timeout,omitempty" jsonschema:"default=15s" validate:"duration_gte=1s"`
+
+	// MaxRequestBodySize is the maximum size of the request bodies, such as `4MB` or `512KiB`.
+	MaxRequestBodySize ByteSize `json:"max_request_body_size,omitempty" jsonschema:"default=4MB" validate:"bytesize_gte=1KB"`
+
+	// AllowedContentTypes are the media types of the request bodies the API accepts, such as `application/json`.
+	// The parameters of the `Content-Type` header, such as `charset=utf-8`, are ignored when matching.
+	AllowedContentTypes []string `json:"allowed_content_types,omitempty" jsonschema:"default=application/json" validate:"dive,required"`
+
+	// ShutdownTimeout is the grace period for the in-flight requests when shutting down the HTTP server,
+	// as a Go duration such as `10s`.
+	ShutdownTimeout Duration `json:"shutdown_timeout,omitempty" jsonschema:"default=10s" validate:"duration_gte=0s"`
+
+	// Compression enables the gzip compression of the responses, for the clients that accept it
+	Compression *bool `json:"compression,omitempty" jsonschema:"default=true" validate:"required"`
+	// field above is a pointer to distinguish between zero value (false) and default value (true)
+
+	// TrustedProxies are the networks of the reverse proxies, such as `10.0.0.0/8`, whose forwarding headers,
+	// such as `X-Forwarded-For`, are trusted for finding the client address.
+	TrustedProxies []CIDR `json:"trusted_proxies,omitempty" jsonschema:"default=127.0.0.1/32 ::1/128" validate:"dive,cidr"`
 
-	// BindAddress is the address to bind to
-	BindAddress string `json:"bind_address,omitempty" jsonschema:"default=0.0.0.0" validate:"required,ip4_addr"`
+	// TLSConfig is the TLS configuration for the HTTP server.
+	TLSConfig TLSConfig `json:"tls"`
+
+	// CORSConfig is the CORS configuration for the HTTP server.
+	CORSConfig CORSConfig `json:"cors"`
+
+	// WebSocketConfig is the configuration for the WebSocket connections of the HTTP server.
+	WebSocketConfig WebSocketConfig `json:"websocket"`
+}
+
+type TLSConfig struct {
+	// Enabled enables TLS for the HTTP server
+	Enabled bool `json:"enabled,omitempty" jsonschema:"default=false"`
+
+	// CertFile is the path to the TLS certificate file. Required when TLS is enabled.
+	CertFile string `json:"cert_file,omitempty" validate:"required_if=Enabled true"`
+
+	// KeyFile is the path to the TLS private key file. Required when TLS is enabled.
+	KeyFile string `json:"key_file,omitempty" validate:"required_if=Enabled true"`
+
+	// MinVersion is the minimum TLS version to accept. Can be `1.2` or `1.3`.
+	MinVersion string `json:"min_version,omitempty" jsonschema:"default=1.3,enum=1.2,enum=1.3" validate:"required,oneof=1.2 1.3"`
+}
+
+type CORSConfig struct {
+	// AllowedOrigins is the list of origins allowed to make cross-origin requests. Use `*` to allow any origin.
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
+
+	// AllowedMethods is the list of HTTP methods allowed for cross-origin requests
+	AllowedMethods []string `json:"allowed_methods,omitempty" jsonschema:"omitempty,default=GET POST"`
+
+	// AllowedHeaders is the list of headers allowed in cross-origin requests
+	AllowedHeaders []string `json:"allowed_headers,omitempty"`
+
+	// AllowCredentials allows cross-origin requests to include credentials, such as cookies.
+	// Can't be used with the `*` origin.
+	AllowCredentials bool `json:"allow_credentials,omitempty"`
+}
+
+type WebSocketConfig struct {
+	// Enabled enables upgrading the HTTP connections to WebSocket connections
+	Enabled bool `json:"enabled,omitempty" jsonschema:"default=false"`
+
+	// ReadBufferSize is the size of the read buffer of the connections, in bytes
+	ReadBufferSize int `json:"read_buffer_size,omitempty" jsonschema:"default=4096" validate:"min=1"`
+
+	// WriteBufferSize is the size of the write buffer of the connections, in bytes
+	WriteBufferSize int `json:"write_buffer_size,omitempty" jsonschema:"default=4096" validate:"min=1"`
+
+	// HandshakeTimeout is the maximum duration for the upgrade handshake, as a Go duration such as `10s`.
+	HandshakeTimeout Duration `json:"handshake_timeout,omitempty" jsonschema:"default=10s" validate:"duration_gte=1ms"`
+
+	// MaxMessageSize is the maximum size of the messages read from the clients, such as `1MB` or `64KiB`.
+	// The connections sending larger messages are closed.
+	MaxMessageSize ByteSize `json:"max_message_size,omitempty" jsonschema:"default=1MB" validate:"bytesize_gte=1B"`
 }
 
 type FeatureConfig struct {
-	// EnabledFeatures is the list of enabled features
-	EnabledFeatures []string `json:"enabled_features,omitempty" jsonschema:"omitempty,default=feature1 feature2"`
+	// EnabledFeatures is the list of enabled features. Deprecated, use `flags` instead.
+	EnabledFeatures []string `json:"enabled_features,omitempty" jsonschema:"omitempty,default=feature1 feature2" deprecated:"features.flags"`
+
+	// Flags enables or disables the features by name, such as `feature1: false`.
+	// Takes precedence over `enabled_features`, so it can also disable a feature enabled there.
+	// The feature names are matched case-insensitively, as Viper lowercases the map keys.
+	Flags map[string]bool `json:"flags,omitempty"`
+}
+
+// IsEnabled returns true if the feature with the given name is enabled.
+// The feature is looked up in Flags first, then in EnabledFeatures.
+func (f FeatureConfig) IsEnabled(name string) bool {
+	// Viper lowercases the map keys, so the flags are looked up in lowercase
+	if enabled, ok := f.Flags[strings.ToLower(name)]; ok {
+		return enabled
+	}
+	return slices.Contains(f.EnabledFeatures, name)
 }
 
 type LoggingConfig struct {
-	// LogLevel is the log level for the application
-	LogLevel *int8 `json:"log_level,omitempty" jsonschema:"default=2" validate:"required,min=-1,max=5"`
+	// LogLevel is the log level for the application. Matches the zap levels: -1 is debug, 0 is info, ..., 5 is fatal.
+	LogLevel *int8 `json:"log_level,omitempty" jsonschema:"default=2" validate:"required,min=-1,max=5,ziplevel"`
 	// field above is a pointer to distinguish between zero value and default value
 
 	// LogFormat is the format of the logs. Can be `json` or `pretty`.
-	LogFormat string `json:"log_format,omitempty" jsonschema:"default=json,enum=json,enum=pretty" validate:"required,oneof=json pretty"`
+	LogFormat string `json:"log_format,omitempty" jsonschema:"default=json,enum=json,enum=pretty" enumdesc:"structured JSON logs|human readable logs" validate:"required,oneof=json pretty"`
+}
+
+type DatabaseConfig struct {
+	// Driver is the database driver. Can be `postgres`, `mysql` or `sqlite`.
+	Driver string `json:"driver,omitempty" jsonschema:"default=postgres,enum=postgres,enum=mysql,enum=sqlite" validate:"required,oneof=postgres mysql sqlite"`
+
+	// Host is the database host. Not used for `sqlite`.
+	Host string `json:"host,omitempty" jsonschema:"default=localhost" validate:"required_unless=Driver sqlite"`
+
+	// Port is the database port. Not used for `sqlite`.
+	Port int `json:"port,omitempty" jsonschema:"default=5432" validate:"required_unless=Driver sqlite,min=0,max=65535"`
+
+	// Name is the database name. For `sqlite`, this is the path to the database file.
+	Name string `json:"name,omitempty" jsonschema:"default=app" validate:"required"`
+
+	// User is the database user
+	User string `json:"user,omitempty"`
+
+	// Password is the database password
+	Password string `json:"password,omitempty" sensitive:"true"`
+
+	// MaxOpenConns is the maximum number of open connections to the database
+	MaxOpenConns int `json:"max_open_conns,omitempty" jsonschema:"default=10" validate:"required,min=1"`
+
+	// MaxIdleConns is the maximum number of idle connections in the pool. Must not be greater than `max_open_conns`.
+	MaxIdleConns int `json:"max_idle_conns,omitempty" jsonschema:"default=5" validate:"min=0"`
+
+	// ConnMaxLifetime is the maximum amount of time a connection may be reused, as a Go duration such as `30m`.
+	ConnMaxLifetime Duration `json:"conn_max_lifetime,omitempty" jsonschema:"default=30m" validate:"duration_gte=1s"`
+
+	// RetryConfig is the retry policy for connecting to the database.
+	RetryConfig RetryConfig `json:"retry"`
+}
+
+// RetryConfig is a retry policy with exponential backoff, reused by the sections that retry operations, such as
+// DatabaseConfig. See Backoff.
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of attempts, including the first one. `1` means no retries.
+	MaxAttempts int `json:"max_attempts,omitempty" jsonschema:"default=3" validate:"required,min=1"`
+
+	// InitialBackoff is the wait before the first retry, as a Go duration such as `100ms`.
+	InitialBackoff Duration `json:"initial_backoff,omitempty" jsonschema:"default=100ms" validate:"duration_gte=1ms"`
+
+	// MaxBackoff is the maximum wait between the retries, as a Go duration such as `10s`.
+	// Must not be shorter than `initial_backoff`.
+	MaxBackoff Duration `json:"max_backoff,omitempty" jsonschema:"default=10s" validate:"duration_gte=1ms"`
+
+	// Multiplier is the factor the wait is multiplied by after each retry. Must be greater than `1`.
+	Multiplier float64 `json:"multiplier,omitempty" jsonschema:"default=2.0" validate:"gt=1"`
+}
+
+type MetricsConfig struct {
+	// Enabled enables the Prometheus metrics endpoint
+	Enabled *bool `json:"enabled,omitempty" jsonschema:"default=true" validate:"required"`
+	// field above is a pointer to distinguish between zero value (false) and default value (true)
+
+	// Path is the HTTP path to serve the metrics at
+	Path string `json:"path,omitempty" jsonschema:"default=/metrics" validate:"required,startswith=/"`
+
+	// BindAddress is the address to bind the separate metrics listener to.
+	// If not set, the separate listener binds to the same address as the HTTP server.
+	BindAddress string `json:"bind_address,omitempty" validate:"omitempty,bind_address"`
+
+	// Port is the port number for the separate metrics listener. Must not be the port of the HTTP server.
+	// If not set, the metrics are served on the HTTP server.
+	Port int `json:"port,omitempty" validate:"required_with=BindAddress,omitempty,min=1,max=65535"`
+}
+
+// IsContentTypeAllowed returns true if the media type of the given `Content-Type` header value, such as
+// `application/json; charset=utf-8`, is one of the allowed content types. The media types are case-insensitive.
+// A malformed header value is not allowed.
+func (h HTTPServerConfig) IsContentTypeAllowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(h.AllowedContentTypes, func(allowed string) bool {
+		return strings.EqualFold(allowed, mediaType)
+	})
+}
+
+// IsTrustedProxy returns true if the given IP address is in one of the trusted proxy networks.
+func (h HTTPServerConfig) IsTrustedProxy(ip net.IP) bool {
+	for _, cidr := range h.TrustedProxies {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// SeparateListener returns true if the metrics should be served on a separate listener,
+// instead of the HTTP server.
+func (m MetricsConfig) SeparateListener() bool {
+	return m.Port != 0
+}
+
+type AuthConfig struct {
+	// Mode is the authentication mode. Can be `none`, `apikey` or `oidc`.
+	// Only the settings of the selected mode can be set.
+	Mode string `json:"mode,omitempty" jsonschema:"default=none,enum=none,enum=apikey,enum=oidc" enumdesc:"no authentication|API key sent by the clients|OpenID Connect tokens" validate:"required,oneof=none apikey oidc"`
+
+	// APIKey is the API key clients must send. Required when the mode is `apikey`.
+	APIKey string `json:"api_key,omitempty" sensitive:"true"`
+
+	// APIKeyValidUntil is the time after which the API key is not accepted anymore, as an RFC 3339 timestamp such
+	// as `2030-01-02T15:04:05Z`. The key doesn't expire if not set. Can only be set when the mode is `apikey`.
+	APIKeyValidUntil Time `json:"api_key_valid_until,omitempty" validate:"omitempty,future"`
+
+	// OIDCConfig is the OpenID Connect configuration. Required when the mode is `oidc`.
+	OIDCConfig OIDCConfig `json:"oidc"`
 }
 
-func HandleConfig(cfg *Config) error {
-	// use go-defaultz to apply defaults
-	// reuse the `jsonschema` tag and the `default=` prefix
+type OIDCConfig struct {
+	// IssuerURL is the URL of the OpenID Connect issuer
+	IssuerURL string `json:"issuer_url,omitempty" format:"uri" validate:"omitempty,url"`
+
+	// ClientID is the OpenID Connect client ID
+	ClientID string `json:"client_id,omitempty"`
+}
+
+type RateLimitConfig struct {
+	// Enabled enables rate limiting of the requests
+	Enabled bool `json:"enabled,omitempty" jsonschema:"default=false"`
+
+	// RequestsPerSecond is the number of requests allowed per second on average, such as `10` or `0.5`.
+	// Required when rate limiting is enabled.
+	RequestsPerSecond float64 `json:"requests_per_second,omitempty" validate:"required_if=Enabled true,omitempty,gt=0"`
+
+	// Burst is the maximum number of requests allowed at once, above the average rate
+	Burst int `json:"burst,omitempty" jsonschema:"default=1" validate:"min=1"`
+}
+
+type TracingConfig struct {
+	// Enabled enables exporting the traces
+	Enabled bool `json:"enabled,omitempty" jsonschema:"default=false"`
+
+	// Endpoint is the URL of the OpenTelemetry collector to export the traces to, such as `http://localhost:4318`.
+	// Required when tracing is enabled.
+	Endpoint string `json:"endpoint,omitempty" format:"uri"`
+
+	// SampleRatio is the ratio of the traces to sample, between `0` (none) and `1` (all)
+	SampleRatio *float64 `json:"sample_ratio,omitempty" jsonschema:"default=0.1" validate:"required,min=0,max=1"`
+	// field above is a pointer to distinguish between zero value (sample none) and default value
+
+	// ServiceName is the name of the service in the traces. Required when tracing is enabled.
+	ServiceName string `json:"service_name,omitempty"`
+
+	// RetryConfig is the retry policy for exporting the traces.
+	RetryConfig RetryConfig `json:"retry"`
+}
+
+type HealthConfig struct {
+	// Enabled enables the liveness and readiness endpoints
+	Enabled *bool `json:"enabled,omitempty" jsonschema:"default=true" validate:"required"`
+	// field above is a pointer to distinguish between zero value (false) and default value (true)
+
+	// LivenessPath is the HTTP path of the liveness endpoint, which tells if the application is running
+	LivenessPath string `json:"liveness_path,omitempty" jsonschema:"default=/healthz" validate:"required,startswith=/"`
+
+	// ReadinessPath is the HTTP path of the readiness endpoint, which tells if the application can serve requests.
+	// Must be different from the liveness path.
+	ReadinessPath string `json:"readiness_path,omitempty" jsonschema:"default=/readyz" validate:"required,startswith=/"`
+}
+
+type ProfilingConfig struct {
+	// Enabled enables the pprof profiling endpoints on a separate listener. Don't expose them publicly.
+	Enabled bool `json:"enabled,omitempty" jsonschema:"default=false"`
+
+	// BindAddress is the address to bind the profiling listener to. Can be an IPv4 address, an IPv6 address or a hostname.
+	BindAddress string `json:"bind_address,omitempty" jsonschema:"default=127.0.0.1" validate:"required,bind_address"`
+
+	// Port is the port number for the profiling listener. Required when profiling is enabled.
+	Port int `json:"port,omitempty" validate:"omitempty,min=1,max=65535"`
+}
+
+type GRPCConfig struct {
+	// Enabled enables the gRPC server, which runs alongside the HTTP server on its own listener
+	Enabled bool `json:"enabled,omitempty" jsonschema:"default=false"`
+
+	// BindAddress is the address to bind the gRPC server to. Can be an IPv4 address, an IPv6 address or a hostname.
+	BindAddress string `json:"bind_address,omitempty" jsonschema:"default=0.0.0.0" validate:"required,bind_address"`
+
+	// Port is the port number for the gRPC server
+	Port int `json:"port,omitempty" jsonschema:"default=50051" validate:"required,min=1,max=65535"`
+
+	// MaxRecvMsgSize is the maximum size of the messages the gRPC server can receive, such as `4MB`.
+	MaxRecvMsgSize ByteSize `json:"max_recv_msg_size,omitempty" jsonschema:"default=4MB" validate:"bytesize_gte=1KB"`
+
+	// MaxSendMsgSize is the maximum size of the messages the gRPC server can send, such as `4MB`.
+	MaxSendMsgSize ByteSize `json:"max_send_msg_size,omitempty" jsonschema:"default=4MB" validate:"bytesize_gte=1KB"`
+
+	// ReflectionEnabled enables the gRPC server reflection service, which lets tools such as `grpcurl` discover the
+	// services. Don't enable it publicly unless the services are meant to be discovered.
+	ReflectionEnabled bool `json:"reflection_enabled,omitempty" jsonschema:"default=false"`
+}
+
+// StorageConfig is a tagged union: the type selects the backend, and only the settings of that backend can be set.
+// See TaggedUnion.
+type StorageConfig struct {
+	// Type is the storage backend. Can be `local`, `s3` or `gcs`.
+	// Only the settings of the selected backend can be set.
+	Type string `json:"type,omitempty" jsonschema:"default=local,enum=local,enum=s3,enum=gcs" enumdesc:"files on the local disk|Amazon S3 or an S3-compatible storage|Google Cloud Storage" validate:"required,oneof=local s3 gcs" union:"discriminator"`
+
+	// LocalConfig is the configuration of the `local` backend.
+	LocalConfig *LocalStorageConfig `json:"local,omitempty" union:"local"`
+
+	// S3Config is the configuration of the `s3` backend.
+	S3Config *S3StorageConfig `json:"s3,omitempty" union:"s3"`
+
+	// GCSConfig is the configuration of the `gcs` backend.
+	GCSConfig *GCSStorageConfig `json:"gcs,omitempty" union:"gcs"`
+}
+
+type LocalStorageConfig struct {
+	// Path is the directory to store the files in
+	Path string `json:"path,omitempty" jsonschema:"default=./data" validate:"required"`
+}
+
+type S3StorageConfig struct {
+	// Bucket is the name of the bucket to store the files in
+	Bucket string `json:"bucket,omitempty" validate:"required"`
+
+	// Region is the region of the bucket, such as `us-east-1`
+	Region string `json:"region,omitempty" jsonschema:"default=us-east-1" validate:"required"`
+
+	// Endpoint is the URL of an S3-compatible storage, such as MinIO. Amazon S3 is used if not set.
+	Endpoint string `json:"endpoint,omitempty" format:"uri" validate:"omitempty,url"`
+
+	// AccessKeyID is the access key ID. The default credential chain of the SDK is used if not set.
+	AccessKeyID string `json:"access_key_id,omitempty" validate:"required_with=SecretAccessKey"`
+
+	// SecretAccessKey is the secret access key. Required when the access key ID is set.
+	SecretAccessKey string `json:"secret_access_key,omitempty" sensitive:"true" validate:"required_with=AccessKeyID"`
+}
+
+type GCSStorageConfig struct {
+	// Bucket is the name of the bucket to store the files in
+	Bucket string `json:"bucket,omitempty" validate:"required"`
+
+	// CredentialsFile is the path to the service account key file. The application default credentials are used if
+	// not set.
+	CredentialsFile string `json:"credentials_file,omitempty"`
+}
+
+// handleOptions are the options for HandleConfig and Validate.
+type handleOptions struct {
+	validatorFuncs []func(*validator.Validate) error
+	translator     ut.Translator
+	defaultTag     string
+	defaultPrefix  string
+	defaultSep     string
+	failFast       bool
+}
+
+// HandleOption is an option for HandleConfig and Validate.
+type HandleOption func(*handleOptions)
+
+// WithValidator sets a function that is called with the validator before validating the configuration.
+// It can register custom validations and translations, such as a rule used in the `validate` tag of a field.
+// The option can be passed multiple times; the functions are called in order.
+func WithValidator(fn func(*validator.Validate) error) HandleOption {
+	return func(o *handleOptions) {
+		o.validatorFuncs = append(o.validatorFuncs, fn)
+	}
+}
+
+// WithTranslator sets the translator for the messages of the validation errors, see ValidationError.
+// The translations for the language of the translator must be registered on the validator using WithValidator,
+// such as with the RegisterDefaultTranslations function of the language in `validator/v10/translations`.
+// The rules without a translation are reported as they are.
+//
+// By default, the messages are in English.
+func WithTranslator(trans ut.Translator) HandleOption {
+	return func(o *handleOptions) {
+		o.translator = trans
+	}
+}
+
+// WithDefaultTag sets where the default values are read from: the struct tag, the prefix of the default value in
+// the tag and the separator of the tag parts. For example, `WithDefaultTag("default", "", ",")` reads the defaults
+// from a dedicated tag, such as `default:"8080"`.
+//
+// By default, the defaults are read from the `jsonschema` tag with the `default=` prefix, such as
+// `jsonschema:"default=8080"`. Note that the JSON schema always takes the defaults from the `jsonschema` tag.
+func WithDefaultTag(tag, prefix, sep string) HandleOption {
+	return func(o *handleOptions) {
+		o.defaultTag = tag
+		o.defaultPrefix = prefix
+		o.defaultSep = sep
+	}
+}
+
+// WithFailFast makes the validation report only the first failing field, instead of all of them, for brevity.
+// The defaulting errors are reported without validating. By default, all the problems are reported at once.
+func WithFailFast(failFast bool) HandleOption {
+	return func(o *handleOptions) {
+		o.failFast = failFast
+	}
+}
+
+func newHandleOptions(opts []HandleOption) *handleOptions {
+	options := &handleOptions{
+		defaultTag:    "jsonschema",
+		defaultPrefix: "default=",
+		defaultSep:    ",",
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// HandleConfig applies the default values to the configuration and validates it.
+// The returned error contains both the defaulting error and all the validation errors, if any.
+// See Validate for how the validation errors are reported, and HandleConfigWithWarnings for the non-fatal problems.
+func HandleConfig(cfg *Config, opts ...HandleOption) error {
+	_, err := HandleConfigWithWarnings(cfg, opts...)
+	return err
+}
+
+// ApplyDefaults sets the default values for the fields that are not set in the configuration.
+// The default values are defined in the `jsonschema` tags of the fields, unless changed with WithDefaultTag.
+// A default can reference an environment variable, such as `default=${DEFAULT_PORT:-8080}`, see resolveDefault.
+// Only WithDefaultTag is relevant for this function; the other options are ignored.
+func ApplyDefaults(cfg *Config, opts ...HandleOption) error {
+	return applyDefaults(cfg, newHandleOptions(opts))
+}
+
+// applyDefaults applies the defaults to the given struct pointer, such as a Config or a section of it.
+// Only the selected variants of the tagged unions are created, see TaggedUnion.
+func applyDefaults(target interface{}, options *handleOptions) error {
+	unset := unsetVariants(reflect.ValueOf(target))
+	err := newDefaulter(options).ApplyDefaults(target)
+	resetUnselectedVariants(unset)
+	return err
+}
+
+// newDefaulter creates the go-defaultz defaulter for the configuration, with the custom defaulters registered.
+func newDefaulter(options *handleOptions) defaultz.DefaulterRegistry {
+	// reuse the `jsonschema` tag and the `default=` prefix by default
+	extractor := &envDefaultExtractor{extractor: defaultz.NewDefaultzExtractor(options.defaultTag, options.defaultPrefix, options.defaultSep)}
 	defaulter := defaultz.NewDefaulterRegistry(
 		defaultz.WithBasicDefaulters(),
-		defaultz.WithDefaultExtractor(defaultz.NewDefaultzExtractor("jsonschema", "default=", ",")),
+		defaultz.WithDefaultExtractor(extractor),
 	)
-	// apply defaults
-	if err := defaulter.ApplyDefaults(cfg); err != nil {
-		return err
+	// size strings, such as `4MB`, for the ByteSize fields
+	defaulter.Register(defaultz.PriorityOtherDefaulter, &byteSizeDefaulter{})
+	// quoted items, such as `"hello world" foo`, for the string slice fields.
+	// must run before the basic slice defaulter, which splits the items by spaces only
+	defaulter.Register(defaultz.PriorityPrimitiveDefaulter-1, &stringSliceDefaulter{})
+	return defaulter
+}
+
+// Validate validates the configuration using the `validate` tags and the custom validations.
+// It doesn't apply the defaults, so it can be used to re-validate a configuration after modifying it.
+//
+// All the failing fields are reported at once, instead of stopping at the first one.
+// The returned error joins a *ValidationError for each failing field, which has the JSON path of the field.
+func Validate(cfg *Config, opts ...HandleOption) error {
+	options := newHandleOptions(opts)
+
+	validate := newValidator()
+	trans := options.translator
+	if trans == nil {
+		var err error
+		if trans, err = newEnglishTranslator(validate); err != nil {
+			return fmt.Errorf("failed to set up the translations: %w", err)
+		}
+	}
+	for _, fn := range options.validatorFuncs {
+		if err := fn(validate); err != nil {
+			return fmt.Errorf("failed to set up the validator: %w", err)
+		}
 	}
 
 	// validate the configuration using `validate` tags
-	validate := validator.New()
-	if err := validate.Struct(cfg); err != nil {
-		return err
+	err := validate.Struct(cfg)
+	// the validator always checks every field, so the rest of the errors are dropped
+	var fieldErrors validator.ValidationErrors
+	if options.failFast && errors.As(err, &fieldErrors) && len(fieldErrors) > 1 {
+		err = fieldErrors[:1]
 	}
-
-	return nil
+	return validationErrors(err, trans)
 }
@@ -0,0 +1,212 @@
+package pkg
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/aliok/best-go-config-setup/util"
+)
+
+func TestApplyDefaults(t *testing.T) {
+	cfg := &Config{}
+	cfg.HTTPServerConfig.Port = 9090
+	if err := ApplyDefaults(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the set fields are kept
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected port 9090, got %d", cfg.HTTPServerConfig.Port)
+	}
+	// the others are defaulted
+	if cfg.HTTPServerConfig.BindAddress != "0.0.0.0" {
+		t.Errorf("expected bind address 0.0.0.0, got %q", cfg.HTTPServerConfig.BindAddress)
+	}
+	if cfg.LoggingConfig.LogLevel == nil || *cfg.LoggingConfig.LogLevel != 2 {
+		t.Errorf("expected log level 2, got %v", cfg.LoggingConfig.LogLevel)
+	}
+	if expected := []string{"feature1", "feature2"}; !slices.Equal(cfg.FeatureConfig.EnabledFeatures, expected) {
+		t.Errorf("expected features %v, got %v", expected, cfg.FeatureConfig.EnabledFeatures)
+	}
+}
+
+func TestValidateDoesNotApplyDefaults(t *testing.T) {
+	cfg := &Config{}
+	if err := Validate(cfg); err == nil {
+		t.Fatal("expected the blank config to be invalid")
+	}
+	if cfg.HTTPServerConfig.Port != 0 {
+		t.Errorf("expected Validate not to default the port, got %d", cfg.HTTPServerConfig.Port)
+	}
+}
+
+func TestValidateAfterModifying(t *testing.T) {
+	cfg := defaultConfig(t)
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("expected the defaults to be valid: %v", err)
+	}
+
+	cfg.HTTPServerConfig.Port = 70000
+	if paths := validationPaths(Validate(cfg)); !slices.Equal(paths, []string{"http_server.port"}) {
+		t.Errorf("expected only http_server.port to fail, got %v", paths)
+	}
+}
+
+func TestHandleConfig(t *testing.T) {
+	cfg := &Config{}
+	if err := HandleConfig(cfg); err != nil {
+		t.Fatalf("expected the blank config to be valid after defaulting: %v", err)
+	}
+	if cfg.HTTPServerConfig.Port != 8080 {
+		t.Errorf("expected the default port 8080, got %d", cfg.HTTPServerConfig.Port)
+	}
+
+	cfg = &Config{}
+	cfg.HTTPServerConfig.Port = 70000
+	if paths := validationPaths(HandleConfig(cfg)); !slices.Equal(paths, []string{"http_server.port"}) {
+		t.Errorf("expected only http_server.port to fail, got %v", paths)
+	}
+}
+
+func TestWithValidator(t *testing.T) {
+	evenPort := func(validate *validator.Validate) error {
+		validate.RegisterStructValidation(func(sl validator.StructLevel) {
+			serverConfig := sl.Current().Interface().(HTTPServerConfig)
+			if serverConfig.Port%2 != 0 {
+				sl.ReportError(serverConfig.Port, "port", "Port", "even_port", "")
+			}
+		}, HTTPServerConfig{})
+		return nil
+	}
+
+	cfg := defaultConfig(t)
+	if err := Validate(cfg, WithValidator(evenPort)); err != nil {
+		t.Fatalf("expected the even port to be valid: %v", err)
+	}
+
+	cfg.HTTPServerConfig.Port = 8081
+	errs := validationErrorsOf(Validate(cfg, WithValidator(evenPort)))
+	if len(errs) != 1 || errs[0].Path != "http_server.port" || errs[0].Tag != "even_port" {
+		t.Errorf("expected the custom rule to fail for http_server.port, got %v", errs)
+	}
+	// the custom rule is not registered without the option
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected the odd port to be valid without the custom rule: %v", err)
+	}
+}
+
+func TestWithValidatorError(t *testing.T) {
+	err := HandleConfig(&Config{}, WithValidator(func(*validator.Validate) error {
+		return errors.New("boom")
+	}))
+	assertErrorContains(t, err, "failed to set up the validator: boom")
+}
+
+func TestWithDefaultTag(t *testing.T) {
+	type server struct {
+		Port     int      `json:"port" default:"9090"`
+		Host     string   `json:"host" default:"localhost"`
+		Features []string `json:"features" default:"a b"`
+		// the `jsonschema` tag is not used with the custom tag
+		Timeout int `json:"timeout" jsonschema:"default=10"`
+	}
+
+	target := &server{}
+	if err := applyDefaults(target, newHandleOptions([]HandleOption{WithDefaultTag("default", "", ",")})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &server{Port: 9090, Host: "localhost", Features: []string{"a", "b"}}
+	if !reflect.DeepEqual(target, expected) {
+		t.Errorf("expected %+v, got %+v", expected, target)
+	}
+
+	// the `jsonschema` tag by default
+	target = &server{}
+	if err := applyDefaults(target, newHandleOptions(nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := (&server{Timeout: 10}); !reflect.DeepEqual(target, expected) {
+		t.Errorf("expected %+v, got %+v", expected, target)
+	}
+}
+
+func TestConfigRequiredFieldsHaveDefaults(t *testing.T) {
+	// the reference config is generated from the defaults, so it must be valid
+	if problems := util.CheckRequiredHaveDefaults(&Config{}); len(problems) != 0 {
+		t.Errorf("expected every required field to have a default, got %q", problems)
+	}
+}
+
+func TestWithFailFast(t *testing.T) {
+	invalid := func() *Config {
+		cfg := &Config{}
+		cfg.HTTPServerConfig.Port = 70000
+		cfg.LoggingConfig.LogFormat = "xml"
+		cfg.DatabaseConfig.MaxOpenConns = -1
+		return cfg
+	}
+
+	// all the failing fields by default, including max_idle_conns, which must not exceed max_open_conns
+	if errs := validationErrorsOf(HandleConfig(invalid())); len(errs) != 4 {
+		t.Errorf("expected 4 errors, got %d: %v", len(errs), errs)
+	}
+	if errs := validationErrorsOf(HandleConfig(invalid(), WithFailFast(false))); len(errs) != 4 {
+		t.Errorf("expected 4 errors without failing fast, got %d: %v", len(errs), errs)
+	}
+
+	// only the first one when failing fast
+	err := HandleConfig(invalid(), WithFailFast(true))
+	if errs := validationErrorsOf(err); len(errs) != 1 {
+		t.Errorf("expected 1 error when failing fast, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestIsContentTypeAllowed(t *testing.T) {
+	server := HTTPServerConfig{AllowedContentTypes: []string{"application/json", "text/plain"}}
+
+	tests := []struct {
+		contentType string
+		expected    bool
+	}{
+		{contentType: "application/json", expected: true},
+		{contentType: "application/json; charset=utf-8", expected: true},
+		{contentType: "Application/JSON", expected: true},
+		{contentType: "text/plain;charset=us-ascii", expected: true},
+		{contentType: "application/xml", expected: false},
+		{contentType: "application/json-patch+json", expected: false},
+		{contentType: "", expected: false},
+		{contentType: "application/json; charset", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			if allowed := server.IsContentTypeAllowed(tt.contentType); allowed != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, allowed)
+			}
+		})
+	}
+}
+
+func TestAllowedContentTypesDefault(t *testing.T) {
+	server := defaultConfig(t).HTTPServerConfig
+	if expected := []string{"application/json"}; !slices.Equal(server.AllowedContentTypes, expected) {
+		t.Errorf("expected the default %v, got %v", expected, server.AllowedContentTypes)
+	}
+	if !server.IsContentTypeAllowed("application/json; charset=utf-8") {
+		t.Error("expected JSON to be allowed by default")
+	}
+	if server.IsContentTypeAllowed("multipart/form-data") {
+		t.Error("expected the forms not to be allowed by default")
+	}
+
+	// the empty media types are not valid
+	cfg := defaultConfig(t)
+	cfg.HTTPServerConfig.AllowedContentTypes = []string{"application/json", ""}
+	if paths := validationPaths(Validate(cfg)); !slices.Equal(paths, []string{"http_server.allowed_content_types[1]"}) {
+		t.Errorf("expected the empty media type to fail, got %v", paths)
+	}
+}
@@ -0,0 +1,22 @@
+package pkg
+
+import "context"
+
+// configContextKey is the key of the configuration in the contexts. It is unexported, so that other packages can't
+// overwrite or read the value without WithConfig and FromContext.
+type configContextKey struct{}
+
+// WithConfig returns a copy of the context carrying the given configuration, such as the configuration of a tenant
+// set by a middleware. Use FromContext to get it back.
+//
+// The configuration is not copied, so callers must not modify it after storing it in the context.
+func WithConfig(ctx context.Context, cfg *Config) context.Context {
+	return context.WithValue(ctx, configContextKey{}, cfg)
+}
+
+// FromContext returns the configuration stored in the context by WithConfig.
+// The second return value is false if the context doesn't carry a configuration.
+func FromContext(ctx context.Context) (*Config, bool) {
+	cfg, ok := ctx.Value(configContextKey{}).(*Config)
+	return cfg, ok && cfg != nil
+}
@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithConfig(t *testing.T) {
+	cfg := defaultConfig(t)
+	ctx := WithConfig(context.Background(), cfg)
+
+	got, ok := FromContext(ctx)
+	if !ok {
+		t.Fatal("expected the context to carry the config")
+	}
+	if got != cfg {
+		t.Errorf("expected the same config, got %p instead of %p", got, cfg)
+	}
+
+	// the derived contexts carry it as well
+	derived, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if got, ok := FromContext(derived); !ok || got != cfg {
+		t.Errorf("expected the derived context to carry the config, got %v, %t", got, ok)
+	}
+}
+
+func TestFromContextMissing(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{name: "no config", ctx: context.Background()},
+		{name: "nil config", ctx: WithConfig(context.Background(), nil)},
+		{name: "config under another key", ctx: context.WithValue(context.Background(), struct{}{}, defaultConfig(t))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, ok := FromContext(tt.ctx)
+			if ok || cfg != nil {
+				t.Errorf("expected no config, got %v, %t", cfg, ok)
+			}
+		})
+	}
+}
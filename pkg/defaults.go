@@ -0,0 +1,122 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/aliok/go-defaultz"
+	"github.com/invopop/jsonschema"
+
+	"github.com/aliok/best-go-config-setup/util"
+)
+
+// resolveDefault resolves the environment variable reference in the default value of a field, such as
+// `default=$DEFAULT_PORT` or `default=${DEFAULT_PORT:-8080}`. This lets a single variable control the defaults
+// of multiple fields when generating the schema and the reference config.
+//
+// The literal after `:-` is used if the variable is not set. An error is returned if the variable is not set and
+// there is no literal. The values that don't start with `$` are returned as they are.
+func resolveDefault(value string) (string, error) {
+	ref, ok := strings.CutPrefix(value, "$")
+	if !ok {
+		return value, nil
+	}
+
+	name, fallback, hasFallback := ref, "", false
+	if inner, ok := strings.CutPrefix(ref, "{"); ok {
+		inner, ok = strings.CutSuffix(inner, "}")
+		if !ok {
+			return "", fmt.Errorf("invalid default %q: missing closing brace", value)
+		}
+		name, fallback, hasFallback = strings.Cut(inner, ":-")
+	}
+	if name == "" {
+		return "", fmt.Errorf("invalid default %q: missing variable name", value)
+	}
+
+	if envValue, ok := os.LookupEnv(name); ok && envValue != "" {
+		return envValue, nil
+	}
+	if hasFallback {
+		return fallback, nil
+	}
+	return "", fmt.Errorf("environment variable %s referenced by the default is not set", name)
+}
+
+var _ defaultz.DefaultExtractor = &envDefaultExtractor{}
+
+// envDefaultExtractor is a defaultz.DefaultExtractor that resolves the environment variable references in the
+// default values extracted by the wrapped extractor, see resolveDefault.
+type envDefaultExtractor struct {
+	extractor defaultz.DefaultExtractor
+}
+
+func (e *envDefaultExtractor) ExtractDefault(field reflect.StructField) (string, bool, error) {
+	value, found, err := e.extractor.ExtractDefault(field)
+	if err != nil || !found {
+		return value, found, err
+	}
+	value, err = resolveDefault(value)
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// applyDefaultRefs sets the default of the field to the resolved value of the environment variable referenced in
+// its `jsonschema` default, if any, see resolveDefault. The reflector can't parse such defaults, so it skips them
+// for the non-string fields.
+func applyDefaultRefs(prop *jsonschema.Schema, field reflect.StructField) error {
+	value := tagDefault(field)
+	if !strings.HasPrefix(value, "$") {
+		return nil
+	}
+
+	resolved, err := resolveDefault(value)
+	if err != nil {
+		return err
+	}
+	if prop.Type == "" {
+		return fmt.Errorf("unsupported default %q: the type of the field is not known", value)
+	}
+	if prop.Default, err = util.ParseValue(resolved, prop.Type); err != nil {
+		return err
+	}
+	return nil
+}
+
+// stringSliceDefaulter sets the default values of the string slice fields, such as `default="hello world" foo`,
+// where the items can be quoted to contain spaces, see util.SplitDefaultList.
+// The basic slice defaulter of go-defaultz splits the items by spaces only.
+type stringSliceDefaulter struct{}
+
+var _ defaultz.Defaulter = &stringSliceDefaulter{}
+
+func (d *stringSliceDefaulter) Name() string {
+	return "pkg.stringSliceDefaulter"
+}
+
+func (d *stringSliceDefaulter) HandledKinds() []reflect.Kind {
+	return []reflect.Kind{reflect.Slice}
+}
+
+func (d *stringSliceDefaulter) HandleField(value string, path string, field reflect.StructField, fieldValue reflect.Value) (bool, bool, error) {
+	// other slices are handled by the basic slice defaulter
+	if field.Type.Elem().Kind() != reflect.String {
+		return true, false, nil
+	}
+
+	parts, err := util.SplitDefaultList(value)
+	if err != nil {
+		return true, false, defaultz.NewError(d, defaultz.ErrInvalidDefaultValue, path, field, err.Error())
+	}
+	slice := reflect.MakeSlice(field.Type, len(parts), len(parts))
+	for i, part := range parts {
+		slice.Index(i).SetString(part)
+	}
+	fieldValue.Set(slice)
+	// don't let the basic slice defaulter split the items again
+	return false, true, nil
+}
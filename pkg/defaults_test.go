@@ -0,0 +1,104 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveDefault(t *testing.T) {
+	t.Setenv("TEST_DEFAULT_PORT", "9090")
+	t.Setenv("TEST_EMPTY", "")
+
+	tests := []struct {
+		value    string
+		expected string
+		wantErr  string
+	}{
+		{value: "8080", expected: "8080"},
+		{value: "$TEST_DEFAULT_PORT", expected: "9090"},
+		{value: "${TEST_DEFAULT_PORT}", expected: "9090"},
+		{value: "${TEST_DEFAULT_PORT:-8080}", expected: "9090"},
+		{value: "${TEST_UNSET:-8080}", expected: "8080"},
+		// an empty variable is not set, as in the config files
+		{value: "${TEST_EMPTY:-8080}", expected: "8080"},
+		{value: "$TEST_UNSET", wantErr: "environment variable TEST_UNSET referenced by the default is not set"},
+		{value: "${TEST_DEFAULT_PORT", wantErr: "missing closing brace"},
+		{value: "${}", wantErr: "missing variable name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			resolved, err := resolveDefault(tt.value)
+			if tt.wantErr != "" {
+				assertErrorContains(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resolved != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, resolved)
+			}
+		})
+	}
+}
+
+// defaultRefConfig is a config whose defaults reference an environment variable, which is shared by the fields.
+type defaultRefConfig struct {
+	Port      int `json:"port" jsonschema:"default=${TEST_DEFAULT_PORT:-8080}"`
+	AdminPort int `json:"admin_port" jsonschema:"default=${TEST_DEFAULT_PORT:-8080}"`
+}
+
+func TestDefaultRefs(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		expected int
+	}{
+		{name: "env set", env: "9090", expected: 9090},
+		{name: "env not set", expected: 8080},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_DEFAULT_PORT", tt.env)
+
+			// the defaults applied to the config, as in the reference config
+			cfg := &defaultRefConfig{}
+			if err := applyDefaults(cfg, newHandleOptions(nil)); err != nil {
+				t.Fatalf("failed to apply the defaults: %v", err)
+			}
+			if expected := (&defaultRefConfig{Port: tt.expected, AdminPort: tt.expected}); !reflect.DeepEqual(cfg, expected) {
+				t.Errorf("expected %+v, got %+v", expected, cfg)
+			}
+
+			// the defaults in the schema
+			schema := newBaseReflector().Reflect(&defaultRefConfig{})
+			if err := visitSchemaFields(schema, reflect.TypeOf(defaultRefConfig{}), applyDefaultRefs); err != nil {
+				t.Fatalf("failed to resolve the defaults: %v", err)
+			}
+			for _, name := range []string{"port", "admin_port"} {
+				prop, _ := schema.Definitions["defaultRefConfig"].Properties.Get(name)
+				if prop.Default != tt.expected {
+					t.Errorf("expected the default %d of %s in the schema, got %#v", tt.expected, name, prop.Default)
+				}
+			}
+		})
+	}
+}
+
+func TestStringSliceDefaulter(t *testing.T) {
+	type config struct {
+		Greetings []string `json:"greetings" jsonschema:"default=\"hello world\" foo"`
+		Ports     []int    `json:"ports" jsonschema:"default=80 443"`
+	}
+
+	cfg := &config{}
+	if err := applyDefaults(cfg, newHandleOptions(nil)); err != nil {
+		t.Fatalf("failed to apply the defaults: %v", err)
+	}
+	expected := &config{Greetings: []string{"hello world", "foo"}, Ports: []int{80, 443}}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("expected %+v, got %+v", expected, cfg)
+	}
+}
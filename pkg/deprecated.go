@@ -0,0 +1,30 @@
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/spf13/viper"
+)
+
+// WarnDeprecated returns a warning for each deprecated field that is set in the config files read by Viper.
+// The deprecated fields are the ones with a `deprecated` tag, whose value is the suggested replacement,
+// such as `deprecated:"features.flags"`.
+//
+// Only the config files are checked, so that the defaults of the deprecated fields don't cause warnings.
+func WarnDeprecated(v *viper.Viper, cfg *Config) []string {
+	var warnings []string
+	visitFields(reflect.TypeOf(cfg), "", func(path string, field reflect.StructField) {
+		replacement, ok := field.Tag.Lookup("deprecated")
+		if !ok || !v.InConfig(path) {
+			return
+		}
+
+		warning := fmt.Sprintf("%s is deprecated", path)
+		if replacement != "" {
+			warning += fmt.Sprintf(", use %s instead", replacement)
+		}
+		warnings = append(warnings, warning)
+	})
+	return warnings
+}
@@ -0,0 +1,54 @@
+package pkg
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWarnDeprecated(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "deprecated field set",
+			content:  "features:\n  enabled_features:\n    - feature1\n",
+			expected: []string{"features.enabled_features is deprecated, use features.flags instead"},
+		},
+		{name: "replacement set", content: "features:\n  flags:\n    feature1: true\n"},
+		{name: "deprecated field not set", content: "http_server:\n  port: 9090\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := readYAML(t, tt.content)
+			cfg := &Config{}
+			if err := Unmarshal(v, cfg); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+			// the defaults of the deprecated fields don't cause warnings
+			if err := ApplyDefaults(cfg); err != nil {
+				t.Fatalf("failed to apply the defaults: %v", err)
+			}
+
+			warnings := WarnDeprecated(v, cfg)
+			if !slices.Equal(warnings, tt.expected) {
+				t.Errorf("expected the warnings %v, got %v", tt.expected, warnings)
+			}
+		})
+	}
+}
+
+func TestLoadConfigWarnFunc(t *testing.T) {
+	var warnings []string
+	warn := func(warning string) {
+		warnings = append(warnings, warning)
+	}
+
+	mustLoadYAML(t, "features:\n  enabled_features:\n    - feature1\n", WithWarnFunc(warn))
+	expected := []string{"features.enabled_features is deprecated, use features.flags instead"}
+	if !slices.Equal(warnings, expected) {
+		t.Errorf("expected the warnings %v, got %v", expected, warnings)
+	}
+}
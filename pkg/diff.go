@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"reflect"
+)
+
+// Change is a change of a single configuration field, as returned by Diff.
+type Change struct {
+	// Path is the JSON path of the field, such as `http_server.port`.
+	Path string
+
+	// Old is the old value of the field. Nil if the field was not set, such as a nil pointer.
+	Old interface{}
+
+	// New is the new value of the field. Nil if the field is not set, such as a nil pointer.
+	New interface{}
+}
+
+// Diff compares two configurations and returns the changed fields, in the order of the fields in Config.
+// Only the changed leaf fields are returned; an empty result means the configurations are equal.
+//
+// Pointer fields are compared by the values they point to. A nil pointer differs from a pointer to any value.
+// Slice fields are compared as a whole: reordering, adding or removing items is reported as a single change of
+// the slice. A nil slice and an empty slice are considered equal.
+//
+// This is useful for logging what changed when the configuration is reloaded.
+func Diff(old, new *Config) []Change {
+	return diff(old, new, leafValuesEqual)
+}
+
+// diff compares the leaf fields of the two configurations using the given equality function.
+func diff(old, new *Config, equal func(a, b interface{}) bool) []Change {
+	oldValues := map[string]interface{}{}
+	visitFieldValues(reflect.ValueOf(old), "", func(path string, _ reflect.StructField, value reflect.Value) {
+		oldValues[path] = leafValue(value)
+	})
+
+	var changes []Change
+	visitFieldValues(reflect.ValueOf(new), "", func(path string, _ reflect.StructField, value reflect.Value) {
+		oldValue := oldValues[path]
+		newValue := leafValue(value)
+		if !equal(oldValue, newValue) {
+			changes = append(changes, Change{Path: path, Old: oldValue, New: newValue})
+		}
+	})
+	return changes
+}
+
+// leafValue returns the value of a leaf field for comparison and reporting.
+// Pointers are dereferenced, and nil pointers and nil slices are returned as nil.
+func leafValue(value reflect.Value) interface{} {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+		return value.Elem().Interface()
+	case reflect.Slice, reflect.Map:
+		if value.Len() == 0 {
+			return nil
+		}
+	}
+	return value.Interface()
+}
+
+func leafValuesEqual(a, b interface{}) bool {
+	// pointers are already dereferenced by leafValue, so DeepEqual compares the values and not the addresses
+	return reflect.DeepEqual(a, b)
+}
+
+// equalOptions are the options for Equal.
+type equalOptions struct {
+	ignoreOrder bool
+}
+
+// EqualOption is an option for Equal.
+type EqualOption func(*equalOptions)
+
+// WithIgnoreOrder makes Equal compare the slice fields, such as `features.enabled_features`, regardless of the order
+// of their items. The number of occurrences of each item still matters.
+func WithIgnoreOrder() EqualOption {
+	return func(o *equalOptions) {
+		o.ignoreOrder = true
+	}
+}
+
+// Equal returns true if the two configurations are equal, field by field.
+// The fields are compared as in Diff: pointer fields are compared by the values they point to, and a nil slice
+// equals an empty slice. Two nil configurations are equal, but a nil configuration doesn't equal a non-nil one.
+func Equal(a, b *Config, opts ...EqualOption) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	options := &equalOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	equal := leafValuesEqual
+	if options.ignoreOrder {
+		equal = leafValuesEqualIgnoreOrder
+	}
+	return len(diff(a, b, equal)) == 0
+}
+
+// leafValuesEqualIgnoreOrder is like leafValuesEqual, but compares the slices as multisets.
+func leafValuesEqualIgnoreOrder(a, b interface{}) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() != reflect.Slice || vb.Kind() != reflect.Slice || va.Len() != vb.Len() {
+		return leafValuesEqual(a, b)
+	}
+
+	// match each item of a with an unmatched equal item of b
+	matched := make([]bool, vb.Len())
+	for i := 0; i < va.Len(); i++ {
+		found := false
+		for j := 0; j < vb.Len(); j++ {
+			if !matched[j] && leafValuesEqual(va.Index(i).Interface(), vb.Index(j).Interface()) {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
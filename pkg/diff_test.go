@@ -0,0 +1,147 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(cfg *Config)
+		expected []Change
+	}{
+		{
+			name:   "identical",
+			modify: func(*Config) {},
+		},
+		{
+			name:     "nested field",
+			modify:   func(cfg *Config) { cfg.HTTPServerConfig.TLSConfig.MinVersion = "1.2" },
+			expected: []Change{{Path: "http_server.tls.min_version", Old: "1.3", New: "1.2"}},
+		},
+		{
+			name:     "reordered slice",
+			modify:   func(cfg *Config) { cfg.FeatureConfig.EnabledFeatures = []string{"feature2", "feature1"} },
+			expected: []Change{{Path: "features.enabled_features", Old: []string{"feature1", "feature2"}, New: []string{"feature2", "feature1"}}},
+		},
+		{
+			name: "added slice item",
+			modify: func(cfg *Config) {
+				cfg.FeatureConfig.EnabledFeatures = append(cfg.FeatureConfig.EnabledFeatures, "feature3")
+			},
+			expected: []Change{{Path: "features.enabled_features", Old: []string{"feature1", "feature2"}, New: []string{"feature1", "feature2", "feature3"}}},
+		},
+		{
+			name:     "unset pointer",
+			modify:   func(cfg *Config) { cfg.LoggingConfig.LogLevel = nil },
+			expected: []Change{{Path: "logging.log_level", Old: int8(2), New: nil}},
+		},
+		{
+			name:     "changed pointer value",
+			modify:   func(cfg *Config) { cfg.LoggingConfig.LogLevel = Ptr(int8(-1)) },
+			expected: []Change{{Path: "logging.log_level", Old: int8(2), New: int8(-1)}},
+		},
+		{
+			name: "multiple fields in the order of the fields",
+			modify: func(cfg *Config) {
+				cfg.LoggingConfig.LogFormat = "pretty"
+				cfg.HTTPServerConfig.Port = 9090
+			},
+			expected: []Change{
+				{Path: "http_server.port", Old: 8080, New: 9090},
+				{Path: "logging.log_format", Old: "json", New: "pretty"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := defaultConfig(t)
+			next := old.Clone()
+			tt.modify(next)
+
+			changes := Diff(old, next)
+			if !reflect.DeepEqual(changes, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, changes)
+			}
+		})
+	}
+}
+
+func TestDiffEqualPointersAndEmptySlices(t *testing.T) {
+	old := defaultConfig(t)
+	next := old.Clone()
+	// a different pointer to the same value
+	next.LoggingConfig.LogLevel = Ptr(*old.LoggingConfig.LogLevel)
+	// an empty slice instead of a nil one
+	old.HTTPServerConfig.CORSConfig.AllowedOrigins = nil
+	next.HTTPServerConfig.CORSConfig.AllowedOrigins = []string{}
+
+	if changes := Diff(old, next); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(cfg *Config)
+		opts     []EqualOption
+		expected bool
+	}{
+		{name: "clone", modify: func(*Config) {}, expected: true},
+		{
+			name: "different pointers to the same value",
+			modify: func(cfg *Config) {
+				level := *cfg.LoggingConfig.LogLevel
+				cfg.LoggingConfig.LogLevel = &level
+			},
+			expected: true,
+		},
+		{
+			name: "different pointed values",
+			modify: func(cfg *Config) {
+				level := int8(-1)
+				cfg.LoggingConfig.LogLevel = &level
+			},
+		},
+		{name: "different field", modify: func(cfg *Config) { cfg.HTTPServerConfig.Port = 9090 }},
+		{
+			name:   "different order",
+			modify: func(cfg *Config) { cfg.FeatureConfig.EnabledFeatures = []string{"feature2", "feature1"} },
+		},
+		{
+			name:     "different order ignored",
+			modify:   func(cfg *Config) { cfg.FeatureConfig.EnabledFeatures = []string{"feature2", "feature1"} },
+			opts:     []EqualOption{WithIgnoreOrder()},
+			expected: true,
+		},
+		{
+			name:   "different occurrences with order ignored",
+			modify: func(cfg *Config) { cfg.FeatureConfig.EnabledFeatures = []string{"feature1", "feature1"} },
+			opts:   []EqualOption{WithIgnoreOrder()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := defaultConfig(t)
+			b := a.Clone()
+			tt.modify(b)
+
+			if equal := Equal(a, b, tt.opts...); equal != tt.expected {
+				t.Errorf("expected Equal to return %v, got %v", tt.expected, equal)
+			}
+		})
+	}
+}
+
+func TestEqualNil(t *testing.T) {
+	if !Equal(nil, nil) {
+		t.Error("expected two nil configs to be equal")
+	}
+	if Equal(nil, defaultConfig(t)) || Equal(defaultConfig(t), nil) {
+		t.Error("expected a nil config not to equal a non-nil one")
+	}
+}
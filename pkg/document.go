@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/spf13/viper"
+
+	"github.com/aliok/best-go-config-setup/util"
+)
+
+// ValidateDocument validates the raw config document of the given type (yaml, json or toml) against the generated
+// JSON schema, before unmarshalling it into a Config. This reports the type mismatches with the paths of the values,
+// such as a string for `http_server.port`, which the unmarshalling reports less clearly.
+//
+// The document is validated over the defaults, like a loaded config, so it doesn't need to set the required fields
+// that have defaults. It is migrated to the current version and its `_file` keys are read, see LoadConfig.
+// The environment variable references and the profiles are not expanded; the profiles are not validated.
+func ValidateDocument(data []byte, configType string) error {
+	if !isSupportedConfigType(configType) {
+		return fmt.Errorf("unsupported config type %q, must be one of yaml, json, toml", configType)
+	}
+	v := viper.New()
+	v.SetConfigType(configType)
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to parse the config: %w", err)
+	}
+	settings, err := configSettings(v)
+	if err != nil {
+		return err
+	}
+
+	defaults, err := toSettings(&Config{})
+	if err != nil {
+		return err
+	}
+	merged := mergeSettings(defaults, settings)
+	if err := alignUnionDefaults(merged, settings, reflect.TypeOf(Config{})); err != nil {
+		return err
+	}
+	doc, err := json.Marshal(merged)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the config: %w", err)
+	}
+
+	// the descriptions are not needed for validating, so the schema is generated without them when the Go comments
+	// can't be read, such as in a deployed binary, like in SchemaHandler
+	schema, err := GenerateSchema()
+	if err != nil {
+		schema, err = GenerateSchema(withoutComments())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate schema: %w", err)
+	}
+	return util.ValidateAgainstSchema(doc, schema)
+}
+
+// alignUnionDefaults aligns the defaults of the tagged unions in the merged settings with the variants selected in the
+// document, recursively: the default variants that the document doesn't select are removed, unless the document sets
+// them, and the selected variants get their defaults. See TaggedUnion.
+func alignUnionDefaults(merged, settings map[string]interface{}, t reflect.Type) error {
+	if discriminator, variants, ok := unionFields(t); ok {
+		selected, _ := merged[jsonName(discriminator)].(string)
+		for _, variant := range variants {
+			name := jsonName(variant.field)
+			section, inDocument := settings[name]
+			if variant.value != selected {
+				if !inDocument {
+					delete(merged, name)
+				}
+				continue
+			}
+
+			defaults, err := toSettings(reflect.New(variant.field.Type.Elem()).Interface())
+			if err != nil {
+				return err
+			}
+			if section, ok := section.(map[string]interface{}); ok {
+				defaults = mergeSettings(defaults, section)
+			} else if inDocument {
+				// not a mapping, which is reported by the schema
+				continue
+			}
+			merged[name] = defaults
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		mergedSection, ok := merged[jsonName(field)].(map[string]interface{})
+		if fieldType.Kind() != reflect.Struct || !ok {
+			continue
+		}
+		section, _ := settings[jsonName(field)].(map[string]interface{})
+		if err := alignUnionDefaults(mergedSection, section, fieldType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toSettings applies the defaults to the given struct pointer, such as a Config or a section of it, and returns it as
+// the settings, keyed by the JSON names of the fields.
+func toSettings(target interface{}) (map[string]interface{}, error) {
+	if err := applyDefaults(target, newHandleOptions(nil)); err != nil {
+		return nil, fmt.Errorf("failed to apply defaults: %w", err)
+	}
+	cfgJSON, err := json.Marshal(target)
+	if err != nil {
+		return nil, err
+	}
+	var settings map[string]interface{}
+	if err := json.Unmarshal(cfgJSON, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// mergeSettings merges the overrides over the base settings at the key level, recursively, like Viper merges the
+// config files. The base settings are modified and returned.
+func mergeSettings(base, overrides map[string]interface{}) map[string]interface{} {
+	for key, value := range overrides {
+		baseSection, baseOK := base[key].(map[string]interface{})
+		section, ok := value.(map[string]interface{})
+		if baseOK && ok {
+			base[key] = mergeSettings(baseSection, section)
+			continue
+		}
+		base[key] = value
+	}
+	return base
+}
@@ -0,0 +1,46 @@
+package pkg
+
+import "testing"
+
+func TestValidateDocument(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		configType string
+		wantErr    string
+	}{
+		{name: "empty", data: "", configType: "yaml"},
+		{name: "valid yaml", data: "http_server:\n  port: 9090\n", configType: "yaml"},
+		{name: "valid json", data: `{"http_server": {"port": 9090}}`, configType: "json"},
+		{name: "valid toml", data: "[http_server]\nport = 9090\n", configType: "toml"},
+		{name: "string for an integer", data: "http_server:\n  port: abc\n", configType: "yaml", wantErr: "http_server.port: got string, want integer"},
+		{name: "unknown enum value", data: "logging:\n  log_format: xml\n", configType: "yaml", wantErr: "logging.log_format"},
+		{name: "selected union variant", data: "storage:\n  type: s3\n  s3:\n    bucket: files\n", configType: "yaml"},
+		{name: "selected union variant without its required fields", data: "storage:\n  type: s3\n", configType: "yaml", wantErr: "storage.s3: missing property 'bucket'"},
+		{name: "unsupported type", data: "", configType: "ini", wantErr: `unsupported config type "ini"`},
+		{name: "invalid document", data: "http_server: [", configType: "yaml", wantErr: "failed to parse the config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDocument([]byte(tt.data), tt.configType)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			assertErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateDocumentWithComments(t *testing.T) {
+	// the tests above run outside the root directory of the module, so the schema is generated without the Go
+	// comments there. the result is the same with them
+	chdirModuleRoot(t)
+	if err := ValidateDocument([]byte("http_server:\n  port: 9090\n"), "yaml"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	assertErrorContains(t, ValidateDocument([]byte("http_server:\n  port: abc\n"), "yaml"), "http_server.port")
+}
@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/invopop/jsonschema"
+)
+
+// Duration is a time.Duration that is represented as a Go duration string, such as `15s` or `1h30m`,
+// in the config files, in the JSON schema and in the output.
+//
+// time.Duration itself is marshalled as an integer number of nanoseconds, which is not user-friendly
+// and doesn't match the duration strings users write in the config files.
+//
+// Use the `duration_gte` validation rule for setting a minimum, such as `validate:"duration_gte=1s"`.
+type Duration time.Duration
+
+// durationPattern is the pattern of the Go duration strings, as accepted by time.ParseDuration.
+const durationPattern = `^[-+]?(0|([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h))+$`
+
+// Duration returns the value as a time.Duration.
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText marshals the duration as a Go duration string. This is used for JSON and YAML as well.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses a Go duration string. This is used by Viper as well, see Unmarshal.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// durationSchema is the JSON schema of Duration fields.
+// It is not implemented as a `JSONSchema()` method, as the reflector would then reference the schema with `$ref`,
+// and the tags on the fields, such as the default value, would be ignored.
+func durationSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:    "string",
+		Pattern: durationPattern,
+	}
+}
+
+var durationType = reflect.TypeOf(Duration(0))
@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDurationUnmarshalText(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{input: "30s", expected: 30 * time.Second},
+		{input: "1h30m", expected: 90 * time.Minute},
+		{input: "250ms", expected: 250 * time.Millisecond},
+		{input: "-5s", expected: -5 * time.Second},
+		{input: "30", wantErr: true},
+		{input: "thirty seconds", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var d Duration
+			err := d.UnmarshalText([]byte(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", d)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if d.Duration() != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, d.Duration())
+			}
+		})
+	}
+}
+
+func TestDurationMarshalText(t *testing.T) {
+	out, err := Duration(90 * time.Minute).MarshalText()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != "1h30m0s" {
+		t.Errorf("expected 1h30m0s, got %s", out)
+	}
+}
+
+func TestDurationPattern(t *testing.T) {
+	pattern := regexp.MustCompile(durationPattern)
+	// the pattern of the schema agrees with time.ParseDuration
+	for _, s := range []string{"30s", "1h30m", "1.5h", ".5s", "-5s", "+5s", "0", "10us", "10µs"} {
+		if _, err := time.ParseDuration(s); err != nil {
+			t.Fatalf("test case %q is not a valid duration: %v", s, err)
+		}
+		if !pattern.MatchString(s) {
+			t.Errorf("expected the pattern to match %q", s)
+		}
+	}
+	for _, s := range []string{"30", "s", "1d", "thirty seconds", ""} {
+		if pattern.MatchString(s) {
+			t.Errorf("expected the pattern not to match %q", s)
+		}
+	}
+}
+
+func TestLoadConfigDuration(t *testing.T) {
+	cfg := mustLoadYAML(t, "http_server:\n  read_timeout: 30s\n")
+	if cfg.HTTPServerConfig.ReadTimeout.Duration() != 30*time.Second {
+		t.Errorf("expected 30s, got %v", cfg.HTTPServerConfig.ReadTimeout)
+	}
+
+	// the default
+	cfg = mustLoadYAML(t, "")
+	if cfg.HTTPServerConfig.ReadTimeout.Duration() != 15*time.Second {
+		t.Errorf("expected the default 15s, got %v", cfg.HTTPServerConfig.ReadTimeout)
+	}
+
+	_, err := LoadConfigFromBytes([]byte("http_server:\n  read_timeout: 30\n"), "yaml")
+	assertErrorContains(t, err, "read_timeout")
+}
+
+func TestValidateDurationGte(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.HTTPServerConfig.ReadTimeout = Duration(500 * time.Millisecond)
+
+	errs := validationErrorsOf(Validate(cfg))
+	if len(errs) != 1 || errs[0].Path != "http_server.read_timeout" || errs[0].Tag != "duration_gte" {
+		t.Fatalf("expected the duration_gte rule to fail for http_server.read_timeout, got %v", errs)
+	}
+	if errs[0].Message != "read_timeout must be 1s or longer" {
+		t.Errorf("unexpected message %q", errs[0].Message)
+	}
+
+	cfg.HTTPServerConfig.ReadTimeout = Duration(time.Second)
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected the minimum to be valid: %v", err)
+	}
+}
+
+func TestDurationSchema(t *testing.T) {
+	schema := generateSchema(t, withoutComments())
+	prop := schemaProperty(t, schema, "HTTPServerConfig", "read_timeout")
+	if prop["type"] != "string" || prop["pattern"] != durationPattern || prop["default"] != "15s" {
+		t.Errorf("unexpected schema of read_timeout: %v", prop)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	cfg := defaultConfig(t)
+	if cfg.HTTPServerConfig.ShutdownTimeout.Duration() != 10*time.Second {
+		t.Errorf("expected the default 10s, got %v", cfg.HTTPServerConfig.ShutdownTimeout)
+	}
+
+	// zero means no grace period
+	cfg.HTTPServerConfig.ShutdownTimeout = 0
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected 0s to be valid: %v", err)
+	}
+
+	cfg.HTTPServerConfig.ShutdownTimeout = Duration(-time.Second)
+	paths := validationPaths(Validate(cfg))
+	if len(paths) != 1 || paths[0] != "http_server.shutdown_timeout" {
+		t.Errorf("expected the negative duration to be rejected, got %v", paths)
+	}
+}
+
+func TestShutdownTimeoutInReferenceConfig(t *testing.T) {
+	chdirModuleRoot(t)
+	out, err := GenerateReferenceConfig()
+	if err != nil {
+		t.Fatalf("failed to generate the reference config: %v", err)
+	}
+	if !strings.Contains(string(out), "shutdown_timeout: 10s") {
+		t.Errorf("expected the default shutdown timeout in the reference config:\n%s", out)
+	}
+}
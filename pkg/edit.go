@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// EditConfig edits the YAML config file at the given path in place. The file is parsed with the yaml.v3 node API
+// and the document node is passed to the edit function, which can change it, e.g. with SetYAMLValue.
+// Unlike SaveConfig, which marshals a Config from scratch, the comments and the order of the keys are preserved.
+//
+// The file is written atomically and its permissions are kept. If the edit function returns an error, the file
+// is not changed.
+func EditConfig(path string, edit func(*yamlv3.Node) error) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%w %s: %w", ErrReadConfig, path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%w %s: %w", ErrReadConfig, path, err)
+	}
+
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse the config yaml %s: %w", path, err)
+	}
+	// an empty file has no document node, start with an empty one
+	if doc.Kind == 0 {
+		doc = yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{{Kind: yamlv3.MappingNode}}}
+	}
+
+	if err := edit(&doc); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	encoder := yamlv3.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to marshal the config yaml: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to marshal the config yaml: %w", err)
+	}
+	return writeFileAtomic(path, buf.Bytes(), info.Mode().Perm())
+}
+
+// SetYAMLValue sets the scalar at the given dotted path, such as `http_server.port`, in the given YAML node.
+// The node can be a document node or a mapping node. The missing mappings along the path are created.
+// The value is written as a plain scalar, so its type is resolved by YAML as usual, e.g. `8080` is an integer.
+//
+// The comments of an existing key are kept.
+func SetYAMLValue(node *yamlv3.Node, path string, value string) error {
+	if node.Kind == yamlv3.DocumentNode {
+		if len(node.Content) == 0 {
+			node.Content = append(node.Content, &yamlv3.Node{Kind: yamlv3.MappingNode})
+		}
+		node = node.Content[0]
+	}
+
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		if node.Kind != yamlv3.MappingNode {
+			return fmt.Errorf("failed to set %s: %s is not a mapping", path, strings.Join(keys[:i], "."))
+		}
+		last := i == len(keys)-1
+
+		var child *yamlv3.Node
+		// the mapping node content is a list of key and value nodes
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == key {
+				child = node.Content[j+1]
+				break
+			}
+		}
+		if child == nil {
+			child = &yamlv3.Node{Kind: yamlv3.MappingNode}
+			if last {
+				child = &yamlv3.Node{Kind: yamlv3.ScalarNode}
+			}
+			node.Content = append(node.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Value: key}, child)
+		}
+
+		if last {
+			if child.Kind != yamlv3.ScalarNode {
+				return fmt.Errorf("failed to set %s: not a scalar", path)
+			}
+			// let the tag be resolved from the new value
+			child.Tag = ""
+			child.Style = 0
+			child.Value = value
+		}
+		node = child
+	}
+	return nil
+}
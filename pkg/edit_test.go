@@ -0,0 +1,111 @@
+package pkg
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+func TestEditConfig(t *testing.T) {
+	path := writeFile(t, "config.yaml", `# the HTTP server
+http_server:
+  # the port to listen on
+  port: 8080 # the default
+  bind_address: 127.0.0.1
+logging:
+  log_format: pretty
+`)
+
+	err := EditConfig(path, func(doc *yamlv3.Node) error {
+		return SetYAMLValue(doc, "http_server.port", "9090")
+	})
+	if err != nil {
+		t.Fatalf("failed to edit the config: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `# the HTTP server
+http_server:
+  # the port to listen on
+  port: 9090 # the default
+  bind_address: 127.0.0.1
+logging:
+  log_format: pretty
+`
+	if string(data) != expected {
+		t.Errorf("expected the comments and the other keys to be kept:\n%s\ngot:\n%s", expected, data)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load the edited config: %v", err)
+	}
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected port 9090, got %d", cfg.HTTPServerConfig.Port)
+	}
+}
+
+func TestEditConfigError(t *testing.T) {
+	content := "http_server:\n  port: 8080\n"
+	path := writeFile(t, "config.yaml", content)
+
+	err := EditConfig(path, func(doc *yamlv3.Node) error {
+		if err := SetYAMLValue(doc, "http_server.port", "9090"); err != nil {
+			return err
+		}
+		return errors.New("boom")
+	})
+	if err == nil || err.Error() != "boom" {
+		t.Fatalf("expected the error of the edit function, got %v", err)
+	}
+
+	// the file is not changed
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("expected the file to be unchanged, got:\n%s", data)
+	}
+}
+
+func TestSetYAMLValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		path     string
+		value    string
+		expected string
+		wantErr  string
+	}{
+		{name: "existing key", content: "a:\n  b: 1\n", path: "a.b", value: "2", expected: "a:\n  b: 2\n"},
+		{name: "missing key", content: "a:\n  b: 1\n", path: "a.c", value: "x", expected: "a:\n  b: 1\n  c: x\n"},
+		{name: "missing mappings", content: "a: 1\n", path: "b.c.d", value: "true", expected: "a: 1\nb:\n  c:\n    d: true\n"},
+		{name: "quoted value", content: "a: \"1\"\n", path: "a", value: "2", expected: "a: 2\n"},
+		{name: "empty document", content: "", path: "a.b", value: "1", expected: "a:\n  b: 1\n"},
+		{name: "not a mapping", content: "a: 1\n", path: "a.b", value: "2", wantErr: "a is not a mapping"},
+		{name: "not a scalar", content: "a:\n  b: 1\n", path: "a", value: "2", wantErr: "not a scalar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "config.yaml", tt.content)
+			err := EditConfig(path, func(doc *yamlv3.Node) error {
+				return SetYAMLValue(doc, tt.path, tt.value)
+			})
+			if tt.wantErr != "" {
+				assertErrorContains(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			data, _ := os.ReadFile(path)
+			if strings.TrimSpace(string(data)) != strings.TrimSpace(tt.expected) {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, data)
+			}
+		})
+	}
+}
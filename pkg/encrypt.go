@@ -0,0 +1,126 @@
+package pkg
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// encryptedPrefix is the prefix of the encrypted config values, such as `password: enc:...`, see EncryptValue.
+const encryptedPrefix = "enc:"
+
+// WithDecryptionKey sets the AES key for decrypting the `enc:` values in the config, see EncryptValue.
+// The key must be 16, 24 or 32 bytes long, for AES-128, AES-192 or AES-256.
+//
+// If no key is set with this option, the `<prefix>_CONFIG_KEY` environment variable is used, as a base64-encoded
+// key, see WithEnvPrefix. Loading fails if the config has an encrypted value and there is no key.
+func WithDecryptionKey(key []byte) LoadOption {
+	return func(o *loadOptions) {
+		o.decryptionKey = key
+	}
+}
+
+// EncryptValue encrypts the given value with the given AES key, using AES-GCM, for writing secrets into the config
+// files, such as `password: enc:...`. The result is the `enc:` prefix followed by the base64-encoded nonce and
+// ciphertext. The encrypted values are decrypted when loading the config, see WithDecryptionKey.
+func EncryptValue(value string, key []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	// the nonce is not secret, so it is stored in front of the ciphertext
+	sealed := gcm.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptValue decrypts a value encrypted with EncryptValue.
+func decryptValue(value string, key []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted value: too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		// a wrong key and a tampered value can't be told apart
+		return "", fmt.Errorf("failed to decrypt, the key is wrong or the value is corrupted: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptionKey returns the key set with WithDecryptionKey, or the one in the `<prefix>_CONFIG_KEY` environment
+// variable. Nil is returned if neither is set.
+func decryptionKey(options *loadOptions) ([]byte, error) {
+	if options.decryptionKey != nil {
+		return options.decryptionKey, nil
+	}
+	name := options.envPrefix + "_CONFIG_KEY"
+	encoded := os.Getenv(name)
+	if encoded == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s, must be base64-encoded: %w", name, err)
+	}
+	return key, nil
+}
+
+// decryptConfig decrypts the `enc:` values of the string and string slice fields of the config in place, so that
+// the rest of the loading, such as the validation, sees the plain values.
+// An error is returned if a value can't be decrypted, or if there is an encrypted value and the key is nil.
+func decryptConfig(cfg *Config, key []byte) error {
+	var errs []error
+	decrypt := func(path string, value reflect.Value) {
+		if !strings.HasPrefix(value.String(), encryptedPrefix) {
+			return
+		}
+		if key == nil {
+			errs = append(errs, fmt.Errorf("%s is encrypted, but no decryption key is set", path))
+			return
+		}
+		plaintext, err := decryptValue(value.String(), key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to decrypt %s: %w", path, err))
+			return
+		}
+		value.SetString(plaintext)
+	}
+
+	visitFieldValues(reflect.ValueOf(cfg), "", func(path string, field reflect.StructField, value reflect.Value) {
+		switch {
+		case value.Kind() == reflect.String:
+			decrypt(path, value)
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String:
+			for i := 0; i < value.Len(); i++ {
+				decrypt(fmt.Sprintf("%s[%d]", path, i), value.Index(i))
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+var testKey = bytes.Repeat([]byte{1}, 32)
+
+// mustEncrypt encrypts the value with the given key and fails the test if it can't.
+func mustEncrypt(t *testing.T, value string, key []byte) string {
+	t.Helper()
+	encrypted, err := EncryptValue(value, key)
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	return encrypted
+}
+
+func TestEncryptValue(t *testing.T) {
+	encrypted := mustEncrypt(t, "s3cret", testKey)
+	if !strings.HasPrefix(encrypted, "enc:") || strings.Contains(encrypted, "s3cret") {
+		t.Errorf("unexpected encrypted value %q", encrypted)
+	}
+	// a new nonce for every value
+	if again := mustEncrypt(t, "s3cret", testKey); again == encrypted {
+		t.Error("expected different encrypted values for the same value")
+	}
+
+	decrypted, err := decryptValue(encrypted, testKey)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if decrypted != "s3cret" {
+		t.Errorf("expected s3cret, got %q", decrypted)
+	}
+
+	if _, err := EncryptValue("s3cret", []byte("short")); err == nil {
+		t.Error("expected an error for an invalid key length")
+	}
+}
+
+func TestDecryptValueErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		key     []byte
+		wantErr string
+	}{
+		{name: "wrong key", value: mustEncrypt(t, "s3cret", testKey), key: bytes.Repeat([]byte{2}, 32), wantErr: "the key is wrong or the value is corrupted"},
+		{name: "not base64", value: "enc:!!!", key: testKey, wantErr: "invalid encrypted value"},
+		{name: "too short", value: "enc:" + base64.StdEncoding.EncodeToString([]byte("abc")), key: testKey, wantErr: "too short"},
+		{name: "invalid key", value: mustEncrypt(t, "s3cret", testKey), key: []byte("short"), wantErr: "invalid encryption key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decryptValue(tt.value, tt.key)
+			assertErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLoadConfigEncryptedValues(t *testing.T) {
+	content := "database:\n  password: " + mustEncrypt(t, "s3cret", testKey) + "\n" +
+		"http_server:\n  cors:\n    allowed_origins: [https://a.example, " + mustEncrypt(t, "https://b.example", testKey) + "]\n"
+
+	cfg := mustLoadYAML(t, content, WithDecryptionKey(testKey))
+	if cfg.DatabaseConfig.Password != "s3cret" {
+		t.Errorf("expected the decrypted password, got %q", cfg.DatabaseConfig.Password)
+	}
+	if origins := cfg.HTTPServerConfig.CORSConfig.AllowedOrigins; len(origins) != 2 || origins[1] != "https://b.example" {
+		t.Errorf("expected the decrypted origin, got %v", origins)
+	}
+
+	// the key from the environment variable
+	t.Setenv("APP_CONFIG_KEY", base64.StdEncoding.EncodeToString(testKey))
+	cfg = mustLoadYAML(t, content)
+	if cfg.DatabaseConfig.Password != "s3cret" {
+		t.Errorf("expected the decrypted password, got %q", cfg.DatabaseConfig.Password)
+	}
+}
+
+func TestLoadConfigEncryptedValuesErrors(t *testing.T) {
+	content := "database:\n  password: " + mustEncrypt(t, "s3cret", testKey) + "\n"
+
+	_, err := LoadConfigFromBytes([]byte(content), "yaml")
+	assertErrorContains(t, err, "database.password is encrypted, but no decryption key is set")
+
+	_, err = LoadConfigFromBytes([]byte(content), "yaml", WithDecryptionKey(bytes.Repeat([]byte{2}, 32)))
+	assertErrorContains(t, err, "failed to decrypt database.password")
+
+	t.Setenv("APP_CONFIG_KEY", "not base64!")
+	_, err = LoadConfigFromBytes([]byte(content), "yaml")
+	assertErrorContains(t, err, "invalid APP_CONFIG_KEY, must be base64-encoded")
+}
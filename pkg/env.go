@@ -0,0 +1,74 @@
+package pkg
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// BindEnv makes it possible to override any configuration field using environment variables.
+//
+// The environment variable name is built from the prefix and the path of the field, joined by `_` and uppercased.
+// For example, with the prefix "APP", `APP_HTTP_SERVER_PORT=9090` overrides `http_server.port`.
+// The fields with an `env` tag use the name in the tag instead, see BindEnvTags.
+// Slice fields, such as `features.enabled_features`, can be set using comma-separated values:
+// `APP_FEATURES_ENABLED_FEATURES=feature3,feature4`. The spaces around the items are trimmed.
+//
+// Precedence is: flags (see WithFlags) > environment variables > configuration file > programmatic defaults
+// (see WithDefaults) > defaults.
+func BindEnv(v *viper.Viper, prefix string) {
+	// the prefix is kept for looking up the environment variable names later, see Explain
+	v.SetEnvPrefix(prefix)
+	bindEnv(v, prefix, reflect.TypeOf(Config{}))
+}
+
+// bindEnv binds the fields of the given struct type to the environment variables, see BindEnv.
+func bindEnv(v *viper.Viper, prefix string, t reflect.Type) {
+	// Unmarshal only considers the keys Viper knows about, e.g. the ones in the config file, so we need to bind every
+	// field explicitly. Otherwise, a field that is only set via an environment variable would be ignored.
+	// Viper's AutomaticEnv is not used, as it would take precedence over the custom names, see BindEnvTags.
+	visitFields(t, "", func(path string, field reflect.StructField) {
+		// error is only returned when no key is passed
+		_ = v.BindEnv(path, fieldEnvName(prefix, path, field))
+	})
+}
+
+// BindEnvTags binds the fields with an `env` tag to the environment variable named in the tag, instead of the
+// automatic name built by BindEnv. For example, a field tagged `env:"PORT"` is overridden by `PORT=9090`,
+// regardless of the prefix. Fields without an `env` tag are not affected.
+//
+// BindEnv already uses the custom names, and the automatic name of such a field is not used at all.
+// BindEnvTags is for binding only the custom names, without the automatic ones.
+func BindEnvTags(v *viper.Viper, cfg *Config) {
+	bindEnvTags(v, reflect.TypeOf(cfg))
+}
+
+// bindEnvTags binds the fields of the given struct type with an `env` tag, see BindEnvTags.
+func bindEnvTags(v *viper.Viper, t reflect.Type) {
+	visitFields(t, "", func(path string, field reflect.StructField) {
+		if name := field.Tag.Get("env"); name != "" {
+			// error is only returned when no key is passed
+			_ = v.BindEnv(path, name)
+		}
+	})
+}
+
+// envName returns the name of the environment variable for the field at the given path, as set up by BindEnv.
+// For example, the environment variable for `http_server.port` with the prefix "APP" is `APP_HTTP_SERVER_PORT`.
+func envName(prefix, path string) string {
+	name := strings.ReplaceAll(path, ".", "_")
+	if prefix != "" {
+		name = prefix + "_" + name
+	}
+	return strings.ToUpper(name)
+}
+
+// fieldEnvName returns the name of the environment variable for the given field, which is the name in the
+// `env` tag if set, see BindEnvTags, or the automatic name otherwise, see envName.
+func fieldEnvName(prefix, path string, field reflect.StructField) string {
+	if name := field.Tag.Get("env"); name != "" {
+		return name
+	}
+	return envName(prefix, path)
+}
@@ -0,0 +1,139 @@
+package pkg
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestBindEnv(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		env    map[string]string
+		check  func(t *testing.T, cfg *Config)
+	}{
+		{
+			name:   "top-level section field",
+			prefix: "APP",
+			env:    map[string]string{"APP_HTTP_SERVER_PORT": "9090"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.HTTPServerConfig.Port != 9090 {
+					t.Errorf("expected port 9090, got %d", cfg.HTTPServerConfig.Port)
+				}
+			},
+		},
+		{
+			name:   "nested section field",
+			prefix: "APP",
+			env:    map[string]string{"APP_HTTP_SERVER_TLS_MIN_VERSION": "1.2"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.HTTPServerConfig.TLSConfig.MinVersion != "1.2" {
+					t.Errorf("expected min version 1.2, got %q", cfg.HTTPServerConfig.TLSConfig.MinVersion)
+				}
+			},
+		},
+		{
+			name:   "comma-separated slice",
+			prefix: "APP",
+			env:    map[string]string{"APP_FEATURES_ENABLED_FEATURES": "feature3, feature4,"},
+			check: func(t *testing.T, cfg *Config) {
+				expected := []string{"feature3", "feature4"}
+				if !slices.Equal(cfg.FeatureConfig.EnabledFeatures, expected) {
+					t.Errorf("expected %v, got %v", expected, cfg.FeatureConfig.EnabledFeatures)
+				}
+			},
+		},
+		{
+			name:   "custom prefix",
+			prefix: "MYAPP",
+			env:    map[string]string{"MYAPP_HTTP_SERVER_PORT": "9191", "APP_HTTP_SERVER_PORT": "9090"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.HTTPServerConfig.Port != 9191 {
+					t.Errorf("expected port 9191, got %d", cfg.HTTPServerConfig.Port)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			// the field isn't in the config file, so only the explicit binding makes it known to Viper
+			v := readYAML(t, "logging:\n  log_format: json\n")
+			BindEnv(v, tt.prefix)
+
+			cfg := &Config{}
+			if err := Unmarshal(v, cfg); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestBindEnvPrecedence(t *testing.T) {
+	// env beats file beats defaults
+	cfg := mustLoadYAML(t, "http_server:\n  port: 7070\n")
+	if cfg.HTTPServerConfig.Port != 7070 {
+		t.Errorf("expected the file to beat the default, got port %d", cfg.HTTPServerConfig.Port)
+	}
+
+	t.Setenv("APP_HTTP_SERVER_PORT", "9090")
+	cfg = mustLoadYAML(t, "http_server:\n  port: 7070\n")
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected the environment variable to beat the file, got port %d", cfg.HTTPServerConfig.Port)
+	}
+}
+
+func TestBindEnvTags(t *testing.T) {
+	type serverConfig struct {
+		Port        int    `json:"port" env:"SERVER_PORT"`
+		BindAddress string `json:"bind_address"`
+	}
+	type config struct {
+		HTTPServer serverConfig `json:"http_server"`
+	}
+
+	t.Setenv("SERVER_PORT", "9191")
+	t.Setenv("APP_HTTP_SERVER_PORT", "9090")
+	t.Setenv("APP_HTTP_SERVER_BIND_ADDRESS", "127.0.0.1")
+
+	v := readYAML(t, "http_server:\n  port: 8080\n")
+	bindEnv(v, "APP", reflect.TypeOf(config{}))
+
+	// the tagged field uses the custom name instead of the automatic one
+	if port := v.GetInt("http_server.port"); port != 9191 {
+		t.Errorf("expected port 9191 from SERVER_PORT, got %d", port)
+	}
+	// the other fields keep the automatic names
+	if address := v.GetString("http_server.bind_address"); address != "127.0.0.1" {
+		t.Errorf("expected bind address 127.0.0.1 from APP_HTTP_SERVER_BIND_ADDRESS, got %q", address)
+	}
+}
+
+func TestBindEnvTagsOnly(t *testing.T) {
+	type config struct {
+		HTTPServer struct {
+			Port        int    `json:"port" env:"SERVER_PORT"`
+			BindAddress string `json:"bind_address"`
+		} `json:"http_server"`
+	}
+
+	t.Setenv("SERVER_PORT", "9191")
+	t.Setenv("APP_HTTP_SERVER_BIND_ADDRESS", "127.0.0.1")
+
+	v := readYAML(t, "http_server:\n  port: 8080\n  bind_address: 0.0.0.0\n")
+	bindEnvTags(v, reflect.TypeOf(config{}))
+
+	if port := v.GetInt("http_server.port"); port != 9191 {
+		t.Errorf("expected port 9191 from SERVER_PORT, got %d", port)
+	}
+	// the automatic names are not bound
+	if address := v.GetString("http_server.bind_address"); address != "0.0.0.0" {
+		t.Errorf("expected bind address 0.0.0.0 from the file, got %q", address)
+	}
+}
@@ -0,0 +1,61 @@
+package pkg
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// environmentDefaults are the defaults of the deployment environments, which override the defaults in the tags,
+// keyed by the environment name and the JSON path of the field, see DefaultsForEnv.
+var environmentDefaults = map[string]map[string]interface{}{
+	"dev": {
+		"logging.log_level":  -1,
+		"logging.log_format": "pretty",
+	},
+	"prod": {
+		"logging.log_level":  1,
+		"logging.log_format": "json",
+	},
+}
+
+// DefaultsForEnv returns the defaults of the given deployment environment, such as `dev` or `prod`, keyed by the
+// JSON path of the field, such as `logging.log_level`. The environment names are case-insensitive.
+// Nil is returned for an unknown environment.
+//
+// The defaults in the tags are the baseline; these only override some of them. See WithEnvironment.
+func DefaultsForEnv(env string) map[string]interface{} {
+	return maps.Clone(environmentDefaults[strings.ToLower(env)])
+}
+
+// WithEnvironment selects the deployment environment, such as `prod`, whose defaults override the defaults in the
+// tags, see DefaultsForEnv. The config files, the environment variables and the flags still override them.
+//
+// If no environment is selected with this option, the `<prefix>_ENV` environment variable is used, such as
+// `APP_ENV=prod`, see WithEnvPrefix. An unknown environment is reported as a warning, see WithWarnFunc.
+func WithEnvironment(env string) LoadOption {
+	return func(o *loadOptions) {
+		o.environment = env
+	}
+}
+
+// applyEnvironmentDefaults sets the defaults of the selected deployment environment in Viper, see WithEnvironment.
+func applyEnvironmentDefaults(v *viper.Viper, options *loadOptions) error {
+	env := options.environment
+	if env == "" {
+		env = os.Getenv(options.envPrefix + "_ENV")
+	}
+	if env == "" {
+		return nil
+	}
+
+	defaults := DefaultsForEnv(env)
+	if defaults == nil {
+		options.warn(fmt.Sprintf("unknown environment %q, using the default values of the fields", env))
+		return nil
+	}
+	return setDefaults(v, defaults)
+}
@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDefaultsForEnv(t *testing.T) {
+	defaults := DefaultsForEnv("PROD")
+	if defaults["logging.log_level"] != 1 || defaults["logging.log_format"] != "json" {
+		t.Errorf("unexpected prod defaults %v", defaults)
+	}
+	if defaults := DefaultsForEnv("unknown"); defaults != nil {
+		t.Errorf("expected nil for an unknown environment, got %v", defaults)
+	}
+
+	// the returned map is a copy
+	defaults["logging.log_level"] = 5
+	if DefaultsForEnv("prod")["logging.log_level"] != 1 {
+		t.Error("expected the defaults not to be modified through the returned map")
+	}
+}
+
+func TestLoadConfigEnvironmentDefaults(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       string
+		opts      []LoadOption
+		content   string
+		logLevel  int8
+		logFormat string
+	}{
+		{name: "no environment", logLevel: 2, logFormat: "json"},
+		{name: "prod from the environment variable", env: "prod", logLevel: 1, logFormat: "json"},
+		{name: "dev from the environment variable", env: "dev", logLevel: -1, logFormat: "pretty"},
+		{name: "option beats the environment variable", env: "dev", opts: []LoadOption{WithEnvironment("prod")}, logLevel: 1, logFormat: "json"},
+		{name: "file overrides the environment defaults", env: "prod", content: "logging:\n  log_level: 4\n", logLevel: 4, logFormat: "json"},
+		{
+			name:      "programmatic defaults override the environment defaults",
+			env:       "dev",
+			opts:      []LoadOption{WithDefaults(map[string]interface{}{"logging.log_format": "json"})},
+			logLevel:  -1,
+			logFormat: "json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("APP_ENV", tt.env)
+			}
+
+			cfg := mustLoadYAML(t, tt.content, tt.opts...)
+			if cfg.LoggingConfig.LogLevel == nil || *cfg.LoggingConfig.LogLevel != tt.logLevel {
+				t.Errorf("expected log level %d, got %v", tt.logLevel, cfg.LoggingConfig.LogLevel)
+			}
+			if cfg.LoggingConfig.LogFormat != tt.logFormat {
+				t.Errorf("expected log format %q, got %q", tt.logFormat, cfg.LoggingConfig.LogFormat)
+			}
+		})
+	}
+}
+
+func TestLoadConfigUnknownEnvironment(t *testing.T) {
+	var warnings []string
+	cfg := mustLoadYAML(t, "", WithEnvironment("qa"), WithWarnFunc(func(warning string) {
+		warnings = append(warnings, warning)
+	}))
+
+	expected := []string{`unknown environment "qa", using the default values of the fields`}
+	if !slices.Equal(warnings, expected) {
+		t.Errorf("expected the warnings %v, got %v", expected, warnings)
+	}
+	if *cfg.LoggingConfig.LogLevel != 2 {
+		t.Errorf("expected the default log level 2, got %d", *cfg.LoggingConfig.LogLevel)
+	}
+}
@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"os"
+	"reflect"
+
+	"github.com/spf13/viper"
+)
+
+// Possible sources of the configuration values returned by Explain.
+const (
+	SourceEnv     = "env"
+	SourceFile    = "file"
+	SourceDefault = "default"
+)
+
+// Explain returns the source of each configuration value, keyed by the JSON path of the field, such as
+// `http_server.port`. The source is one of SourceEnv, SourceFile and SourceDefault, following the precedence
+// documented in BindEnv.
+//
+// Fields that are set neither in the environment nor in the config files get their values from the defaults.
+// Fields without a default value keep their zero value, which is also reported as SourceDefault.
+//
+// This is useful for debugging "why is this value X?".
+func Explain(v *viper.Viper, cfg *Config) map[string]string {
+	sources := map[string]string{}
+	visitFields(reflect.TypeOf(cfg), "", func(path string, field reflect.StructField) {
+		switch {
+		case isEnvSet(v, path, field):
+			sources[path] = SourceEnv
+		case v.InConfig(path):
+			sources[path] = SourceFile
+		default:
+			sources[path] = SourceDefault
+		}
+	})
+	return sources
+}
+
+// isEnvSet returns true if the environment variable for the field at the given path is set.
+// Empty environment variables are ignored, just like Viper does.
+func isEnvSet(v *viper.Viper, path string, field reflect.StructField) bool {
+	value, ok := os.LookupEnv(fieldEnvName(v.GetEnvPrefix(), path, field))
+	return ok && value != ""
+}
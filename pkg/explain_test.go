@@ -0,0 +1,43 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	t.Setenv("APP_HTTP_SERVER_PORT", "9090")
+	// an empty environment variable is ignored, as by Viper
+	t.Setenv("APP_LOGGING_LOG_FORMAT", "")
+
+	v := readYAML(t, "http_server:\n  bind_address: 127.0.0.1\nlogging:\n  log_format: pretty\n")
+	BindEnv(v, "APP")
+	cfg := &Config{}
+	if err := Unmarshal(v, cfg); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if err := HandleConfig(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sources := Explain(v, cfg)
+	expected := map[string]string{
+		"http_server.port":         SourceEnv,
+		"http_server.bind_address": SourceFile,
+		"logging.log_format":       SourceFile,
+		"logging.log_level":        SourceDefault,
+		"http_server.tls.enabled":  SourceDefault,
+	}
+	for path, source := range expected {
+		if sources[path] != source {
+			t.Errorf("expected the source of %s to be %q, got %q", path, source, sources[path])
+		}
+	}
+
+	// every leaf field is explained
+	visitFields(reflect.TypeOf(Config{}), "", func(path string, _ reflect.StructField) {
+		if _, ok := sources[path]; !ok {
+			t.Errorf("expected %s to be explained", path)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	featuresMu sync.RWMutex
+	// knownFeatures are the registered feature names, keyed by the lowercase names
+	knownFeatures = map[string]string{}
+)
+
+// RegisterFeature registers the name of a feature known to the application, such as `tracing`, usually from an
+// `init` function of the package implementing the feature. Registering a name again is a no-op.
+//
+// Once any feature is registered, the features in `enabled_features` and `flags` must be registered ones, which
+// catches the typos in the feature names, see KnownFeatures. If no feature is registered, any name is accepted.
+// The features enabled by default, in the default of `enabled_features`, are registered along with the first
+// feature, so that the configs relying on the defaults stay valid.
+func RegisterFeature(name string) {
+	featuresMu.Lock()
+	defer featuresMu.Unlock()
+	if len(knownFeatures) == 0 {
+		for _, feature := range defaultFeatures() {
+			knownFeatures[strings.ToLower(feature)] = feature
+		}
+	}
+	if _, ok := knownFeatures[strings.ToLower(name)]; !ok {
+		knownFeatures[strings.ToLower(name)] = name
+	}
+}
+
+// defaultFeatures returns the features enabled by default, in the default of `enabled_features`.
+func defaultFeatures() []string {
+	featureConfig := FeatureConfig{}
+	// the defaults of the features don't reference environment variables, so they can't fail
+	_ = applyDefaults(&featureConfig, newHandleOptions(nil))
+	return featureConfig.EnabledFeatures
+}
+
+// KnownFeatures returns the names of the registered features, sorted. See RegisterFeature.
+func KnownFeatures() []string {
+	featuresMu.RLock()
+	defer featuresMu.RUnlock()
+	names := make([]string, 0, len(knownFeatures))
+	for _, name := range knownFeatures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isKnownFeature returns true if the feature with the given name is registered, or if no feature is registered.
+// The names are matched case-insensitively, as Viper lowercases the keys of `flags`.
+func isKnownFeature(name string) bool {
+	featuresMu.RLock()
+	defer featuresMu.RUnlock()
+	_, ok := knownFeatures[strings.ToLower(name)]
+	return ok || len(knownFeatures) == 0
+}
+
+// validateFeatureConfig checks that the features in `enabled_features` and `flags` are registered, see
+// RegisterFeature.
+func validateFeatureConfig(sl validator.StructLevel) {
+	featureConfig := sl.Current().Interface().(FeatureConfig)
+	for _, name := range featureConfig.EnabledFeatures {
+		if !isKnownFeature(name) {
+			sl.ReportError(featureConfig.EnabledFeatures, "enabled_features", "EnabledFeatures", "known_feature", name)
+		}
+	}
+
+	// sorted, so that the errors are reported in a stable order
+	names := make([]string, 0, len(featureConfig.Flags))
+	for name := range featureConfig.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !isKnownFeature(name) {
+			sl.ReportError(featureConfig.Flags, "flags", "Flags", "known_feature", name)
+		}
+	}
+}
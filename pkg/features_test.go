@@ -0,0 +1,139 @@
+package pkg
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFeatureConfigIsEnabled(t *testing.T) {
+	features := FeatureConfig{
+		EnabledFeatures: []string{"feature1", "feature2"},
+		Flags:           map[string]bool{"feature2": false, "feature3": true},
+	}
+
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{name: "feature1", expected: true},  // only in the list
+		{name: "feature2", expected: false}, // disabled in the map, which takes precedence
+		{name: "feature3", expected: true},  // only in the map
+		{name: "FEATURE3", expected: true},  // the map keys are matched case-insensitively
+		{name: "feature4", expected: false}, // in neither
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if enabled := features.IsEnabled(tt.name); enabled != tt.expected {
+				t.Errorf("expected IsEnabled(%q) to be %v, got %v", tt.name, tt.expected, enabled)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFeatureFlags(t *testing.T) {
+	cfg := mustLoadYAML(t, `
+features:
+  enabled_features:
+    - feature1
+  flags:
+    Feature1: false
+    feature2: true
+`)
+	if cfg.FeatureConfig.IsEnabled("feature1") {
+		t.Error("expected feature1 to be disabled by the flags")
+	}
+	if !cfg.FeatureConfig.IsEnabled("feature2") {
+		t.Error("expected feature2 to be enabled by the flags")
+	}
+}
+
+// resetFeatures empties the feature registry for the test and restores it afterward, see RegisterFeature.
+func resetFeatures(t *testing.T) {
+	t.Helper()
+	featuresMu.Lock()
+	saved := knownFeatures
+	knownFeatures = map[string]string{}
+	featuresMu.Unlock()
+	t.Cleanup(func() {
+		featuresMu.Lock()
+		knownFeatures = saved
+		featuresMu.Unlock()
+	})
+}
+
+func TestKnownFeaturesEmptyRegistry(t *testing.T) {
+	resetFeatures(t)
+
+	if names := KnownFeatures(); len(names) != 0 {
+		t.Errorf("expected no known features, got %v", names)
+	}
+	// any name is accepted until a feature is registered
+	cfg := mustLoadYAML(t, "features:\n  enabled_features: [anything]\n  flags:\n    other: true\n")
+	if !cfg.FeatureConfig.IsEnabled("anything") || !cfg.FeatureConfig.IsEnabled("other") {
+		t.Errorf("expected the unregistered features to be enabled, got %+v", cfg.FeatureConfig)
+	}
+}
+
+func TestRegisterFeature(t *testing.T) {
+	resetFeatures(t)
+
+	RegisterFeature("search")
+	RegisterFeature("Beta")
+	// registering again is a no-op, case-insensitively
+	RegisterFeature("search")
+	RegisterFeature("beta")
+
+	// the features enabled by default are registered along with the first one
+	expected := []string{"Beta", "feature1", "feature2", "search"}
+	if names := KnownFeatures(); !slices.Equal(names, expected) {
+		t.Errorf("expected the known features %v, got %v", expected, names)
+	}
+
+	// so the defaults stay valid
+	if err := Validate(defaultConfig(t)); err != nil {
+		t.Errorf("expected the defaults to be valid, got %v", err)
+	}
+}
+
+func TestValidateUnknownFeatures(t *testing.T) {
+	resetFeatures(t)
+	RegisterFeature("search")
+	RegisterFeature("beta")
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{name: "registered features", content: "features:\n  enabled_features: [search, feature1]\n  flags:\n    BETA: true\n"},
+		{name: "unknown feature in the list", content: "features:\n  enabled_features: [search, serch]\n", expected: []string{"serch"}},
+		{name: "unknown feature in the flags", content: "features:\n  flags:\n    beta: true\n    gamma: false\n    alpha: true\n", expected: []string{"alpha", "gamma"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfigFromBytes([]byte(tt.content), "yaml")
+
+			var unknown []string
+			for _, e := range validationErrorsOf(err) {
+				if e.Tag != "known_feature" {
+					t.Errorf("unexpected error %v", e)
+				}
+				unknown = append(unknown, e.Param)
+			}
+			if !slices.Equal(unknown, tt.expected) {
+				t.Errorf("expected the unknown features %v, got %v: %v", tt.expected, unknown, err)
+			}
+		})
+	}
+}
+
+func TestValidateUnknownFeatureMessage(t *testing.T) {
+	resetFeatures(t)
+	RegisterFeature("search")
+
+	cfg := defaultConfig(t)
+	cfg.FeatureConfig.EnabledFeatures = []string{"serch"}
+	assertErrorContains(t, Validate(cfg), "features.enabled_features: enabled_features has the unknown feature serch")
+}
@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"reflect"
+	"strings"
+)
+
+// visitFields walks the fields of the given struct type recursively and calls the visitor for every leaf field.
+// The path passed to the visitor is the dotted path built from the `json` tag names, such as "http_server.port".
+// This is the same key format Viper uses, so the paths can be passed to Viper as-is.
+func visitFields(t reflect.Type, prefix string, visitor func(path string, field reflect.StructField)) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			visitFields(fieldType, path, visitor)
+			continue
+		}
+
+		visitor(path, field)
+	}
+}
+
+// jsonName returns the name of the field as used in the `json` tag.
+// Returns an empty string for unexported fields and fields that are skipped with `json:"-"`.
+func jsonName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+
+	tag := field.Tag.Get("json")
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// visitFieldValues is like visitFields, but walks the values of a struct and passes the value of each leaf field
+// to the visitor as well.
+func visitFieldValues(v reflect.Value, prefix string, visitor func(path string, field reflect.StructField, value reflect.Value)) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			visitFieldValues(v.Field(i), path, visitor)
+			continue
+		}
+
+		visitor(path, field, v.Field(i))
+	}
+}
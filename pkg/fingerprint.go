@@ -0,0 +1,29 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// Fingerprint returns a stable hash of the configuration, as a hex-encoded SHA-256 of its JSON form.
+// Two equal configurations have the same fingerprint, regardless of the order the map entries were set in,
+// so the callers can skip work, such as a reload, when the fingerprint hasn't changed.
+//
+// The JSON form is canonical: the fields are in the order of Config and the map keys are sorted.
+// The order of the slice items is significant, unlike with WithIgnoreOrder of Equal.
+// The secrets are part of the hash, so a changed secret changes the fingerprint without revealing it.
+//
+// Returns an empty string for a nil configuration.
+func Fingerprint(cfg *Config) string {
+	if cfg == nil {
+		return ""
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		// can't happen, the configuration only has JSON-compatible fields
+		panic(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,51 @@
+package pkg
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	base := defaultConfig(t)
+	base.FeatureConfig.Flags = map[string]bool{"feature1": true, "feature2": false, "feature3": true}
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		same   bool
+	}{
+		{name: "clone", modify: func(*Config) {}, same: true},
+		{
+			name: "map entries set in another order",
+			modify: func(cfg *Config) {
+				cfg.FeatureConfig.Flags = map[string]bool{}
+				for _, name := range []string{"feature3", "feature2", "feature1"} {
+					cfg.FeatureConfig.Flags[name] = name != "feature2"
+				}
+			},
+			same: true,
+		},
+		{name: "different pointer to the same value", modify: func(cfg *Config) { cfg.LoggingConfig.LogLevel = Ptr(*base.LoggingConfig.LogLevel) }, same: true},
+		{name: "different field", modify: func(cfg *Config) { cfg.HTTPServerConfig.Port = 9090 }},
+		{name: "different secret", modify: func(cfg *Config) { cfg.DatabaseConfig.Password = "secret" }},
+		{name: "different map value", modify: func(cfg *Config) { cfg.FeatureConfig.Flags["feature2"] = true }},
+		{name: "different slice order", modify: func(cfg *Config) { cfg.FeatureConfig.EnabledFeatures = []string{"feature2", "feature1"} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := base.Clone()
+			tt.modify(next)
+
+			if same := Fingerprint(base) == Fingerprint(next); same != tt.same {
+				t.Errorf("expected the fingerprints to be the same: %v, got %v", tt.same, same)
+			}
+		})
+	}
+}
+
+func TestFingerprintFormat(t *testing.T) {
+	if fingerprint := Fingerprint(defaultConfig(t)); len(fingerprint) != 64 {
+		t.Errorf("expected a hex-encoded SHA-256, got %q", fingerprint)
+	}
+	if fingerprint := Fingerprint(nil); fingerprint != "" {
+		t.Errorf("expected an empty fingerprint for nil, got %q", fingerprint)
+	}
+}
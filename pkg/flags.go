@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/aliok/best-go-config-setup/util"
+)
+
+// BindFlags registers a flag for every leaf field of the configuration on the given flag set.
+// The flag name is the JSON path of the field with `-` instead of `_`, such as `--http-server.port`.
+// The default of the flag is the default of the field, and the usage is the Go comment of the field, if available.
+//
+// Slice fields take comma-separated values, such as `--features.enabled-features=feature3,feature4`.
+// Map fields, such as `features.flags`, can't be set with flags.
+//
+// The flags must be bound to Viper with WithFlags after parsing, so that they override the other config sources.
+func BindFlags(fs *pflag.FlagSet, cfg *Config) {
+	// the Go comments are only available when running from the root directory of the module, such as while
+	// developing. otherwise, the flags don't have a usage.
+	var comments map[string]string
+	if reflector, err := newReflector(); err == nil {
+		comments = reflector.CommentMap
+	}
+
+	bindFlags(fs, reflect.TypeOf(cfg), "", comments)
+}
+
+// bindFlags registers the flags for the fields of the given struct type, recursively.
+// This is like visitFields, but it also needs the struct type of each field to look up its Go comment.
+func bindFlags(fs *pflag.FlagSet, t reflect.Type, prefix string, comments map[string]string) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			bindFlags(fs, fieldType, path, comments)
+			continue
+		}
+
+		flagName := flagName(path)
+		usage := flagUsage(comments[t.PkgPath()+"."+t.Name()+"."+field.Name])
+		// an unresolvable environment variable reference in the default is reported when applying the defaults
+		defaultValue, _ := resolveDefault(tagDefault(field))
+
+		// Duration, ByteSize and Time are integers, but they are set as strings such as `15s`, `4MB` and
+		// `2030-01-02T15:04:05Z`
+		if fieldType == durationType || fieldType == byteSizeType || fieldType == timeType {
+			fs.String(flagName, defaultValue, usage)
+			continue
+		}
+
+		switch fieldType.Kind() {
+		case reflect.Bool:
+			fs.Bool(flagName, defaultValue == "true", usage)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i, _ := strconv.ParseInt(defaultValue, 10, 64)
+			fs.Int64(flagName, i, usage)
+		case reflect.Float32, reflect.Float64:
+			f, _ := strconv.ParseFloat(defaultValue, 64)
+			fs.Float64(flagName, f, usage)
+		case reflect.Slice:
+			// slice defaults are space-separated, see util.SplitDefaultList
+			items, _ := util.SplitDefaultList(defaultValue)
+			fs.StringSlice(flagName, items, usage)
+		case reflect.Map:
+			continue
+		default:
+			fs.String(flagName, defaultValue, usage)
+		}
+	}
+}
+
+// flagUsage converts the Go comment of a field to a single-line flag usage.
+// The backquotes are removed, as pflag would use the first backquoted word as the name of the flag value.
+func flagUsage(comment string) string {
+	usage := strings.ReplaceAll(comment, "`", "")
+	return strings.Join(strings.Fields(usage), " ")
+}
+
+// WithFlags binds the flags registered by BindFlags to Viper, so that the flags that are set override the config
+// files and the environment variables.
+func WithFlags(fs *pflag.FlagSet) LoadOption {
+	return func(o *loadOptions) {
+		o.flags = fs
+	}
+}
+
+// bindFlagsToViper binds the flags registered by BindFlags, which are set, to the keys of the fields in Viper.
+//
+// The flags that are not set are not bound, as Viper would otherwise use their defaults as the values of the fields
+// that are not set anywhere else. Their defaults are the defaults in the tags, which are applied by ApplyDefaults
+// instead. Otherwise, the fields of the tagged union variants that are not selected would be set as well.
+func bindFlagsToViper(v *viper.Viper, fs *pflag.FlagSet) {
+	visitFields(reflect.TypeOf(Config{}), "", func(path string, _ reflect.StructField) {
+		if flag := fs.Lookup(flagName(path)); flag != nil && flag.Changed {
+			// error is only returned for a nil flag
+			_ = v.BindPFlag(path, flag)
+		}
+	})
+}
+
+// flagName returns the name of the flag for the field at the given path, such as `http-server.port` for
+// `http_server.port`.
+func flagName(path string) string {
+	return strings.ReplaceAll(path, "_", "-")
+}
+
+// tagDefault returns the default value of the field from its `jsonschema` tag, such as `8080` for
+// `jsonschema:"default=8080"`. Returns an empty string if the field has no default value.
+func tagDefault(field reflect.StructField) string {
+	for _, part := range strings.Split(field.Tag.Get("jsonschema"), ",") {
+		if value, ok := strings.CutPrefix(part, "default="); ok {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package pkg
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// newFlagSet returns a flag set with the flags of the config registered and the given arguments parsed.
+func newFlagSet(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	BindFlags(fs, &Config{})
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("failed to parse the flags: %v", err)
+	}
+	return fs
+}
+
+func TestBindFlags(t *testing.T) {
+	fs := newFlagSet(t)
+
+	tests := []struct {
+		name         string
+		defaultValue string
+	}{
+		{name: "http-server.port", defaultValue: "8080"},
+		{name: "http-server.bind-address", defaultValue: "0.0.0.0"},
+		{name: "http-server.read-timeout", defaultValue: "15s"},
+		{name: "http-server.tls.enabled", defaultValue: "false"},
+		// pointer fields
+		{name: "logging.log-level", defaultValue: "2"},
+		// slice fields
+		{name: "features.enabled-features", defaultValue: "[feature1,feature2]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flag := fs.Lookup(tt.name)
+			if flag == nil {
+				t.Fatalf("expected the flag %s to be registered", tt.name)
+			}
+			if flag.DefValue != tt.defaultValue {
+				t.Errorf("expected the default %q, got %q", tt.defaultValue, flag.DefValue)
+			}
+		})
+	}
+
+	// map fields can't be set with flags
+	if fs.Lookup("features.flags") != nil {
+		t.Error("expected no flag for features.flags")
+	}
+}
+
+func TestLoadConfigWithFlags(t *testing.T) {
+	path := writeFile(t, "config.yaml", "http_server:\n  port: 8081\n  bind_address: 127.0.0.1\n")
+	fs := newFlagSet(t, "--http-server.port", "9090", "--features.enabled-features=feature3,feature4")
+
+	cfg, err := LoadConfig(path, WithFlags(fs))
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	// the flags that are set win over the file
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected port 9090 from the flag, got %d", cfg.HTTPServerConfig.Port)
+	}
+	if expected := []string{"feature3", "feature4"}; !slices.Equal(cfg.FeatureConfig.EnabledFeatures, expected) {
+		t.Errorf("expected features %v, got %v", expected, cfg.FeatureConfig.EnabledFeatures)
+	}
+	// the flags that are not set don't override the file with their defaults
+	if cfg.HTTPServerConfig.BindAddress != "127.0.0.1" {
+		t.Errorf("expected the bind address of the file 127.0.0.1, got %q", cfg.HTTPServerConfig.BindAddress)
+	}
+}
+
+func TestFlagUsage(t *testing.T) {
+	usage := flagUsage("Port is the port to listen on, such as `8080`.\nRequired.")
+	if expected := "Port is the port to listen on, such as 8080. Required."; usage != expected {
+		t.Errorf("expected %q, got %q", expected, usage)
+	}
+}
@@ -0,0 +1,35 @@
+package pkg
+
+import "sync"
+
+var (
+	globalOnce sync.Once
+	globalCfg  *Config
+	globalErr  error
+)
+
+// Init loads the global configuration using LoadConfig, for the simple programs that want to access the
+// configuration from anywhere using Get.
+//
+// The configuration is loaded only once: the later calls do nothing and return the result of the first call,
+// even if they have different arguments.
+func Init(path string, opts ...LoadOption) error {
+	globalOnce.Do(func() {
+		globalCfg, globalErr = LoadConfig(path, opts...)
+	})
+	return globalErr
+}
+
+// Get returns the global configuration loaded by Init.
+// Callers must not modify the returned Config, as it is shared.
+//
+// It panics if Init is not called yet or if it failed, as there is no configuration to return then.
+func Get() *Config {
+	if globalCfg == nil {
+		if globalErr != nil {
+			panic("pkg.Get called after pkg.Init failed: " + globalErr.Error())
+		}
+		panic("pkg.Get called before pkg.Init")
+	}
+	return globalCfg
+}
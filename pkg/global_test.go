@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// resetGlobal resets the global configuration, so that Init can be called again, see Init.
+func resetGlobal(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		globalOnce = sync.Once{}
+		globalCfg = nil
+		globalErr = nil
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// assertPanics fails the test if the given function doesn't panic with a message containing the given text.
+func assertPanics(t *testing.T, text string, fn func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		r := recover()
+		if r == nil {
+			t.Fatalf("expected a panic containing %q", text)
+		}
+		if msg, _ := r.(string); !strings.Contains(msg, text) {
+			t.Fatalf("expected a panic containing %q, got %v", text, r)
+		}
+	}()
+	fn()
+}
+
+func TestInitThenGet(t *testing.T) {
+	resetGlobal(t)
+
+	path := writeFile(t, "config.yaml", "http_server:\n  port: 9090\n")
+	if err := Init(path); err != nil {
+		t.Fatalf("failed to init: %v", err)
+	}
+	if port := Get().HTTPServerConfig.Port; port != 9090 {
+		t.Errorf("expected port 9090, got %d", port)
+	}
+
+	// the later calls don't load again
+	other := writeFile(t, "other.yaml", "http_server:\n  port: 9191\n")
+	if err := Init(other); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if port := Get().HTTPServerConfig.Port; port != 9090 {
+		t.Errorf("expected the first config to be kept, got port %d", port)
+	}
+}
+
+func TestGetBeforeInit(t *testing.T) {
+	resetGlobal(t)
+	assertPanics(t, "pkg.Get called before pkg.Init", func() { Get() })
+}
+
+func TestGetAfterFailedInit(t *testing.T) {
+	resetGlobal(t)
+
+	if err := Init(writeFile(t, "config.yaml", "http_server:\n  port: 70000\n")); err == nil {
+		t.Fatal("expected an error for the invalid config")
+	}
+	assertPanics(t, "pkg.Get called after pkg.Init failed: http_server.port", func() { Get() })
+}
@@ -0,0 +1,134 @@
+package pkg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// writeFile writes the given content to the file with the given name in a temporary directory of the test and
+// returns the path of the file.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+// readYAML reads the given YAML content into a new Viper instance.
+func readYAML(t *testing.T, content string) *viper.Viper {
+	t.Helper()
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(content)); err != nil {
+		t.Fatalf("failed to read the config: %v", err)
+	}
+	return v
+}
+
+// readConfigFile writes the given YAML content to a config file and reads it into a new Viper instance.
+func readConfigFile(t *testing.T, content string) (*viper.Viper, string) {
+	t.Helper()
+	path := writeFile(t, "config.yaml", content)
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatalf("failed to read the config: %v", err)
+	}
+	return v, path
+}
+
+// replaceFile replaces the content of the file at the given path atomically, so that a watcher never reads it
+// partially written.
+func replaceFile(t *testing.T, path, content string) {
+	t.Helper()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("failed to replace %s: %v", path, err)
+	}
+}
+
+// defaultConfig returns a blank config with the defaults applied, which is valid.
+func defaultConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg := &Config{}
+	if err := ApplyDefaults(cfg); err != nil {
+		t.Fatalf("failed to apply the defaults: %v", err)
+	}
+	return cfg
+}
+
+// mustLoadYAML loads the given YAML config with LoadConfigFromBytes and fails the test if it is not valid.
+func mustLoadYAML(t *testing.T, content string, opts ...LoadOption) *Config {
+	t.Helper()
+	cfg, err := LoadConfigFromBytes([]byte(content), "yaml", opts...)
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	return cfg
+}
+
+// assertErrorContains fails the test if the error is nil or its message doesn't contain the given text.
+func assertErrorContains(t *testing.T, err error, text string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected an error containing %q, got nil", text)
+	}
+	if !strings.Contains(err.Error(), text) {
+		t.Fatalf("expected an error containing %q, got %q", text, err.Error())
+	}
+}
+
+// validationErrorsOf returns the *ValidationError errors in the given error, which can be joined, see Validate.
+// The joined errors can be nested, such as the validation errors joined with the defaulting error by HandleConfig.
+func validationErrorsOf(err error) []*ValidationError {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var result []*ValidationError
+		for _, e := range joined.Unwrap() {
+			result = append(result, validationErrorsOf(e)...)
+		}
+		return result
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return []*ValidationError{validationErr}
+	}
+	return nil
+}
+
+// validationPaths returns the paths of the failing fields in the given validation error, see Validate.
+func validationPaths(err error) []string {
+	var paths []string
+	for _, e := range validationErrorsOf(err) {
+		paths = append(paths, e.Path)
+	}
+	return paths
+}
+
+// chdirModuleRoot changes the working directory to the root directory of the module for the test, as the Go
+// comments are read relative to it, see GenerateSchema. The tests calling it can't run in parallel.
+func chdirModuleRoot(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Dir(wd)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
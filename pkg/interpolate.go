@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ExpandEnv expands the environment variable references in the string values of the configuration read by Viper.
+// Both `${VAR}` and `$VAR` are supported. Use `$$` for a literal `$`.
+//
+// For example, `password: ${DB_PASSWORD}` in the config file becomes `password: secret` when `DB_PASSWORD=secret`.
+//
+// If requireAllEnv is true, an error listing the undefined variables is returned. Otherwise, undefined variables
+// are expanded to empty strings.
+//
+// ExpandEnv must be called after reading the config files and before unmarshalling, so that defaulting and
+// validation see the final values. Only the values in the config files are expanded and written back, so the values
+// from the other sources, such as the environment variables bound by BindEnv and the defaults, stay in their own
+// sources, see Explain.
+func ExpandEnv(v *viper.Viper, requireAllEnv bool) error {
+	missing := map[string]struct{}{}
+	mapping := func(name string) string {
+		// `$$` is passed as `$`, which is the escape for a literal `$`
+		if name == "$" {
+			return "$"
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing[name] = struct{}{}
+		}
+		return value
+	}
+
+	expanded := map[string]interface{}{}
+	for key, value := range fileSettings(v) {
+		// the unchanged values are not written back, so that they keep their source
+		if expandedValue := expandValue(value, mapping); !reflect.DeepEqual(expandedValue, value) {
+			expanded[key] = expandedValue
+		}
+	}
+
+	if requireAllEnv && len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("undefined environment variables referenced in the config: %s", strings.Join(names, ", "))
+	}
+
+	if len(expanded) == 0 {
+		return nil
+	}
+	return v.MergeConfigMap(expanded)
+}
+
+// fileSettings returns the top-level settings in the config files read by Viper, without the values from the other
+// sources, such as the environment variables and the defaults. Viper doesn't expose the settings of the config files,
+// but it returns a top-level section, such as `database`, from the config files as it is, as the other sources are
+// bound to the fields within the sections.
+func fileSettings(v *viper.Viper) map[string]interface{} {
+	settings := map[string]interface{}{}
+	for _, key := range v.AllKeys() {
+		section, _, _ := strings.Cut(key, ".")
+		if _, ok := settings[section]; !ok && v.InConfig(section) {
+			settings[section] = v.Get(section)
+		}
+	}
+	return settings
+}
+
+// expandValue expands the environment variable references in the strings in the given value, recursively.
+func expandValue(value interface{}, mapping func(string) string) interface{} {
+	switch val := value.(type) {
+	case string:
+		return os.Expand(val, mapping)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[k] = expandValue(item, mapping)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(val))
+		for i, item := range val {
+			s[i] = expandValue(item, mapping)
+		}
+		return s
+	default:
+		return value
+	}
+}
@@ -0,0 +1,129 @@
+package pkg
+
+import (
+	"os"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           map[string]string
+		requireAllEnv bool
+		value         string
+		expected      string
+		wantErr       string
+	}{
+		{
+			name:     "braced reference",
+			env:      map[string]string{"TEST_DB_PASSWORD": "secret"},
+			value:    "${TEST_DB_PASSWORD}",
+			expected: "secret",
+		},
+		{
+			name:     "plain reference within text",
+			env:      map[string]string{"TEST_DB_USER": "admin"},
+			value:    "user-$TEST_DB_USER",
+			expected: "user-admin",
+		},
+		{
+			name:     "unset variable is empty",
+			value:    "pre-${TEST_UNSET_VARIABLE}-post",
+			expected: "pre--post",
+		},
+		{
+			name:          "unset variable is an error when required",
+			requireAllEnv: true,
+			value:         "${TEST_UNSET_VARIABLE} ${TEST_OTHER_UNSET_VARIABLE}",
+			wantErr:       "TEST_OTHER_UNSET_VARIABLE, TEST_UNSET_VARIABLE",
+		},
+		{
+			name:     "escaped dollar sign",
+			env:      map[string]string{"TEST_DB_PASSWORD": "secret"},
+			value:    "pa$$word-$${TEST_DB_PASSWORD}",
+			expected: "pa$word-${TEST_DB_PASSWORD}",
+		},
+		{
+			name:          "escaped dollar sign is not an undefined variable",
+			requireAllEnv: true,
+			value:         "pa$$word",
+			expected:      "pa$word",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			v := readYAML(t, "database:\n  password: '"+tt.value+"'\nfeatures:\n  enabled_features:\n    - '"+tt.value+"'\n")
+
+			err := ExpandEnv(v, tt.requireAllEnv)
+			if tt.wantErr != "" {
+				assertErrorContains(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := v.GetString("database.password"); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+			// the strings in the slices are expanded as well
+			if got := v.GetStringSlice("features.enabled_features"); len(got) != 1 || got[0] != tt.expected {
+				t.Errorf("expected [%q], got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadConfigExpandsEnv(t *testing.T) {
+	// the expanded value is defaulted and validated, so an invalid port is reported
+	t.Setenv("TEST_HTTP_PORT", "9090")
+	cfg := mustLoadYAML(t, "http_server:\n  port: ${TEST_HTTP_PORT}\n")
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected port 9090, got %d", cfg.HTTPServerConfig.Port)
+	}
+
+	t.Setenv("TEST_HTTP_PORT", "70000")
+	_, err := LoadConfigFromBytes([]byte("http_server:\n  port: ${TEST_HTTP_PORT}\n"), "yaml")
+	assertErrorContains(t, err, "http_server.port")
+
+	_, err = LoadConfigFromBytes([]byte("http_server:\n  bind_address: ${TEST_UNSET_VARIABLE}\n"), "yaml", WithRequireEnv(true))
+	assertErrorContains(t, err, "TEST_UNSET_VARIABLE")
+}
+
+func TestExpandEnvOnlyConfigFiles(t *testing.T) {
+	t.Setenv("TEST_DB_PASSWORD", "secret")
+	t.Setenv("APP_DATABASE_USER", "admin")
+
+	v := readYAML(t, "database:\n  password: ${TEST_DB_PASSWORD}\n  host: db.local\n")
+	BindEnv(v, "APP")
+	v.SetDefault("database.name", "app")
+
+	if err := ExpandEnv(v, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := v.GetString("database.password"); got != "secret" {
+		t.Errorf("expected the expanded password secret, got %q", got)
+	}
+
+	// the environment variables and the defaults are not written into the config files
+	for _, key := range []string{"database.user", "database.name"} {
+		if v.InConfig(key) {
+			t.Errorf("expected %s not to be in the config", key)
+		}
+	}
+	sources := Explain(v, defaultConfig(t))
+	if sources["database.user"] != SourceEnv || sources["database.name"] != SourceDefault || sources["database.host"] != SourceFile {
+		t.Errorf("unexpected sources: user %s, name %s, host %s", sources["database.user"], sources["database.name"], sources["database.host"])
+	}
+
+	// so the unset environment variable is not kept
+	if err := os.Unsetenv("APP_DATABASE_USER"); err != nil {
+		t.Fatal(err)
+	}
+	if got := v.GetString("database.user"); got != "" {
+		t.Errorf("expected no user after unsetting the environment variable, got %q", got)
+	}
+}
@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// LintRule is a check for the settings that are valid but likely a mistake, such as binding to all network
+// interfaces without TLS. Unlike the validation, the findings of the rules don't make the config unusable.
+type LintRule struct {
+	// Name is the name of the rule, such as `bind-all-without-tls`.
+	Name string
+
+	// Check returns the findings of the rule for the configuration loaded from the given file.
+	// The file is empty if the configuration is not loaded from a file.
+	Check func(cfg *Config, file string) []Warning
+}
+
+// LintFinding is a finding of a LintRule.
+type LintFinding struct {
+	Warning
+
+	// Rule is the name of the rule that reported the finding.
+	Rule string
+}
+
+func (f LintFinding) String() string {
+	return f.Warning.String() + " (" + f.Rule + ")"
+}
+
+// DefaultLintRules are the lint rules used by the `configlint` command.
+var DefaultLintRules = []LintRule{
+	{Name: "bind-all-without-tls", Check: lintBindAllWithoutTLS},
+	{Name: "debug-log-in-prod", Check: lintDebugLogInProd},
+	{Name: "no-enabled-features", Check: lintNoEnabledFeatures},
+	{Name: "cors-any-origin", Check: lintCORSAnyOrigin},
+}
+
+// Lint runs the given rules on the configuration loaded from the given file and returns their findings,
+// in the order of the rules.
+func Lint(cfg *Config, file string, rules []LintRule) []LintFinding {
+	var findings []LintFinding
+	for _, rule := range rules {
+		for _, warning := range rule.Check(cfg, file) {
+			findings = append(findings, LintFinding{Warning: warning, Rule: rule.Name})
+		}
+	}
+	return findings
+}
+
+// lintBindAllWithoutTLS reports the HTTP server reachable from every network without TLS.
+func lintBindAllWithoutTLS(cfg *Config, _ string) []Warning {
+	if !isAllInterfaces(cfg.HTTPServerConfig.BindAddress) || cfg.HTTPServerConfig.TLSConfig.Enabled {
+		return nil
+	}
+	return []Warning{{
+		Path:     "http_server.bind_address",
+		Message:  "binds to all network interfaces without TLS, enable TLS or terminate it in front of the server",
+		Severity: SeverityWarning,
+	}}
+}
+
+// lintDebugLogInProd reports the debug log level in the production configs, detected from the file name,
+// such as `app-config.prod.yaml`. The debug logs are verbose and may contain sensitive data.
+func lintDebugLogInProd(cfg *Config, file string) []Warning {
+	if !strings.Contains(strings.ToLower(filepath.Base(file)), "prod") {
+		return nil
+	}
+	if cfg.LoggingConfig.LogLevel == nil || *cfg.LoggingConfig.LogLevel > -1 {
+		return nil
+	}
+	return []Warning{{
+		Path:     "logging.log_level",
+		Message:  "debug logs are enabled in a production config",
+		Severity: SeverityWarning,
+	}}
+}
+
+// lintNoEnabledFeatures reports the configs without any enabled features, which is usually an incomplete config.
+func lintNoEnabledFeatures(cfg *Config, _ string) []Warning {
+	if len(cfg.FeatureConfig.EnabledFeatures) > 0 || slices.Contains(slices.Collect(maps.Values(cfg.FeatureConfig.Flags)), true) {
+		return nil
+	}
+	return []Warning{{
+		Path:     "features",
+		Message:  "no features are enabled",
+		Severity: SeverityInfo,
+	}}
+}
+
+// lintCORSAnyOrigin reports allowing the cross-origin requests from any origin.
+func lintCORSAnyOrigin(cfg *Config, _ string) []Warning {
+	if !slices.Contains(cfg.HTTPServerConfig.CORSConfig.AllowedOrigins, "*") {
+		return nil
+	}
+	return []Warning{{
+		Path:     "http_server.cors.allowed_origins",
+		Message:  "cross-origin requests are allowed from any origin",
+		Severity: SeverityInfo,
+	}}
+}
@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLintRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		file     string
+		expected []string
+	}{
+		{
+			name:    "clean config",
+			content: "http_server:\n  bind_address: 127.0.0.1\n",
+			file:    "app-config.prod.yaml",
+		},
+		{
+			name:     "bind to all network interfaces without TLS",
+			content:  "http_server:\n  bind_address: 0.0.0.0\n",
+			expected: []string{"bind-all-without-tls"},
+		},
+		{
+			name:     "debug logs in a production config",
+			content:  "http_server:\n  bind_address: 127.0.0.1\nlogging:\n  log_level: -1\n",
+			file:     "/etc/app/app-config.PROD.yaml",
+			expected: []string{"debug-log-in-prod"},
+		},
+		{
+			name:    "debug logs in a development config",
+			content: "http_server:\n  bind_address: 127.0.0.1\nlogging:\n  log_level: -1\n",
+			file:    "app-config.dev.yaml",
+		},
+		{
+			name:     "no enabled features",
+			content:  "http_server:\n  bind_address: 127.0.0.1\nfeatures:\n  enabled_features: []\n  flags:\n    beta: false\n",
+			expected: []string{"no-enabled-features"},
+		},
+		{
+			name:    "features enabled by the flags",
+			content: "http_server:\n  bind_address: 127.0.0.1\nfeatures:\n  enabled_features: []\n  flags:\n    beta: true\n",
+		},
+		{
+			name:     "cors from any origin",
+			content:  "http_server:\n  bind_address: 0.0.0.0\n  cors:\n    allowed_origins: [\"*\"]\n",
+			expected: []string{"bind-all-without-tls", "cors-any-origin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := mustLoadYAML(t, tt.content)
+
+			var rules []string
+			for _, finding := range Lint(cfg, tt.file, DefaultLintRules) {
+				rules = append(rules, finding.Rule)
+			}
+			if !slices.Equal(rules, tt.expected) {
+				t.Errorf("expected the findings of the rules %v, got %v", tt.expected, rules)
+			}
+		})
+	}
+}
+
+func TestLintFindingString(t *testing.T) {
+	cfg := mustLoadYAML(t, "http_server:\n  bind_address: 0.0.0.0\n")
+	rules := []LintRule{{Name: "bind-all-without-tls", Check: lintBindAllWithoutTLS}}
+
+	findings := Lint(cfg, "", rules)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %v", findings)
+	}
+	expected := "warning: http_server.bind_address: binds to all network interfaces without TLS, enable TLS or terminate it in front of the server (bind-all-without-tls)"
+	if s := findings[0].String(); s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+}
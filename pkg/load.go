@@ -0,0 +1,747 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// ErrReadConfig is returned when a config source can't be read, such as a missing or malformed config file.
+var ErrReadConfig = errors.New("failed to read config")
+
+// DefaultEnvPrefix is the prefix of the environment variables that override the config, such as `APP_HTTP_SERVER_PORT`.
+const DefaultEnvPrefix = "APP"
+
+// loadOptions are the options for LoadConfig.
+type loadOptions struct {
+	overlays    []string
+	stdin       io.Reader
+	stdinType   string
+	strict      bool
+	requireEnv  bool
+	envPrefix   string
+	warn        func(warning string)
+	flags       *pflag.FlagSet
+	searchPaths []string
+	handleOpts  []HandleOption
+	defaults    map[string]interface{}
+	profile     string
+	environment string
+
+	decryptionKey []byte
+	httpClient    *http.Client
+	urlType       string
+
+	// stats is not an option, but collects the stats of the loading, see LoadConfigWithStats
+	stats *LoadStats
+}
+
+// LoadOption is an option for LoadConfig.
+type LoadOption func(*loadOptions)
+
+// WithOverlays sets the config files to merge on top of the main config file, in order.
+//
+// Merging happens at the key level: a later file overriding `http_server.port` keeps `http_server.bind_address`
+// from the earlier files.
+// Slices, such as `features.enabled_features`, are values and not merged: a later file fully replaces the slice.
+func WithOverlays(paths ...string) LoadOption {
+	return func(o *loadOptions) {
+		o.overlays = paths
+	}
+}
+
+// WithStdin sets the reader and the config type (yaml, json or toml) for the config path `-`.
+// By default, the config is read from os.Stdin as yaml.
+func WithStdin(r io.Reader, configType string) LoadOption {
+	return func(o *loadOptions) {
+		o.stdin = r
+		o.stdinType = configType
+	}
+}
+
+// WithStrict enables the strict mode, which rejects the unknown keys in the config files. See StrictUnmarshal.
+func WithStrict(strict bool) LoadOption {
+	return func(o *loadOptions) {
+		o.strict = strict
+	}
+}
+
+// WithRequireEnv makes loading fail if the config references undefined environment variables. See ExpandEnv.
+func WithRequireEnv(requireEnv bool) LoadOption {
+	return func(o *loadOptions) {
+		o.requireEnv = requireEnv
+	}
+}
+
+// WithEnvPrefix sets the prefix of the environment variables that override the config. See BindEnv.
+// DefaultEnvPrefix is used by default.
+func WithEnvPrefix(prefix string) LoadOption {
+	return func(o *loadOptions) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithWarnFunc sets the function that is called with the warnings about the config, such as the use of deprecated
+// fields, see WarnDeprecated. By default, the warnings are logged with the standard logger.
+func WithWarnFunc(warn func(warning string)) LoadOption {
+	return func(o *loadOptions) {
+		o.warn = warn
+	}
+}
+
+// WithSearchPaths sets the directories to search the default config file in, in order, when no config path is given.
+// DefaultSearchPaths is used by default.
+func WithSearchPaths(dirs ...string) LoadOption {
+	return func(o *loadOptions) {
+		o.searchPaths = dirs
+	}
+}
+
+// WithHandleOptions sets the options for defaulting and validating the config, such as WithValidator.
+// See HandleConfig.
+func WithHandleOptions(opts ...HandleOption) LoadOption {
+	return func(o *loadOptions) {
+		o.handleOpts = append(o.handleOpts, opts...)
+	}
+}
+
+// WithDefaults overrides the defaults of the fields at the given JSON paths, such as `http_server.port`, without
+// changing the tags. This is useful for the libraries embedding the configuration, which need different defaults.
+//
+// Precedence is: the defaults in the tags < the defaults of the environment, see WithEnvironment < these defaults <
+// the config files < environment variables < flags.
+// An error is returned from the loading if a path is not a config field.
+func WithDefaults(defaults map[string]interface{}) LoadOption {
+	return func(o *loadOptions) {
+		if o.defaults == nil {
+			o.defaults = map[string]interface{}{}
+		}
+		for path, value := range defaults {
+			o.defaults[path] = value
+		}
+	}
+}
+
+// LoadConfig loads the configuration, applies the defaults and validates it.
+//
+// The config is read from the file at the given path, whose type is detected from its extension, see configType.
+// The path `-` means reading the config from stdin, see WithStdin.
+// The HTTP(S) URLs, such as `https://config.example/app.yaml`, are fetched, see LoadConfigURL.
+// If the path is empty, app-config.{yaml,yml,json,toml} is searched in the directories returned by
+// DefaultSearchPaths, in order, and the first one found is used. The search paths can be changed with WithSearchPaths.
+// If no config file is found, only the defaults and the environment variables are used.
+//
+// The environment variable references in the config values are expanded, see ExpandEnv, and the config fields can be
+// overridden with environment variables, see BindEnv. The `enc:` values are decrypted, see WithDecryptionKey.
+//
+// When a field is set in multiple sources, the value with the highest precedence is used. From the highest to the
+// lowest, the sources are:
+//
+//  1. the flags that are set, see WithFlags
+//  2. the environment variables, see BindEnv and BindEnvTags
+//  3. the config files, where the later ones override the earlier ones, such as the overlays, see WithOverlays
+//  4. the programmatic defaults, see WithDefaults
+//  5. the defaults of the environment, such as `prod`, see WithEnvironment
+//  6. the defaults in the tags, see ApplyDefaults
+//
+// Errors reading the config sources wrap ErrReadConfig.
+func LoadConfig(path string, opts ...LoadOption) (*Config, error) {
+	return LoadConfigContext(context.Background(), path, opts...)
+}
+
+// LoadConfigContext is like LoadConfig, but stops loading when the given context is cancelled.
+// The context is checked between the loading steps, such as before reading each config source,
+// and the error of the context, such as context.Canceled, is returned.
+func LoadConfigContext(ctx context.Context, path string, opts ...LoadOption) (*Config, error) {
+	cfg, _, err := loadConfig(ctx, path, opts)
+	return cfg, err
+}
+
+// LoadStats are the stats of loading the configuration, returned by LoadConfigWithStats.
+type LoadStats struct {
+	// ReadDuration is the time taken to read the config sources, including expanding the environment variables.
+	ReadDuration time.Duration
+
+	// UnmarshalDuration is the time taken to unmarshal the config into a Config.
+	UnmarshalDuration time.Duration
+
+	// DefaultDuration is the time taken to apply the defaults.
+	DefaultDuration time.Duration
+
+	// ValidateDuration is the time taken to validate the config.
+	ValidateDuration time.Duration
+
+	// SourceBytes is the total size of the config sources, such as the config files, in bytes.
+	SourceBytes int64
+}
+
+// LoadConfigWithStats is like LoadConfig, but returns the stats of the loading as well, such as the time taken by
+// each stage. This helps diagnosing slow startups. The stats are returned even if loading fails, covering the stages
+// run until the failure.
+func LoadConfigWithStats(path string, opts ...LoadOption) (*Config, *LoadStats, error) {
+	return loadConfig(context.Background(), path, opts)
+}
+
+func loadConfig(ctx context.Context, path string, opts []LoadOption) (*Config, *LoadStats, error) {
+	options := newLoadOptions(opts)
+	v := viper.New()
+
+	var paths []string
+	if path != "" {
+		paths = append(paths, path)
+	} else if defaultPath, found := searchDefaultConfig(options.searchPaths); found {
+		paths = append(paths, defaultPath)
+	} else {
+		// ok to not have a config file
+		log.Printf("No default config file found, going to use defaults")
+	}
+	paths = append(paths, options.overlays...)
+
+	start := time.Now()
+	if err := readConfigs(ctx, v, paths, false, options); err != nil {
+		return nil, options.stats, err
+	}
+	options.stats.ReadDuration = time.Since(start)
+
+	cfg, err := load(ctx, v, options)
+	return cfg, options.stats, err
+}
+
+// LoadConfigFromBytes is like LoadConfig, but reads the config from the given data of the given type
+// (yaml, json or toml) instead of a file. This is useful for configs embedded with `go:embed`.
+func LoadConfigFromBytes(data []byte, configType string, opts ...LoadOption) (*Config, error) {
+	options := newLoadOptions(opts)
+	v := viper.New()
+
+	if !isSupportedConfigType(configType) {
+		return nil, fmt.Errorf("%w: unsupported config type %q, must be one of yaml, json, toml", ErrReadConfig, configType)
+	}
+	v.SetConfigType(configType)
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReadConfig, err)
+	}
+
+	ctx := context.Background()
+	if err := readConfigs(ctx, v, options.overlays, true, options); err != nil {
+		return nil, err
+	}
+	return load(ctx, v, options)
+}
+
+// LoadConfigDir is like LoadConfig, but reads the config from a directory with a file per config key, such as a
+// mounted Kubernetes ConfigMap. The file name is the dotted path of the key, such as `http_server.port`, and the
+// content of the file is the value, such as `8080`. Slices can be set using comma-separated values, such as
+// `feature3,feature4` in `features.enabled_features`.
+//
+// Hidden files, such as the `..data` link Kubernetes creates, and subdirectories are ignored.
+func LoadConfigDir(dir string, opts ...LoadOption) (*Config, error) {
+	options := newLoadOptions(opts)
+	v := viper.New()
+
+	settings, err := readConfigDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w directory %s: %w", ErrReadConfig, dir, err)
+	}
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, fmt.Errorf("%w directory %s: %w", ErrReadConfig, dir, err)
+	}
+
+	ctx := context.Background()
+	if err := readConfigs(ctx, v, options.overlays, true, options); err != nil {
+		return nil, err
+	}
+	return load(ctx, v, options)
+}
+
+// readConfigDir reads the key files in the given directory into a nested map, such as
+// `{"http_server": {"port": "8080"}}` for the file `http_server.port`.
+// The values are strings, which are converted to the field types when unmarshalling.
+func readConfigDir(dir string) (map[string]interface{}, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := map[string]interface{}{}
+	for _, entry := range entries {
+		key := entry.Name()
+		if strings.HasPrefix(key, ".") {
+			continue
+		}
+
+		// the keys of a ConfigMap are links to the files in a hidden directory, so follow the links
+		path := filepath.Join(dir, key)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		value := strings.TrimRight(string(data), "\r\n")
+
+		// build the nested maps for the dotted key
+		parts := strings.Split(key, ".")
+		m := settings
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := m[part].(map[string]interface{})
+			if !ok {
+				if _, exists := m[part]; exists {
+					return nil, fmt.Errorf("key %s conflicts with a value set by another file", key)
+				}
+				child = map[string]interface{}{}
+				m[part] = child
+			}
+			m = child
+		}
+		last := parts[len(parts)-1]
+		if _, exists := m[last]; exists {
+			return nil, fmt.Errorf("key %s conflicts with the keys set by other files", key)
+		}
+		m[last] = value
+	}
+	return settings, nil
+}
+
+func newLoadOptions(opts []LoadOption) *loadOptions {
+	options := &loadOptions{
+		stdin:       os.Stdin,
+		stdinType:   "yaml",
+		envPrefix:   DefaultEnvPrefix,
+		searchPaths: DefaultSearchPaths(),
+		warn: func(warning string) {
+			log.Printf("Warning: %s", warning)
+		},
+		stats: &LoadStats{},
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// setDefaults sets the defaults of Viper for the given paths, see WithDefaults.
+// The paths must be the config fields, or the keys of the map fields, such as `features.flags.tracing`.
+func setDefaults(v *viper.Viper, defaults map[string]interface{}) error {
+	known := map[string]bool{}
+	var maps []string
+	visitFields(reflect.TypeOf(Config{}), "", func(path string, field reflect.StructField) {
+		known[path] = true
+		if field.Type.Kind() == reflect.Map {
+			maps = append(maps, path+".")
+		}
+	})
+
+	for path, value := range defaults {
+		if !known[path] && !slices.ContainsFunc(maps, func(prefix string) bool { return strings.HasPrefix(path, prefix) }) {
+			return fmt.Errorf("unknown config field %q in the defaults", path)
+		}
+		v.SetDefault(path, value)
+	}
+	return nil
+}
+
+// load builds the configuration from the config sources read by Viper.
+// It expands the environment variable references, binds the environment variable overrides, unmarshals the config,
+// applies the defaults and validates it.
+func load(ctx context.Context, v *viper.Viper, options *loadOptions) (*Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+
+	// merge the selected profile, such as `prod`, over the base config, before anything else sees the config
+	if err := applyProfile(v, options); err != nil {
+		return nil, err
+	}
+
+	// expand the environment variable references in the config values, such as `password: ${DB_PASSWORD}`
+	if err := ExpandEnv(v, options.requireEnv); err != nil {
+		return nil, err
+	}
+
+	// the programmatic defaults are set after expanding, so that they stay below the config files in precedence.
+	// the defaults of the environment, such as `prod`, are set first, so that the programmatic ones override them
+	if err := applyEnvironmentDefaults(v, options); err != nil {
+		return nil, err
+	}
+	if err := setDefaults(v, options.defaults); err != nil {
+		return nil, err
+	}
+
+	// override the config with environment variables, such as `APP_HTTP_SERVER_PORT=9090`.
+	// environment variables take precedence over the values in the config file.
+	// fields with an `env` tag use the name in the tag instead, such as `PORT=9090`
+	BindEnv(v, options.envPrefix)
+
+	// flags take precedence over the environment variables, such as `--http-server.port=9090`
+	if options.flags != nil {
+		bindFlagsToViper(v, options.flags)
+	}
+	options.stats.ReadDuration += time.Since(start)
+
+	// in strict mode, unknown keys in the config files, such as typos, are reported as errors
+	unmarshalFunc := Unmarshal
+	if options.strict {
+		unmarshalFunc = StrictUnmarshal
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	start = time.Now()
+	cfg := &Config{}
+	if err := unmarshalFunc(v, cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	// decrypt the `enc:` values, such as `password: enc:...`, wherever they come from, see WithDecryptionKey
+	key, err := decryptionKey(options)
+	if err != nil {
+		return nil, err
+	}
+	if err := decryptConfig(cfg, key); err != nil {
+		return nil, err
+	}
+	options.stats.UnmarshalDuration = time.Since(start)
+
+	for _, warning := range WarnDeprecated(v, cfg) {
+		options.warn(warning)
+	}
+
+	// set default values for the configuration and validate it.
+	// this is what HandleConfig does, but the stages are timed separately.
+	// validate even if defaulting fails, so that the user sees all the problems at once
+	start = time.Now()
+	defaultErr := ApplyDefaults(cfg, options.handleOpts...)
+	options.stats.DefaultDuration = time.Since(start)
+	if defaultErr != nil && newHandleOptions(options.handleOpts).failFast {
+		return nil, defaultErr
+	}
+
+	start = time.Now()
+	validateErr := Validate(cfg, options.handleOpts...)
+	options.stats.ValidateDuration = time.Since(start)
+
+	if err := errors.Join(defaultErr, validateErr); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// readConfigs reads the configs at the given paths into Viper.
+// The first one is read with ReadInConfig and the rest are merged on top of it in order.
+// If merge is true, all of them are merged on top of the config that is already read.
+func readConfigs(ctx context.Context, v *viper.Viper, paths []string, merge bool, options *loadOptions) error {
+	stdinRead := false
+	for i, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if path == "-" {
+			if stdinRead {
+				return fmt.Errorf("%w: stdin can only be used once as a config source", ErrReadConfig)
+			}
+			stdinRead = true
+
+			n, err := readConfigStdin(v, options.stdin, options.stdinType, merge || i > 0)
+			if err != nil {
+				return err
+			}
+			options.stats.SourceBytes += int64(n)
+			continue
+		}
+
+		if isConfigURL(path) {
+			n, err := readConfigURL(ctx, v, path, merge || i > 0, options)
+			if err != nil {
+				return err
+			}
+			options.stats.SourceBytes += int64(n)
+			continue
+		}
+
+		log.Printf("Using config file: %s", path)
+
+		cfgType, err := configType(path)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrReadConfig, err)
+		}
+		v.SetConfigFile(path)
+		v.SetConfigType(cfgType)
+
+		if info, err := os.Stat(path); err == nil {
+			options.stats.SourceBytes += info.Size()
+		}
+
+		// an empty config file has no values, so only the defaults are used.
+		// the parsers of some types, such as JSON, would fail on it otherwise.
+		if empty, err := isEmptyFile(path); err == nil && empty {
+			log.Printf("Config file %s is empty, skipping it", path)
+			continue
+		}
+
+		if merge || i > 0 {
+			err = v.MergeInConfig()
+		} else {
+			err = v.ReadInConfig()
+		}
+		if err != nil {
+			return fmt.Errorf("%w file %s: %w", ErrReadConfig, path, err)
+		}
+		log.Printf("Read config file: %s", path)
+	}
+	return nil
+}
+
+// readConfigStdin reads the config of the given type from stdin into Viper and returns the number of bytes read.
+// If merge is true, the config is merged on top of the already read config.
+func readConfigStdin(v *viper.Viper, stdin io.Reader, stdinType string, merge bool) (int, error) {
+	log.Printf("Using config from stdin")
+
+	if !isSupportedConfigType(stdinType) {
+		return 0, fmt.Errorf("%w: unsupported config type %q for stdin, must be one of yaml, json, toml", ErrReadConfig, stdinType)
+	}
+
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return 0, fmt.Errorf("%w from stdin: %w", ErrReadConfig, err)
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		log.Printf("Config from stdin is empty, skipping it")
+		return len(data), nil
+	}
+
+	v.SetConfigType(stdinType)
+	if merge {
+		err = v.MergeConfig(bytes.NewReader(data))
+	} else {
+		err = v.ReadConfig(bytes.NewReader(data))
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w from stdin: %w", ErrReadConfig, err)
+	}
+	log.Printf("Read config from stdin")
+	return len(data), nil
+}
+
+// isEmptyFile returns true if the file at the given path is empty or only has whitespace.
+// Errors reading the file are returned, so that the caller can report them as usual.
+func isEmptyFile(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	return len(bytes.TrimSpace(data)) == 0, nil
+}
+
+// configTypes maps the supported config file extensions to the Viper config types.
+// The order of the extensions is the order of the default config file search.
+var configTypes = []struct {
+	ext     string
+	cfgType string
+}{
+	{".yaml", "yaml"},
+	{".yml", "yaml"},
+	{".json", "json"},
+	{".toml", "toml"},
+}
+
+// configType detects the config type from the extension of the config file.
+func configType(path string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, t := range configTypes {
+		if t.ext == ext {
+			return t.cfgType, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported config file extension %q for %s, must be one of .yaml, .yml, .json, .toml", ext, path)
+}
+
+func isSupportedConfigType(cfgType string) bool {
+	for _, t := range configTypes {
+		if t.cfgType == cfgType {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultSearchPaths returns the directories to search the default config file in, in order of precedence:
+//
+//   - the current directory
+//   - `$XDG_CONFIG_HOME/app`, or `$HOME/.config/app` if `XDG_CONFIG_HOME` is not set
+//   - `/etc/app`
+//   - `$HOME/.app`
+//
+// The directories under the home directory are skipped if it can't be determined.
+func DefaultSearchPaths() []string {
+	paths := []string{"."}
+
+	home, homeErr := os.UserHomeDir()
+	if xdgConfigHome := os.Getenv("XDG_CONFIG_HOME"); xdgConfigHome != "" {
+		paths = append(paths, filepath.Join(xdgConfigHome, "app"))
+	} else if homeErr == nil {
+		paths = append(paths, filepath.Join(home, ".config", "app"))
+	}
+
+	paths = append(paths, filepath.Join("/etc", "app"))
+
+	if homeErr == nil {
+		paths = append(paths, filepath.Join(home, ".app"))
+	}
+	return paths
+}
+
+// searchDefaultConfig looks for the default config file in the given directories, in order, see findDefaultConfig.
+func searchDefaultConfig(dirs []string) (string, bool) {
+	for _, dir := range dirs {
+		if path, found := findDefaultConfig(dir); found {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// findDefaultConfig looks for the default config file, app-config.{yaml,yml,json,toml}, in the given directory.
+func findDefaultConfig(dir string) (string, bool) {
+	for _, t := range configTypes {
+		path := filepath.Join(dir, "app-config"+t.ext)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// Unmarshal unmarshals the configuration read by Viper into the given Config.
+// Viper is configured to use the `json` tag, so that the same tags are used for Viper, JSON and YAML.
+// Configs of older versions are migrated to the current version first, see Migrate.
+// The string fields can be read from files, such as `password_file: /run/secrets/db` for `password`.
+// Defaults are not applied and the configuration is not validated; see HandleConfig for that.
+func Unmarshal(v *viper.Viper, cfg *Config) error {
+	return unmarshal(v, cfg, decoderConfigOption)
+}
+
+// StrictUnmarshal is like Unmarshal, but returns an error listing the unknown keys in the config files,
+// such as the misspelled `htpp_server`. Otherwise, unknown keys would be silently ignored.
+//
+// Only the keys in the config files are checked. Keys that are only set via environment variables or Viper defaults
+// don't count as unknown.
+func StrictUnmarshal(v *viper.Viper, cfg *Config) error {
+	// mapstructure's ErrorUnused option would complain about every unused key, regardless of where it comes from.
+	// instead, we collect the unused keys and filter the ones from the config files.
+	var metadata mapstructure.Metadata
+	metadataOption := func(dc *mapstructure.DecoderConfig) {
+		dc.Metadata = &metadata
+	}
+	if err := unmarshal(v, cfg, decoderConfigOption, metadataOption); err != nil {
+		return err
+	}
+
+	var unknown []string
+	for _, key := range metadata.Unused {
+		if v.InConfig(key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown keys in the config: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// unmarshal migrates the configuration read by Viper and decodes it into the given Config.
+// It mimics viper.Unmarshal, which can't be used as it decodes the settings as they are, without migrating them.
+func unmarshal(v *viper.Viper, cfg *Config, opts ...viper.DecoderConfigOption) error {
+	settings, err := configSettings(v)
+	if err != nil {
+		return err
+	}
+
+	// same defaults as Viper
+	dc := &mapstructure.DecoderConfig{
+		Result:           cfg,
+		WeaklyTypedInput: true,
+	}
+	for _, opt := range opts {
+		opt(dc)
+	}
+	decoder, err := mapstructure.NewDecoder(dc)
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(settings)
+}
+
+// configSettings returns the settings read by Viper in the structure of Config: migrated to the current version, with
+// the profiles removed and the `_file` keys replaced with the values read from the files.
+func configSettings(v *viper.Viper) (map[string]interface{}, error) {
+	settings, err := Migrate(v.AllSettings())
+	if err != nil {
+		return nil, err
+	}
+	// the profiles are already merged by the loader, see WithProfile
+	delete(settings, profilesKey)
+	// read the values of the `_file` keys, such as `password_file`, see readSecretFiles
+	if err := readSecretFiles(settings, reflect.TypeOf(Config{}), ""); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// decoderConfigOption configures viper to use the `json` tag and the decode hooks for the custom config types
+func decoderConfigOption(dc *mapstructure.DecoderConfig) {
+	dc.TagName = "json"
+	dc.DecodeHook = mapstructure.ComposeDecodeHookFunc(
+		// parse strings into the types implementing encoding.TextUnmarshaler, such as Duration
+		mapstructure.TextUnmarshallerHookFunc(),
+		// the unquoted timestamps in YAML, which are parsed into time.Time, for the Time fields
+		timeHookFunc(),
+		// Viper's default hooks, with the items of the comma-separated slices trimmed
+		mapstructure.StringToTimeDurationHookFunc(),
+		stringToSliceHookFunc(","),
+	)
+}
+
+// stringToSliceHookFunc splits the strings into slices, such as `a,b,c` from an environment variable into
+// `["a", "b", "c"]`. Unlike mapstructure.StringToSliceHookFunc, the spaces around the items are trimmed and
+// the empty items are dropped, so `a, b,` is split into `["a", "b"]` as well.
+func stringToSliceHookFunc(sep string) mapstructure.DecodeHookFuncKind {
+	return func(from reflect.Kind, to reflect.Kind, data interface{}) (interface{}, error) {
+		if from != reflect.String || to != reflect.Slice {
+			return data, nil
+		}
+
+		items := make([]string, 0)
+		for _, item := range strings.Split(data.(string), sep) {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+}
@@ -0,0 +1,703 @@
+package pkg
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigOverlays(t *testing.T) {
+	base := writeFile(t, "base.yaml", `
+http_server:
+  port: 8081
+  bind_address: 127.0.0.1
+features:
+  enabled_features:
+    - feature1
+    - feature2
+    - feature3
+logging:
+  log_format: pretty
+`)
+	overlay := writeFile(t, "overlay.yaml", `
+http_server:
+  port: 9091
+features:
+  enabled_features:
+    - feature4
+`)
+
+	cfg, err := LoadConfig(base, WithOverlays(overlay))
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+
+	// the overlay wins for the keys it sets
+	if cfg.HTTPServerConfig.Port != 9091 {
+		t.Errorf("expected the port of the overlay 9091, got %d", cfg.HTTPServerConfig.Port)
+	}
+	// the sections are merged at the key level, so the other keys of the base are kept
+	if cfg.HTTPServerConfig.BindAddress != "127.0.0.1" {
+		t.Errorf("expected the bind address of the base 127.0.0.1, got %q", cfg.HTTPServerConfig.BindAddress)
+	}
+	if cfg.LoggingConfig.LogFormat != "pretty" {
+		t.Errorf("expected the log format of the base pretty, got %q", cfg.LoggingConfig.LogFormat)
+	}
+	// the slices are replaced, not appended
+	if expected := []string{"feature4"}; !slices.Equal(cfg.FeatureConfig.EnabledFeatures, expected) {
+		t.Errorf("expected the features of the overlay %v, got %v", expected, cfg.FeatureConfig.EnabledFeatures)
+	}
+}
+
+func TestLoadConfigOverlaysOrder(t *testing.T) {
+	base := writeFile(t, "base.yaml", "http_server:\n  port: 8081\n")
+	first := writeFile(t, "first.yaml", "http_server:\n  port: 8082\n")
+	second := writeFile(t, "second.yaml", "http_server:\n  port: 8083\n")
+
+	cfg, err := LoadConfig(base, WithOverlays(first, second))
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	if cfg.HTTPServerConfig.Port != 8083 {
+		t.Errorf("expected the port of the last overlay 8083, got %d", cfg.HTTPServerConfig.Port)
+	}
+}
+
+func TestLoadConfigTypes(t *testing.T) {
+	files := map[string]string{
+		"app-config.yaml": `
+http_server:
+  port: 9090
+  tls:
+    min_version: "1.2"
+features:
+  enabled_features: [feature3, feature4]
+logging:
+  log_level: -1
+`,
+		"app-config.json": `{
+  "http_server": {"port": 9090, "tls": {"min_version": "1.2"}},
+  "features": {"enabled_features": ["feature3", "feature4"]},
+  "logging": {"log_level": -1}
+}`,
+		"app-config.toml": `
+[http_server]
+port = 9090
+
+[http_server.tls]
+min_version = "1.2"
+
+[features]
+enabled_features = ["feature3", "feature4"]
+
+[logging]
+log_level = -1
+`,
+	}
+
+	configs := map[string]*Config{}
+	for name, content := range files {
+		cfg, err := LoadConfig(writeFile(t, name, content))
+		if err != nil {
+			t.Fatalf("failed to load %s: %v", name, err)
+		}
+		configs[name] = cfg
+	}
+
+	expected := configs["app-config.yaml"]
+	if expected.HTTPServerConfig.Port != 9090 || *expected.LoggingConfig.LogLevel != -1 {
+		t.Fatalf("unexpected config from YAML: %v", expected)
+	}
+	for name, cfg := range configs {
+		if !reflect.DeepEqual(cfg, expected) {
+			t.Errorf("expected the config from %s to match the one from YAML, got the differences %v", name, Diff(expected, cfg))
+		}
+	}
+}
+
+func TestConfigType(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+		wantErr  bool
+	}{
+		{path: "config.yaml", expected: "yaml"},
+		{path: "config.yml", expected: "yaml"},
+		{path: "/etc/app/config.JSON", expected: "json"},
+		{path: "config.toml", expected: "toml"},
+		{path: "config.ini", wantErr: true},
+		{path: "config", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			cfgType, err := configType(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", cfgType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfgType != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, cfgType)
+			}
+		})
+	}
+}
+
+func TestFindDefaultConfig(t *testing.T) {
+	dir := t.TempDir()
+	if _, found := findDefaultConfig(dir); found {
+		t.Fatal("expected no default config in an empty directory")
+	}
+
+	for _, name := range []string{"app-config.toml", "app-config.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte{}, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// JSON comes before TOML in the search order
+	path, found := findDefaultConfig(dir)
+	if !found || path != filepath.Join(dir, "app-config.json") {
+		t.Errorf("expected app-config.json, got %q", path)
+	}
+}
+
+func TestStrictUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "misspelled section", content: "htpp_server:\n  port: 9090\n", wantErr: "unknown keys in the config: htpp_server"},
+		{name: "misspelled field", content: "http_server:\n  prot: 9090\n  bind_adress: 127.0.0.1\n", wantErr: "http_server.bind_adress, http_server.prot"},
+		{name: "known keys", content: "http_server:\n  port: 9090\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := readYAML(t, tt.content)
+			err := StrictUnmarshal(v, &Config{})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			assertErrorContains(t, err, tt.wantErr)
+
+			// the unknown keys are ignored by the lenient Unmarshal
+			if err := Unmarshal(v, &Config{}); err != nil {
+				t.Errorf("unexpected error from Unmarshal: %v", err)
+			}
+		})
+	}
+}
+
+func TestStrictUnmarshalIgnoresNonFileKeys(t *testing.T) {
+	v := readYAML(t, "http_server:\n  port: 9090\n")
+	// neither the Viper defaults nor the environment variables are config file keys
+	v.SetDefault("unknown_default", 1)
+	t.Setenv("APP_UNKNOWN_ENV", "1")
+	_ = v.BindEnv("unknown_env", "APP_UNKNOWN_ENV")
+
+	if err := StrictUnmarshal(v, &Config{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoadConfigStrict(t *testing.T) {
+	path := writeFile(t, "config.yaml", "htpp_server:\n  port: 9090\n")
+
+	if _, err := LoadConfig(path); err != nil {
+		t.Errorf("expected the unknown key to be ignored when not strict: %v", err)
+	}
+	_, err := LoadConfig(path, WithStrict(true))
+	assertErrorContains(t, err, "htpp_server")
+}
+
+func TestLoadConfigStdin(t *testing.T) {
+	stdin := strings.NewReader("http_server:\n  port: 9090\n")
+	cfg, err := LoadConfig("-", WithStdin(stdin, "yaml"))
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected port 9090, got %d", cfg.HTTPServerConfig.Port)
+	}
+}
+
+func TestLoadConfigStdinTypes(t *testing.T) {
+	tests := []struct {
+		name       string
+		configType string
+		content    string
+		wantErr    bool
+	}{
+		{name: "json", configType: "json", content: `{"http_server": {"port": 9090}}`},
+		{name: "toml", configType: "toml", content: "[http_server]\nport = 9090\n"},
+		{name: "invalid content", configType: "json", content: "http_server: {", wantErr: true},
+		{name: "unsupported type", configType: "ini", content: "[http_server]\nport = 9090\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfig("-", WithStdin(strings.NewReader(tt.content), tt.configType))
+			if tt.wantErr {
+				if !errors.Is(err, ErrReadConfig) {
+					t.Errorf("expected ErrReadConfig, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to load the config: %v", err)
+			}
+			if cfg.HTTPServerConfig.Port != 9090 {
+				t.Errorf("expected port 9090, got %d", cfg.HTTPServerConfig.Port)
+			}
+		})
+	}
+}
+
+func TestLoadConfigStdinAsOverlay(t *testing.T) {
+	base := writeFile(t, "base.yaml", "http_server:\n  port: 8081\n  bind_address: 127.0.0.1\n")
+	stdin := strings.NewReader("http_server:\n  port: 9090\n")
+
+	cfg, err := LoadConfig(base, WithOverlays("-"), WithStdin(stdin, "yaml"))
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	if cfg.HTTPServerConfig.Port != 9090 || cfg.HTTPServerConfig.BindAddress != "127.0.0.1" {
+		t.Errorf("expected stdin to be merged over the base, got %v", cfg.HTTPServerConfig)
+	}
+
+	// stdin can only be read once
+	_, err = LoadConfig("-", WithOverlays("-"), WithStdin(strings.NewReader(""), "yaml"))
+	if !errors.Is(err, ErrReadConfig) {
+		t.Errorf("expected ErrReadConfig, got %v", err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeFile(t, "config.yaml", "http_server:\n  port: 9090\nlogging:\n  log_format: pretty\n")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	// the fields set in the file
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected port 9090, got %d", cfg.HTTPServerConfig.Port)
+	}
+	if cfg.LoggingConfig.LogFormat != "pretty" {
+		t.Errorf("expected log format pretty, got %q", cfg.LoggingConfig.LogFormat)
+	}
+	// the defaults are applied for the fields that are not set
+	if cfg.HTTPServerConfig.BindAddress != "0.0.0.0" {
+		t.Errorf("expected the default bind address 0.0.0.0, got %q", cfg.HTTPServerConfig.BindAddress)
+	}
+	if cfg.LoggingConfig.LogLevel == nil || *cfg.LoggingConfig.LogLevel != 2 {
+		t.Errorf("expected the default log level 2, got %v", cfg.LoggingConfig.LogLevel)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{name: "missing file", path: filepath.Join(t.TempDir(), "missing.yaml"), wantErr: "missing.yaml"},
+		{name: "invalid config", path: writeFile(t, "invalid.yaml", "http_server:\n  port: 70000\n"), wantErr: "http_server.port"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(tt.path)
+			assertErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+//go:embed testdata/embedded.yaml
+var embeddedConfig []byte
+
+func TestLoadConfigFromBytes(t *testing.T) {
+	cfg, err := LoadConfigFromBytes(embeddedConfig, "yaml")
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected port 9090, got %d", cfg.HTTPServerConfig.Port)
+	}
+	if cfg.DatabaseConfig.Name != "embedded" {
+		t.Errorf("expected database name embedded, got %q", cfg.DatabaseConfig.Name)
+	}
+	// the defaults fill in the missing fields
+	if cfg.HTTPServerConfig.BindAddress != "0.0.0.0" {
+		t.Errorf("expected the default bind address 0.0.0.0, got %q", cfg.HTTPServerConfig.BindAddress)
+	}
+	if cfg.DatabaseConfig.Port != 5432 {
+		t.Errorf("expected the default database port 5432, got %d", cfg.DatabaseConfig.Port)
+	}
+}
+
+func TestLoadConfigFromBytesErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		configType string
+	}{
+		{name: "unsupported type", data: "[http_server]\nport = 9090\n", configType: "ini"},
+		{name: "invalid content", data: "http_server: {", configType: "json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfigFromBytes([]byte(tt.data), tt.configType)
+			if !errors.Is(err, ErrReadConfig) {
+				t.Errorf("expected ErrReadConfig, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigContextCancelled(t *testing.T) {
+	path := writeFile(t, "config.yaml", "http_server:\n  port: 9090\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := LoadConfigContext(ctx, path)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestLoadConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"http_server.port":            "9090\n",
+		"http_server.tls.min_version": "1.2",
+		"logging.log_format":          "pretty\n",
+		"features.enabled_features":   "feature3,feature4",
+		// the hidden files and the subdirectories of a mounted ConfigMap are ignored
+		"..data": "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "subdir"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfigDir(dir)
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected port 9090, got %d", cfg.HTTPServerConfig.Port)
+	}
+	if cfg.HTTPServerConfig.TLSConfig.MinVersion != "1.2" {
+		t.Errorf("expected min version 1.2, got %q", cfg.HTTPServerConfig.TLSConfig.MinVersion)
+	}
+	if cfg.LoggingConfig.LogFormat != "pretty" {
+		t.Errorf("expected log format pretty, got %q", cfg.LoggingConfig.LogFormat)
+	}
+	if expected := []string{"feature3", "feature4"}; !slices.Equal(cfg.FeatureConfig.EnabledFeatures, expected) {
+		t.Errorf("expected features %v, got %v", expected, cfg.FeatureConfig.EnabledFeatures)
+	}
+	// the defaults are applied for the keys without a file
+	if cfg.HTTPServerConfig.BindAddress != "0.0.0.0" {
+		t.Errorf("expected the default bind address 0.0.0.0, got %q", cfg.HTTPServerConfig.BindAddress)
+	}
+}
+
+func TestLoadConfigDirConflictingKeys(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"http_server", "http_server.port"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("9090"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := LoadConfigDir(dir)
+	if !errors.Is(err, ErrReadConfig) {
+		t.Fatalf("expected ErrReadConfig, got %v", err)
+	}
+	assertErrorContains(t, err, "conflicts")
+}
+
+func TestDefaultSearchPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	expected := []string{".", filepath.Join("/xdg", "app"), filepath.Join("/etc", "app"), filepath.Join(home, ".app")}
+	if paths := DefaultSearchPaths(); !slices.Equal(paths, expected) {
+		t.Errorf("expected %v, got %v", expected, paths)
+	}
+
+	// falls back to ~/.config when XDG_CONFIG_HOME is not set
+	t.Setenv("XDG_CONFIG_HOME", "")
+	expected = []string{".", filepath.Join(home, ".config", "app"), filepath.Join("/etc", "app"), filepath.Join(home, ".app")}
+	if paths := DefaultSearchPaths(); !slices.Equal(paths, expected) {
+		t.Errorf("expected %v, got %v", expected, paths)
+	}
+}
+
+func TestLoadConfigSearchPaths(t *testing.T) {
+	cwd := t.TempDir()
+	etc := t.TempDir()
+	if err := os.WriteFile(filepath.Join(etc, "app-config.yaml"), []byte("http_server:\n  port: 9090\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// found in the later directory when the earlier one has none
+	cfg, err := LoadConfig("", WithSearchPaths(cwd, etc))
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected port 9090 from the etc directory, got %d", cfg.HTTPServerConfig.Port)
+	}
+
+	// the earlier directory wins
+	if err := os.WriteFile(filepath.Join(cwd, "app-config.yaml"), []byte("http_server:\n  port: 9191\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = LoadConfig("", WithSearchPaths(cwd, etc))
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	if cfg.HTTPServerConfig.Port != 9191 {
+		t.Errorf("expected port 9191 from the current directory, got %d", cfg.HTTPServerConfig.Port)
+	}
+}
+
+func TestLoadConfigEmptyFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr bool
+	}{
+		{name: "empty yaml", file: "config.yaml", content: ""},
+		{name: "whitespace-only yaml", file: "config.yaml", content: "\n  \n\t\n"},
+		{name: "empty json", file: "config.json", content: ""},
+		{name: "whitespace-only toml", file: "config.toml", content: " \n"},
+		{name: "malformed yaml", file: "config.yaml", content: "http_server: [\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfig(writeFile(t, tt.file, tt.content))
+			if tt.wantErr {
+				if !errors.Is(err, ErrReadConfig) {
+					t.Errorf("expected ErrReadConfig, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected the empty file to be ignored: %v", err)
+			}
+			// all defaults
+			if cfg.HTTPServerConfig.Port != 8080 {
+				t.Errorf("expected the default port 8080, got %d", cfg.HTTPServerConfig.Port)
+			}
+		})
+	}
+}
+
+func TestLoadConfigWithStats(t *testing.T) {
+	content := "http_server:\n  port: 9090\n"
+	overlay := "logging:\n  log_format: pretty\n"
+	path := writeFile(t, "config.yaml", content)
+
+	cfg, stats, err := LoadConfigWithStats(path, WithOverlays(writeFile(t, "overlay.yaml", overlay)))
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected port 9090, got %d", cfg.HTTPServerConfig.Port)
+	}
+
+	if expected := int64(len(content) + len(overlay)); stats.SourceBytes != expected {
+		t.Errorf("expected %d source bytes, got %d", expected, stats.SourceBytes)
+	}
+	durations := map[string]time.Duration{
+		"read":      stats.ReadDuration,
+		"unmarshal": stats.UnmarshalDuration,
+		"default":   stats.DefaultDuration,
+		"validate":  stats.ValidateDuration,
+	}
+	for stage, d := range durations {
+		if d < 0 {
+			t.Errorf("expected a non-negative %s duration, got %v", stage, d)
+		}
+	}
+}
+
+func TestLoadConfigWithStatsFailure(t *testing.T) {
+	_, stats, err := LoadConfigWithStats(writeFile(t, "config.yaml", "http_server:\n  port: 70000\n"))
+	if err == nil {
+		t.Fatal("expected an error for the invalid config")
+	}
+	// the stats cover the stages run until the failure
+	if stats == nil || stats.SourceBytes == 0 {
+		t.Errorf("expected the stats of the stages run, got %+v", stats)
+	}
+}
+
+func TestStringToSliceHookFunc(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{input: "a,b,c", expected: []string{"a", "b", "c"}},
+		{input: " a , b ,, c, ", expected: []string{"a", "b", "c"}},
+		{input: "a", expected: []string{"a"}},
+		{input: "", expected: []string{}},
+	}
+
+	hook := stringToSliceHookFunc(",")
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := hook(reflect.String, reflect.Slice, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(result.([]string), tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	// the other conversions are left as they are
+	if result, _ := hook(reflect.String, reflect.String, "a,b"); result != "a,b" {
+		t.Errorf("expected the string to be kept, got %v", result)
+	}
+}
+
+func TestLoadConfigSliceFromEnv(t *testing.T) {
+	t.Setenv("APP_FEATURES_ENABLED_FEATURES", "feature3, feature4")
+
+	// the environment variable overrides the list in the file
+	cfg := mustLoadYAML(t, "features:\n  enabled_features: [feature1]\n")
+	expected := []string{"feature3", "feature4"}
+	if !slices.Equal(cfg.FeatureConfig.EnabledFeatures, expected) {
+		t.Errorf("expected %v, got %v", expected, cfg.FeatureConfig.EnabledFeatures)
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	withDefaults := WithDefaults(map[string]interface{}{
+		"http_server.port":    3000,
+		"features.flags.beta": true,
+		"logging.log_format":  "pretty",
+	})
+
+	// the programmatic defaults override the defaults in the tags
+	cfg := mustLoadYAML(t, "", withDefaults)
+	if cfg.HTTPServerConfig.Port != 3000 {
+		t.Errorf("expected the programmatic default port 3000, got %d", cfg.HTTPServerConfig.Port)
+	}
+	if !cfg.FeatureConfig.IsEnabled("beta") {
+		t.Error("expected the programmatic default of the map key to be used")
+	}
+
+	// the config file overrides the programmatic defaults
+	cfg = mustLoadYAML(t, "http_server:\n  port: 9090\n", withDefaults)
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected the port 9090 from the file, got %d", cfg.HTTPServerConfig.Port)
+	}
+	// the other programmatic defaults are kept
+	if cfg.LoggingConfig.LogFormat != "pretty" {
+		t.Errorf("expected the programmatic default log format pretty, got %q", cfg.LoggingConfig.LogFormat)
+	}
+
+	// as well as the environment variables
+	t.Setenv("APP_HTTP_SERVER_PORT", "7070")
+	cfg = mustLoadYAML(t, "", withDefaults)
+	if cfg.HTTPServerConfig.Port != 7070 {
+		t.Errorf("expected the port 7070 from the environment variable, got %d", cfg.HTTPServerConfig.Port)
+	}
+}
+
+func TestWithDefaultsUnknownField(t *testing.T) {
+	_, err := LoadConfigFromBytes([]byte(""), "yaml", WithDefaults(map[string]interface{}{"http_server.unknown": 1}))
+	assertErrorContains(t, err, `unknown config field "http_server.unknown" in the defaults`)
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	// the same field is set at every layer, from the highest precedence to the lowest, see LoadConfig.
+	// the layers are removed one at a time from the top, so that the next one wins
+	layers := []struct {
+		name     string
+		logLevel int8
+	}{
+		{name: "flag", logLevel: 5},
+		{name: "environment variable", logLevel: 4},
+		{name: "overlay", logLevel: 3},
+		{name: "file", logLevel: 0},
+		{name: "programmatic default", logLevel: -1},
+		{name: "environment default", logLevel: 1},
+	}
+
+	for i := 0; i <= len(layers); i++ {
+		// the defaults in the tags are the last layer, which can't be removed
+		winner, expected := "tag default", int8(2)
+		if i < len(layers) {
+			winner, expected = layers[i].name, layers[i].logLevel
+		}
+		enabled := map[string]bool{}
+		for _, layer := range layers[i:] {
+			enabled[layer.name] = true
+		}
+
+		t.Run(winner, func(t *testing.T) {
+			// the flags that are not set must not override the other layers, so the flag set is always used
+			args := []string{}
+			if enabled["flag"] {
+				args = append(args, "--logging.log-level=5")
+			}
+			opts := []LoadOption{WithFlags(newFlagSet(t, args...))}
+
+			if enabled["environment variable"] {
+				t.Setenv("APP_LOGGING_LOG_LEVEL", "4")
+			}
+			if enabled["overlay"] {
+				opts = append(opts, WithOverlays(writeFile(t, "overlay.yaml", "logging:\n  log_level: 3\n")))
+			}
+			content := "http_server:\n  port: 8080\n"
+			if enabled["file"] {
+				content += "logging:\n  log_level: 0\n"
+			}
+			if enabled["programmatic default"] {
+				opts = append(opts, WithDefaults(map[string]interface{}{"logging.log_level": -1}))
+			}
+			if enabled["environment default"] {
+				t.Setenv("APP_ENV", "prod")
+			}
+
+			cfg, err := LoadConfig(writeFile(t, "config.yaml", content), opts...)
+			if err != nil {
+				t.Fatalf("failed to load the config: %v", err)
+			}
+			if cfg.LoggingConfig.LogLevel == nil || *cfg.LoggingConfig.LogLevel != expected {
+				t.Errorf("expected log level %d from the %s, got %v", expected, winner, cfg.LoggingConfig.LogLevel)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ZapLevel converts the log level in the configuration to a zap log level.
+// The log levels in the configuration match the zap levels: -1 is debug, 0 is info, ..., 5 is fatal.
+func ZapLevel(cfg *Config) (zapcore.Level, error) {
+	if cfg.LoggingConfig.LogLevel == nil {
+		return 0, errors.New("log level is not set")
+	}
+	return toZapLevel(int64(*cfg.LoggingConfig.LogLevel))
+}
+
+// NewLogger builds a slog logger from the logging configuration, writing to stderr.
+// The `json` format uses a JSON handler, and the `pretty` format uses a text handler for human readable logs.
+// The level is converted from the zap level in the configuration, see SlogLevel.
+func NewLogger(cfg *Config) (*slog.Logger, error) {
+	level, err := SlogLevel(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	switch cfg.LoggingConfig.LogFormat {
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	case "pretty":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("unsupported log format %q, must be one of json, pretty", cfg.LoggingConfig.LogFormat)
+	}
+}
+
+// SlogLevel converts the log level in the configuration to a slog level.
+// Debug, info, warn and error map to the slog levels of the same name. slog has no levels above error, so
+// dpanic, panic and fatal map to the levels above error, ERROR+1, ERROR+2 and ERROR+3.
+func SlogLevel(cfg *Config) (slog.Level, error) {
+	zapLevel, err := ZapLevel(cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	switch zapLevel {
+	case zapcore.DebugLevel:
+		return slog.LevelDebug, nil
+	case zapcore.InfoLevel:
+		return slog.LevelInfo, nil
+	case zapcore.WarnLevel:
+		return slog.LevelWarn, nil
+	default:
+		return slog.LevelError + slog.Level(zapLevel-zapcore.ErrorLevel), nil
+	}
+}
+
+func toZapLevel(level int64) (zapcore.Level, error) {
+	if level < int64(zapcore.DebugLevel) || level > int64(zapcore.FatalLevel) {
+		return 0, fmt.Errorf("invalid log level %d, must be between %d and %d", level, zapcore.DebugLevel, zapcore.FatalLevel)
+	}
+	return zapcore.Level(level), nil
+}
@@ -0,0 +1,161 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestZapLevel(t *testing.T) {
+	tests := []struct {
+		level    int8
+		expected zapcore.Level
+		wantErr  bool
+	}{
+		{level: -2, wantErr: true},
+		{level: -1, expected: zapcore.DebugLevel},
+		{level: 0, expected: zapcore.InfoLevel},
+		{level: 1, expected: zapcore.WarnLevel},
+		{level: 2, expected: zapcore.ErrorLevel},
+		{level: 3, expected: zapcore.DPanicLevel},
+		{level: 4, expected: zapcore.PanicLevel},
+		{level: 5, expected: zapcore.FatalLevel},
+		{level: 6, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(int(tt.level)), func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.LoggingConfig.LogLevel = Ptr(tt.level)
+
+			level, err := ZapLevel(cfg)
+			validateErr := Validate(cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected a conversion error, got %v", level)
+				}
+				if validateErr == nil {
+					t.Error("expected a validation error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected conversion error: %v", err)
+			}
+			if level != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, level)
+			}
+			if validateErr != nil {
+				t.Errorf("unexpected validation error: %v", validateErr)
+			}
+		})
+	}
+}
+
+func TestZapLevelNotSet(t *testing.T) {
+	if _, err := ZapLevel(&Config{}); err == nil {
+		t.Error("expected an error for the unset log level")
+	}
+}
+
+func TestValidateLogLevelRule(t *testing.T) {
+	// `zaplevel` is an alias of the `ziplevel` rule
+	type levels struct {
+		Zip int8 `validate:"ziplevel"`
+		Zap int8 `validate:"zaplevel"`
+	}
+	validate := newValidator()
+
+	if err := validate.Struct(levels{Zip: -1, Zap: 5}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err := validate.Struct(levels{Zip: 6, Zap: -2})
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) || len(validationErrs) != 2 {
+		t.Fatalf("expected 2 validation errors, got %v", err)
+	}
+	if validationErrs[0].Tag() != "ziplevel" || validationErrs[1].Tag() != "zaplevel" {
+		t.Errorf("expected the ziplevel and zaplevel rules, got %s and %s", validationErrs[0].Tag(), validationErrs[1].Tag())
+	}
+}
+
+func TestSlogLevel(t *testing.T) {
+	tests := []struct {
+		level    int8
+		expected slog.Level
+	}{
+		{level: -1, expected: slog.LevelDebug},
+		{level: 0, expected: slog.LevelInfo},
+		{level: 1, expected: slog.LevelWarn},
+		{level: 2, expected: slog.LevelError},
+		{level: 3, expected: slog.LevelError + 1},
+		{level: 4, expected: slog.LevelError + 2},
+		{level: 5, expected: slog.LevelError + 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(int(tt.level)), func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.LoggingConfig.LogLevel = Ptr(tt.level)
+
+			level, err := SlogLevel(cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if level != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, level)
+			}
+		})
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		format   string
+		level    int8
+		expected slog.Handler
+	}{
+		{format: "json", level: 0, expected: &slog.JSONHandler{}},
+		{format: "pretty", level: 1, expected: &slog.TextHandler{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.LoggingConfig.LogFormat = tt.format
+			cfg.LoggingConfig.LogLevel = Ptr(tt.level)
+
+			logger, err := NewLogger(cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if handlerType, expected := reflect.TypeOf(logger.Handler()), reflect.TypeOf(tt.expected); handlerType != expected {
+				t.Errorf("expected the handler %v, got %v", expected, handlerType)
+			}
+
+			level, _ := SlogLevel(cfg)
+			ctx := context.Background()
+			if !logger.Enabled(ctx, level) || logger.Enabled(ctx, level-1) {
+				t.Errorf("expected the logger to be enabled from the level %v on", level)
+			}
+		})
+	}
+}
+
+func TestNewLoggerErrors(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.LoggingConfig.LogFormat = "xml"
+	_, err := NewLogger(cfg)
+	assertErrorContains(t, err, `unsupported log format "xml"`)
+
+	_, err = NewLogger(&Config{})
+	assertErrorContains(t, err, "log level is not set")
+}
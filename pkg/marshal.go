@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// Marshal marshals the configuration in the given format, which can be `yaml`, `json` or `toml`.
+// The JSON output is indented, which makes it readable and easy to pipe into tools like `jq`.
+//
+// The secrets are not masked, use Redacted before marshalling to mask them.
+func Marshal(cfg *Config, format string) ([]byte, error) {
+	switch format {
+	case "yaml":
+		return yaml.Marshal(cfg)
+	case "json":
+		return json.MarshalIndent(cfg, "", "  ")
+	case "toml":
+		// the TOML encoder doesn't use the `json` tags, so go through a map built from the JSON output
+		cfgJSON, err := json.Marshal(cfg)
+		if err != nil {
+			return nil, err
+		}
+		// keep the numbers as json.Number, otherwise the integers would become floats, such as `port = 8080.0`
+		decoder := json.NewDecoder(bytes.NewReader(cfgJSON))
+		decoder.UseNumber()
+		var m map[string]interface{}
+		if err := decoder.Decode(&m); err != nil {
+			return nil, err
+		}
+		return toml.Marshal(convertNumbers(m))
+	default:
+		return nil, fmt.Errorf("unsupported output format %q, must be one of yaml, json, toml", format)
+	}
+}
+
+// convertNumbers replaces the json.Number values in the given value decoded from JSON, recursively, with int64 for
+// the integers and float64 for the others.
+func convertNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = convertNumbers(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = convertNumbers(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// SaveConfig writes the configuration to the file at the given path, in the format detected from the extension of
+// the file, see configType. The file is written atomically: the configuration is written to a temporary file in the
+// same directory, which is then renamed to the path. So, readers never see a partially written file.
+//
+// If the file already exists and starts with a `yaml-language-server` header, such as SchemaHeader, the header is
+// kept. The permissions of an existing file are kept as well.
+//
+// The secrets are written as they are, not masked.
+func SaveConfig(cfg *Config, path string) error {
+	cfgType, err := configType(path)
+	if err != nil {
+		return err
+	}
+	data, err := Marshal(cfg, cfgType)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	perm := os.FileMode(0644)
+	if existing, err := os.ReadFile(path); err == nil {
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+		}
+		if cfgType == "yaml" {
+			if header, _, _ := strings.Cut(string(existing), "\n"); strings.HasPrefix(header, "# yaml-language-server:") {
+				data = append([]byte(header+"\n"), data...)
+			}
+		}
+	}
+
+	return writeFileAtomic(path, data, perm)
+}
+
+// writeFileAtomic writes the data to a temporary file in the directory of the given path, then renames it to the
+// path. So, readers never see a partially written file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	// no-op after the rename
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("failed to set the permissions of the config: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package pkg
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+	"sigs.k8s.io/yaml"
+)
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		format    string
+		unmarshal func(data []byte, v interface{}) error
+	}{
+		{format: "yaml", unmarshal: func(data []byte, v interface{}) error { return yaml.Unmarshal(data, v) }},
+		{format: "json", unmarshal: json.Unmarshal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.HTTPServerConfig.Port = 9090
+
+			data, err := Marshal(cfg, tt.format)
+			if err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+
+			var m map[string]interface{}
+			if err := tt.unmarshal(data, &m); err != nil {
+				t.Fatalf("failed to unmarshal the output:\n%s\n%v", data, err)
+			}
+			// the output uses the names in the `json` tags
+			server, _ := m["http_server"].(map[string]interface{})
+			if port, _ := server["port"].(float64); port != 9090 {
+				t.Errorf("expected port 9090, got %v in:\n%s", server["port"], data)
+			}
+		})
+	}
+}
+
+func TestMarshalUnsupportedFormat(t *testing.T) {
+	_, err := Marshal(defaultConfig(t), "xml")
+	assertErrorContains(t, err, `unsupported output format "xml"`)
+}
+
+func TestMarshalTOML(t *testing.T) {
+	cfg := defaultConfig(t)
+	data, err := Marshal(cfg, "toml")
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	var m map[string]interface{}
+	if err := toml.Unmarshal(data, &m); err != nil {
+		t.Fatalf("failed to unmarshal the output:\n%s\n%v", data, err)
+	}
+	// the integers stay integers, and the floats stay floats
+	server, _ := m["http_server"].(map[string]interface{})
+	if port, ok := server["port"].(int64); !ok || port != 8080 {
+		t.Errorf("expected the integer port 8080, got %#v in:\n%s", server["port"], data)
+	}
+	tracing, _ := m["tracing"].(map[string]interface{})
+	if ratio, ok := tracing["sample_ratio"].(float64); !ok || ratio != 0.1 {
+		t.Errorf("expected the float sample ratio 0.1, got %#v in:\n%s", tracing["sample_ratio"], data)
+	}
+}
+
+func TestSaveConfig(t *testing.T) {
+	for _, name := range []string{"config.yaml", "config.json", "config.toml"} {
+		t.Run(name, func(t *testing.T) {
+			path := writeFile(t, name, "")
+			if err := SaveConfig(defaultConfig(t), path); err != nil {
+				t.Fatalf("failed to save the config: %v", err)
+			}
+
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to load the config: %v", err)
+			}
+			cfg.HTTPServerConfig.Port = 9090
+			if err := SaveConfig(cfg, path); err != nil {
+				t.Fatalf("failed to save the config: %v", err)
+			}
+
+			reloaded, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("failed to reload the config: %v", err)
+			}
+			if reloaded.HTTPServerConfig.Port != 9090 {
+				t.Errorf("expected the saved port 9090, got %d", reloaded.HTTPServerConfig.Port)
+			}
+			if !Equal(cfg, reloaded) {
+				t.Errorf("expected the reloaded config to equal the saved one, got the changes %v", Diff(cfg, reloaded))
+			}
+		})
+	}
+}
+
+func TestSaveConfigKeepsHeaderAndPermissions(t *testing.T) {
+	header := "# yaml-language-server: $schema=./configuration-schema.gen.json"
+	path := writeFile(t, "config.yaml", header+"\nhttp_server:\n  port: 8081\n")
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveConfig(defaultConfig(t), path); err != nil {
+		t.Fatalf("failed to save the config: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), header+"\n") {
+		t.Errorf("expected the header to be kept, got:\n%s", data)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o640 {
+		t.Errorf("expected the permissions 0640 to be kept, got %v", perm)
+	}
+}
+
+func TestSaveConfigUnsupportedExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.ini")
+	if err := SaveConfig(defaultConfig(t), path); err == nil {
+		t.Fatal("expected an error for the unsupported extension")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected no file to be written, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package pkg
+
+import (
+	"fmt"
+
+	"github.com/spf13/cast"
+)
+
+// CurrentConfigVersion is the version of the config format this code understands.
+// Configs of older versions are migrated to this version when loaded, see Migrate.
+// Bump it when adding a migration, and update the default and the maximum of Config.Version accordingly.
+const CurrentConfigVersion = 2
+
+// migration brings a raw config of the version it is registered for to the next version.
+type migration func(raw map[string]interface{}) (map[string]interface{}, error)
+
+// migrations are the migrations to run in order, keyed by the version they migrate from.
+// A migration for every version from 1 to CurrentConfigVersion-1 must be registered.
+var migrations = []struct {
+	from    int
+	migrate migration
+}{
+	{1, migrateV1ToV2},
+}
+
+// Migrate brings the given raw config, as read from the config files, up to CurrentConfigVersion by applying the
+// migrations from its version on, in order. The version of the config is the `version` key, which defaults to
+// CurrentConfigVersion when not set, so configs without a version are not migrated.
+//
+// The given map is not modified. The returned map has `version` set to CurrentConfigVersion.
+// An error is returned for configs of a newer version than CurrentConfigVersion.
+func Migrate(raw map[string]interface{}) (map[string]interface{}, error) {
+	version := CurrentConfigVersion
+	if rawVersion, ok := raw["version"]; ok && rawVersion != nil {
+		// the version is an int for YAML, a float64 for JSON and a string for environment variables
+		var err error
+		if version, err = cast.ToIntE(rawVersion); err != nil {
+			return nil, fmt.Errorf("invalid config version %v: %w", rawVersion, err)
+		}
+	}
+	if version > CurrentConfigVersion {
+		return nil, fmt.Errorf("config version %d is newer than the supported version %d", version, CurrentConfigVersion)
+	}
+
+	migrated := copyMap(raw)
+	for version < CurrentConfigVersion {
+		m, ok := findMigration(version)
+		if !ok {
+			return nil, fmt.Errorf("no migration registered for config version %d", version)
+		}
+		var err error
+		if migrated, err = m(migrated); err != nil {
+			return nil, fmt.Errorf("failed to migrate config from version %d to %d: %w", version, version+1, err)
+		}
+		version++
+	}
+
+	migrated["version"] = CurrentConfigVersion
+	return migrated, nil
+}
+
+func findMigration(from int) (migration, bool) {
+	for _, m := range migrations {
+		if m.from == from {
+			return m.migrate, true
+		}
+	}
+	return nil, false
+}
+
+// migrateV1ToV2 renames the `http` section to `http_server`, such as `http.port` to `http_server.port`.
+// Keys already set in the `http_server` section win over the ones in the `http` section.
+func migrateV1ToV2(raw map[string]interface{}) (map[string]interface{}, error) {
+	rawHTTP, ok := raw["http"]
+	if !ok {
+		return raw, nil
+	}
+	httpSection, ok := rawHTTP.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("`http` must be a map, got %T", rawHTTP)
+	}
+
+	httpServer := map[string]interface{}{}
+	if rawHTTPServer, ok := raw["http_server"]; ok {
+		if httpServer, ok = rawHTTPServer.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("`http_server` must be a map, got %T", rawHTTPServer)
+		}
+		httpServer = copyMap(httpServer)
+	}
+	for k, v := range httpSection {
+		if _, ok := httpServer[k]; !ok {
+			httpServer[k] = v
+		}
+	}
+
+	raw["http_server"] = httpServer
+	delete(raw, "http")
+	return raw, nil
+}
+
+// copyMap returns a shallow copy of the given map.
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      map[string]interface{}
+		expected map[string]interface{}
+		wantErr  string
+	}{
+		{
+			name: "v1 to v2",
+			raw: map[string]interface{}{
+				"version": 1,
+				"http":    map[string]interface{}{"port": 9090},
+			},
+			expected: map[string]interface{}{
+				"version":     CurrentConfigVersion,
+				"http_server": map[string]interface{}{"port": 9090},
+			},
+		},
+		{
+			name: "v1 with both sections",
+			raw: map[string]interface{}{
+				"version":     1,
+				"http":        map[string]interface{}{"port": 9090, "bind_address": "127.0.0.1"},
+				"http_server": map[string]interface{}{"port": 9191},
+			},
+			expected: map[string]interface{}{
+				"version":     CurrentConfigVersion,
+				"http_server": map[string]interface{}{"port": 9191, "bind_address": "127.0.0.1"},
+			},
+		},
+		{
+			name: "version from JSON",
+			raw: map[string]interface{}{
+				"version": float64(1),
+				"http":    map[string]interface{}{"port": 9090},
+			},
+			expected: map[string]interface{}{
+				"version":     CurrentConfigVersion,
+				"http_server": map[string]interface{}{"port": 9090},
+			},
+		},
+		{
+			name:     "no version",
+			raw:      map[string]interface{}{"http": map[string]interface{}{"port": 9090}},
+			expected: map[string]interface{}{"version": CurrentConfigVersion, "http": map[string]interface{}{"port": 9090}},
+		},
+		{name: "newer version", raw: map[string]interface{}{"version": CurrentConfigVersion + 1}, wantErr: "is newer than the supported version"},
+		{name: "invalid version", raw: map[string]interface{}{"version": "abc"}, wantErr: "invalid config version abc"},
+		{name: "invalid http section", raw: map[string]interface{}{"version": 1, "http": "abc"}, wantErr: "`http` must be a map"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrated, err := Migrate(tt.raw)
+			if tt.wantErr != "" {
+				assertErrorContains(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(migrated, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, migrated)
+			}
+		})
+	}
+}
+
+func TestMigrateDoesNotModifyTheInput(t *testing.T) {
+	raw := map[string]interface{}{
+		"version": 1,
+		"http":    map[string]interface{}{"port": 9090},
+	}
+	if _, err := Migrate(raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := raw["http"]; !ok || raw["version"] != 1 {
+		t.Errorf("expected the input to be unchanged, got %v", raw)
+	}
+}
+
+func TestLoadConfigMigrates(t *testing.T) {
+	cfg := mustLoadYAML(t, "version: 1\nhttp:\n  port: 9090\n")
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected port 9090 from the v1 http section, got %d", cfg.HTTPServerConfig.Port)
+	}
+	if cfg.Version != CurrentConfigVersion {
+		t.Errorf("expected version %d, got %d", CurrentConfigVersion, cfg.Version)
+	}
+}
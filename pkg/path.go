@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// GetByPath returns the value of the field at the given dotted path of the `json` tag names, such as
+// `http_server.port`. The path can point to a section as well, such as `http_server`, in which case the section
+// struct is returned. The items of the slices and the entries of the maps can be addressed with an index or a key,
+// such as `http_server.cors.allowed_origins.0` or `features.flags.tracing`.
+//
+// The pointers are dereferenced; nil is returned for a nil pointer, and the zero values for the fields of a section
+// behind a nil pointer, such as `storage.s3.bucket` when `storage.s3` is not set. The nil pointers are not allocated.
+// An error is returned for an unknown path.
+func GetByPath(cfg *Config, path string) (interface{}, error) {
+	value, mapKey, err := resolvePath(cfg, path, false)
+	if err != nil {
+		return nil, err
+	}
+	if mapKey.IsValid() {
+		entry := value.MapIndex(mapKey)
+		if !entry.IsValid() {
+			return nil, fmt.Errorf("config path %q not found", path)
+		}
+		value = entry
+	}
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+	return value.Interface(), nil
+}
+
+// SetByPath sets the field at the given dotted path, see GetByPath for the paths. The value is converted to the
+// type of the field like the values in the config files are, such as `"8080"` to an integer, `"15s"` to a Duration
+// or `"a,b"` to a slice. The pointer fields can be set with the values they point to.
+//
+// The sections behind the nil pointers on the path are allocated, such as `storage.s3` when setting
+// `storage.s3.bucket`. The configuration is not validated; use Validate after setting the fields.
+func SetByPath(cfg *Config, path string, value interface{}) error {
+	target, mapKey, err := resolvePath(cfg, path, true)
+	if err != nil {
+		return err
+	}
+
+	targetType := target.Type()
+	if mapKey.IsValid() {
+		targetType = targetType.Elem()
+	}
+	converted := reflect.New(targetType)
+	dc := &mapstructure.DecoderConfig{
+		Result:           converted.Interface(),
+		WeaklyTypedInput: true,
+	}
+	decoderConfigOption(dc)
+	decoder, err := mapstructure.NewDecoder(dc)
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(value); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", path, err)
+	}
+
+	if mapKey.IsValid() {
+		if target.IsNil() {
+			target.Set(reflect.MakeMap(target.Type()))
+		}
+		target.SetMapIndex(mapKey, converted.Elem())
+		return nil
+	}
+	target.Set(converted.Elem())
+	return nil
+}
+
+// resolvePath returns the value of the field at the given path of the configuration.
+// If the last part of the path is a map key, the map and the key are returned instead, as the map entries
+// can't be set in place.
+//
+// The nil pointers to the sections on the path are allocated only if allocate is true, so that the reads don't
+// modify the configuration. Otherwise, the path is resolved in the zero value of the section, and the returned value
+// is not settable.
+func resolvePath(cfg *Config, path string, allocate bool) (reflect.Value, reflect.Value, error) {
+	value := reflect.ValueOf(cfg).Elem()
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		if value.Kind() == reflect.Ptr && value.Type().Elem().Kind() == reflect.Struct {
+			switch {
+			case !value.IsNil():
+				value = value.Elem()
+			case allocate:
+				value.Set(reflect.New(value.Type().Elem()))
+				value = value.Elem()
+			default:
+				value = reflect.Zero(value.Type().Elem())
+			}
+		}
+
+		switch value.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByJSONName(value, part)
+			if !ok {
+				return reflect.Value{}, reflect.Value{}, fmt.Errorf("config path %q not found", path)
+			}
+			value = field
+		case reflect.Slice:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= value.Len() {
+				return reflect.Value{}, reflect.Value{}, fmt.Errorf("config path %q not found: invalid index %q", path, part)
+			}
+			value = value.Index(index)
+		case reflect.Map:
+			if i != len(parts)-1 || value.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, reflect.Value{}, fmt.Errorf("config path %q not found", path)
+			}
+			return value, reflect.ValueOf(part).Convert(value.Type().Key()), nil
+		default:
+			return reflect.Value{}, reflect.Value{}, fmt.Errorf("config path %q not found", path)
+		}
+	}
+	return value, reflect.Value{}, nil
+}
+
+// fieldByJSONName returns the field of the struct value with the given `json` tag name.
+func fieldByJSONName(value reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < value.NumField(); i++ {
+		if jsonName(value.Type().Field(i)) == name {
+			return value.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
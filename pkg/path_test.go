@@ -0,0 +1,174 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetByPath(t *testing.T) {
+	cfg := mustLoadYAML(t, `
+http_server:
+  port: 9090
+  cors:
+    allowed_origins: [https://a.example, https://b.example]
+features:
+  flags:
+    beta: true
+`)
+
+	tests := []struct {
+		path     string
+		expected interface{}
+	}{
+		{path: "http_server.port", expected: 9090},
+		{path: "http_server.tls.min_version", expected: "1.3"},
+		{path: "http_server.shutdown_timeout", expected: Duration(10 * time.Second)},
+		{path: "http_server.compression", expected: true},
+		{path: "http_server.cors.allowed_origins.1", expected: "https://b.example"},
+		{path: "features.flags.beta", expected: true},
+		{path: "logging", expected: cfg.LoggingConfig},
+		// the sections behind the nil pointers are not set
+		{path: "storage.s3", expected: nil},
+		{path: "storage.s3.bucket", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			value, err := GetByPath(cfg, tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(value, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, value)
+			}
+		})
+	}
+}
+
+func TestGetByPathDoesNotAllocate(t *testing.T) {
+	cfg := defaultConfig(t)
+
+	if _, err := GetByPath(cfg, "storage.s3.bucket"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := GetByPath(cfg, "storage.gcs"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.StorageConfig.S3Config != nil || cfg.StorageConfig.GCSConfig != nil {
+		t.Errorf("expected the unset sections to stay nil, got %+v", cfg.StorageConfig)
+	}
+}
+
+func TestSetByPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		value interface{}
+		check func(t *testing.T, cfg *Config)
+	}{
+		{
+			path:  "http_server.port",
+			value: "7070",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.HTTPServerConfig.Port != 7070 {
+					t.Errorf("expected port 7070, got %d", cfg.HTTPServerConfig.Port)
+				}
+			},
+		},
+		{
+			path:  "http_server.shutdown_timeout",
+			value: "15s",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.HTTPServerConfig.ShutdownTimeout != Duration(15*time.Second) {
+					t.Errorf("expected 15s, got %v", cfg.HTTPServerConfig.ShutdownTimeout)
+				}
+			},
+		},
+		{
+			path:  "logging.log_level",
+			value: -1,
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.LoggingConfig.LogLevel == nil || *cfg.LoggingConfig.LogLevel != -1 {
+					t.Errorf("expected log level -1, got %v", cfg.LoggingConfig.LogLevel)
+				}
+			},
+		},
+		{
+			path:  "http_server.cors.allowed_origins",
+			value: "https://a.example,https://b.example",
+			check: func(t *testing.T, cfg *Config) {
+				expected := []string{"https://a.example", "https://b.example"}
+				if !reflect.DeepEqual(cfg.HTTPServerConfig.CORSConfig.AllowedOrigins, expected) {
+					t.Errorf("expected %v, got %v", expected, cfg.HTTPServerConfig.CORSConfig.AllowedOrigins)
+				}
+			},
+		},
+		{
+			path:  "features.flags.beta",
+			value: "true",
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.FeatureConfig.Flags["beta"] {
+					t.Errorf("expected the beta flag to be set, got %v", cfg.FeatureConfig.Flags)
+				}
+			},
+		},
+		{
+			path:  "storage.s3.bucket",
+			value: "files",
+			check: func(t *testing.T, cfg *Config) {
+				// the section behind the nil pointer is allocated
+				if cfg.StorageConfig.S3Config == nil || cfg.StorageConfig.S3Config.Bucket != "files" {
+					t.Errorf("expected the bucket to be set, got %+v", cfg.StorageConfig.S3Config)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			if err := SetByPath(cfg, tt.path, tt.value); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.check(t, cfg)
+
+			// the value can be read back
+			if _, err := GetByPath(cfg, tt.path); err != nil {
+				t.Errorf("failed to get the value back: %v", err)
+			}
+		})
+	}
+}
+
+func TestByPathErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{name: "unknown section", path: "unknown", wantErr: `config path "unknown" not found`},
+		{name: "unknown field", path: "http_server.unknown", wantErr: `config path "http_server.unknown" not found`},
+		{name: "below a scalar", path: "http_server.port.value", wantErr: `config path "http_server.port.value" not found`},
+		{name: "invalid index", path: "http_server.cors.allowed_origins.x", wantErr: `invalid index "x"`},
+		{name: "unknown field behind a nil pointer", path: "storage.s3.unknown", wantErr: `config path "storage.s3.unknown" not found`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+
+			_, err := GetByPath(cfg, tt.path)
+			assertErrorContains(t, err, tt.wantErr)
+
+			err = SetByPath(cfg, tt.path, "1")
+			assertErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	// a missing map key can be set, but not read
+	_, err := GetByPath(defaultConfig(t), "features.flags.missing")
+	assertErrorContains(t, err, `config path "features.flags.missing" not found`)
+
+	err = SetByPath(defaultConfig(t), "http_server.port", "not a number")
+	assertErrorContains(t, err, "invalid value for http_server.port")
+}
@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// profilesKey is the top-level key of the profiles in the config files, such as:
+//
+//	http_server:
+//	  port: 8080
+//	profiles:
+//	  prod:
+//	    http_server:
+//	      port: 80
+//
+// The profiles are not part of Config; the selected one is merged over the base config, see WithProfile.
+const profilesKey = "profiles"
+
+// WithProfile selects the profile to merge over the base config, such as `prod`. The profiles are in the `profiles`
+// key of the config files, keyed by their names, and have the same structure as the config.
+//
+// If no profile is selected with this option, the `<prefix>_PROFILE` environment variable is used, such as
+// `APP_PROFILE=prod`, see WithEnvPrefix. If neither is set, no profile is merged. The profile names are case-insensitive.
+func WithProfile(name string) LoadOption {
+	return func(o *loadOptions) {
+		o.profile = name
+	}
+}
+
+// applyProfile merges the selected profile over the base config read by Viper, at the key level like the overlays.
+// An error is returned if the selected profile doesn't exist in the config files.
+func applyProfile(v *viper.Viper, options *loadOptions) error {
+	profile := options.profile
+	if profile == "" {
+		profile = os.Getenv(options.envPrefix + "_PROFILE")
+	}
+	if profile == "" {
+		return nil
+	}
+	// Viper lowercases the keys
+	profile = strings.ToLower(profile)
+
+	profiles := v.GetStringMap(profilesKey)
+	settings, ok := profiles[profile].(map[string]interface{})
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("%w: profile %q not found, available profiles: %s", ErrReadConfig, profile, strings.Join(names, ", "))
+	}
+	return v.MergeConfigMap(settings)
+}
@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+)
+
+const profilesConfig = `
+http_server:
+  port: 9090
+logging:
+  log_format: pretty
+profiles:
+  prod:
+    http_server:
+      port: 80
+  staging:
+    http_server:
+      bind_address: 127.0.0.1
+    logging:
+      log_format: json
+`
+
+func TestWithProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []LoadOption
+		env         string
+		port        int
+		bindAddress string
+	}{
+		{name: "no profile", port: 9090, bindAddress: "0.0.0.0"},
+		{name: "selected profile", opts: []LoadOption{WithProfile("prod")}, port: 80, bindAddress: "0.0.0.0"},
+		{name: "case-insensitive name", opts: []LoadOption{WithProfile("PROD")}, port: 80, bindAddress: "0.0.0.0"},
+		{name: "profile from the environment", env: "staging", port: 9090, bindAddress: "127.0.0.1"},
+		{name: "option beats the environment", opts: []LoadOption{WithProfile("prod")}, env: "staging", port: 80, bindAddress: "0.0.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("APP_PROFILE", tt.env)
+			}
+
+			// strict, so that the profiles are not reported as unknown keys
+			cfg := mustLoadYAML(t, profilesConfig, append(tt.opts, WithStrict(true))...)
+			if cfg.HTTPServerConfig.Port != tt.port {
+				t.Errorf("expected port %d, got %d", tt.port, cfg.HTTPServerConfig.Port)
+			}
+			if cfg.HTTPServerConfig.BindAddress != tt.bindAddress {
+				t.Errorf("expected bind address %s, got %s", tt.bindAddress, cfg.HTTPServerConfig.BindAddress)
+			}
+		})
+	}
+}
+
+func TestWithProfileKeepsBaseConfig(t *testing.T) {
+	cfg := mustLoadYAML(t, profilesConfig, WithProfile("prod"))
+
+	// the keys not in the profile are kept from the base config, and the unselected profiles don't leak in
+	if cfg.LoggingConfig.LogFormat != "pretty" {
+		t.Errorf("expected the log format pretty from the base config, got %q", cfg.LoggingConfig.LogFormat)
+	}
+}
+
+func TestWithProfileNotFound(t *testing.T) {
+	_, err := LoadConfigFromBytes([]byte(profilesConfig), "yaml", WithProfile("dev"))
+	assertErrorContains(t, err, `profile "dev" not found, available profiles: prod, staging`)
+	if !errors.Is(err, ErrReadConfig) {
+		t.Errorf("expected ErrReadConfig, got %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package pkg
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// PprofHandler returns an HTTP handler that serves the standard pprof routes under `/debug/pprof/`, to be served
+// on the profiling listener. Returns a handler that responds with 404 to all requests if profiling is disabled.
+//
+// The routes are registered on a new mux, so that they are not exposed on http.DefaultServeMux.
+func (p ProfilingConfig) PprofHandler() http.Handler {
+	if !p.Enabled {
+		return http.NotFoundHandler()
+	}
+
+	mux := http.NewServeMux()
+	// the index also serves the named profiles, such as `/debug/pprof/heap`
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
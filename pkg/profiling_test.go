@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestValidateProfilingConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		profiling ProfilingConfig
+		expected  []string
+	}{
+		{name: "disabled without a port", profiling: ProfilingConfig{BindAddress: "127.0.0.1"}},
+		{name: "enabled", profiling: ProfilingConfig{Enabled: true, BindAddress: "127.0.0.1", Port: 6060}},
+		{name: "enabled without a port", profiling: ProfilingConfig{Enabled: true, BindAddress: "127.0.0.1"}, expected: []string{"profiling.port"}},
+		{name: "invalid port", profiling: ProfilingConfig{Enabled: true, BindAddress: "127.0.0.1", Port: 70000}, expected: []string{"profiling.port"}},
+		{name: "invalid bind address", profiling: ProfilingConfig{Enabled: true, BindAddress: "not an address", Port: 6060}, expected: []string{"profiling.bind_address"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.ProfilingConfig = tt.profiling
+
+			paths := validationPaths(Validate(cfg))
+			if !slices.Equal(paths, tt.expected) {
+				t.Errorf("expected the failing fields %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+}
+
+func TestPprofHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		path     string
+		expected int
+	}{
+		{name: "index", enabled: true, path: "/debug/pprof/", expected: http.StatusOK},
+		{name: "named profile", enabled: true, path: "/debug/pprof/heap", expected: http.StatusOK},
+		{name: "other path", enabled: true, path: "/other", expected: http.StatusNotFound},
+		{name: "disabled", path: "/debug/pprof/", expected: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := ProfilingConfig{Enabled: tt.enabled}.PprofHandler()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, rec.Code)
+			}
+		})
+	}
+}
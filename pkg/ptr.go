@@ -0,0 +1,11 @@
+package pkg
+
+// Ptr returns a pointer to the given value. This is useful for setting the pointer fields of the configuration,
+// such as `cfg.MetricsConfig.Enabled = Ptr(false)`.
+//
+// The scalar fields are pointers when their zero value is a valid setting that differs from their default,
+// such as `false` for a toggle that defaults to `true`. The defaults are only applied to the nil pointers,
+// so an explicit zero value in the config file is kept.
+func Ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,54 @@
+package pkg
+
+import "testing"
+
+func TestPtr(t *testing.T) {
+	p := Ptr(false)
+	if p == nil || *p {
+		t.Errorf("expected a pointer to false, got %v", p)
+	}
+	// every call returns a new pointer
+	if Ptr(1) == Ptr(1) {
+		t.Error("expected different pointers")
+	}
+}
+
+func TestPointerScalarDefaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		compression bool
+		logLevel    int8
+	}{
+		{name: "not set", content: "", compression: true, logLevel: 2},
+		{name: "explicit zero values", content: "http_server:\n  compression: false\nlogging:\n  log_level: 0\n", compression: false, logLevel: 0},
+		{name: "explicit non-default values", content: "http_server:\n  compression: true\nlogging:\n  log_level: -1\n", compression: true, logLevel: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := mustLoadYAML(t, tt.content)
+			// the explicit values in the file are not overwritten by the defaults
+			if cfg.HTTPServerConfig.Compression == nil || *cfg.HTTPServerConfig.Compression != tt.compression {
+				t.Errorf("expected compression %v, got %v", tt.compression, cfg.HTTPServerConfig.Compression)
+			}
+			if cfg.LoggingConfig.LogLevel == nil || *cfg.LoggingConfig.LogLevel != tt.logLevel {
+				t.Errorf("expected log level %d, got %v", tt.logLevel, cfg.LoggingConfig.LogLevel)
+			}
+		})
+	}
+}
+
+func TestValidatePointerScalarRequired(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.HTTPServerConfig.Compression = nil
+	if paths := validationPaths(Validate(cfg)); len(paths) != 1 || paths[0] != "http_server.compression" {
+		t.Errorf("expected the unset compression to be reported, got %v", paths)
+	}
+
+	// an explicit false is a valid setting
+	cfg.HTTPServerConfig.Compression = Ptr(false)
+	if err := Validate(cfg); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
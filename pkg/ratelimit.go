@@ -0,0 +1,12 @@
+package pkg
+
+import "golang.org/x/time/rate"
+
+// Limiter builds a token-bucket rate limiter from the configuration.
+// Returns nil if rate limiting is disabled.
+func (r RateLimitConfig) Limiter() *rate.Limiter {
+	if !r.Enabled {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(r.RequestsPerSecond), r.Burst)
+}
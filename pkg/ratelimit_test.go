@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"slices"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimitConfigLimiter(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    RateLimitConfig
+		wantLimit rate.Limit
+		wantBurst int
+		wantNil   bool
+	}{
+		{name: "disabled", config: RateLimitConfig{RequestsPerSecond: 10, Burst: 5}, wantNil: true},
+		{name: "enabled", config: RateLimitConfig{Enabled: true, RequestsPerSecond: 10, Burst: 5}, wantLimit: 10, wantBurst: 5},
+		{name: "fractional rate", config: RateLimitConfig{Enabled: true, RequestsPerSecond: 0.5, Burst: 1}, wantLimit: 0.5, wantBurst: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := tt.config.Limiter()
+			if tt.wantNil {
+				if limiter != nil {
+					t.Errorf("expected no limiter, got %v", limiter)
+				}
+				return
+			}
+			if limiter == nil {
+				t.Fatal("expected a limiter, got nil")
+			}
+			if limiter.Limit() != tt.wantLimit {
+				t.Errorf("expected limit %v, got %v", tt.wantLimit, limiter.Limit())
+			}
+			if limiter.Burst() != tt.wantBurst {
+				t.Errorf("expected burst %d, got %d", tt.wantBurst, limiter.Burst())
+			}
+		})
+	}
+}
+
+func TestValidateRateLimitConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    RateLimitConfig
+		wantPaths []string
+	}{
+		{name: "disabled without a rate", config: RateLimitConfig{Burst: 1}},
+		{name: "enabled", config: RateLimitConfig{Enabled: true, RequestsPerSecond: 10, Burst: 5}},
+		{name: "enabled without a rate", config: RateLimitConfig{Enabled: true, Burst: 1}, wantPaths: []string{"rate_limit.requests_per_second"}},
+		{name: "negative rate", config: RateLimitConfig{Enabled: true, RequestsPerSecond: -1, Burst: 1}, wantPaths: []string{"rate_limit.requests_per_second"}},
+		{name: "burst below one", config: RateLimitConfig{Enabled: true, RequestsPerSecond: 10, Burst: -1}, wantPaths: []string{"rate_limit.burst"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.RateLimitConfig = tt.config
+			paths := validationPaths(Validate(cfg))
+			if !slices.Equal(paths, tt.wantPaths) {
+				t.Errorf("expected the errors of %v, got %v", tt.wantPaths, paths)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// redactedValue is the value that replaces sensitive fields in the redacted configuration.
+const redactedValue = "***"
+
+// Redacted returns a deep copy of the configuration with the sensitive fields masked.
+// Fields tagged with `sensitive:"true"` are replaced by "***", unless they're empty.
+// Empty fields are kept empty, so that the output still shows that the value is not set.
+//
+// The result is meant to be used for output, such as logging, and not for running business logic.
+func Redacted(cfg *Config) *Config {
+	redacted := cfg.Clone()
+	redact(reflect.ValueOf(redacted))
+	return redacted
+}
+
+// String returns the configuration as YAML, with the sensitive fields masked, see Redacted.
+// This makes it safe to log the configuration, such as with `log.Printf("%v", cfg)`.
+func (c *Config) String() string {
+	if c == nil {
+		return "<nil>"
+	}
+	out, err := Marshal(Redacted(c), "yaml")
+	if err != nil {
+		return fmt.Sprintf("<failed to marshal config: %v>", err)
+	}
+	return string(out)
+}
+
+// redact masks the fields tagged with `sensitive:"true"` in the given value, in place.
+func redact(v reflect.Value) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		fieldValue := v.Field(i)
+		if !fieldValue.CanSet() {
+			continue
+		}
+
+		if field.Tag.Get("sensitive") != "true" {
+			redact(fieldValue)
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Ptr {
+			if fieldValue.IsNil() {
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
+		if fieldValue.Kind() == reflect.String && fieldValue.String() != "" {
+			fieldValue.SetString(redactedValue)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedacted(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.DatabaseConfig.Password = "secret"
+	cfg.DatabaseConfig.User = "admin"
+	cfg.AuthConfig.APIKey = ""
+	cfg.StorageConfig.Type = "s3"
+	cfg.StorageConfig.S3Config = &S3StorageConfig{Bucket: "files", AccessKeyID: "id", SecretAccessKey: "s3-secret"}
+
+	redacted := Redacted(cfg)
+
+	if redacted.DatabaseConfig.Password != redactedValue {
+		t.Errorf("expected the password to be masked, got %q", redacted.DatabaseConfig.Password)
+	}
+	// the nested pointer structs are walked as well
+	if redacted.StorageConfig.S3Config.SecretAccessKey != redactedValue {
+		t.Errorf("expected the secret access key to be masked, got %q", redacted.StorageConfig.S3Config.SecretAccessKey)
+	}
+	// the empty sensitive fields stay empty
+	if redacted.AuthConfig.APIKey != "" {
+		t.Errorf("expected the empty API key to stay empty, got %q", redacted.AuthConfig.APIKey)
+	}
+	// the other fields are untouched
+	if redacted.DatabaseConfig.User != "admin" || redacted.StorageConfig.S3Config.AccessKeyID != "id" {
+		t.Errorf("expected the other fields to be untouched, got user %q and access key ID %q",
+			redacted.DatabaseConfig.User, redacted.StorageConfig.S3Config.AccessKeyID)
+	}
+	if redacted.HTTPServerConfig.Port != cfg.HTTPServerConfig.Port {
+		t.Errorf("expected the port %d, got %d", cfg.HTTPServerConfig.Port, redacted.HTTPServerConfig.Port)
+	}
+
+	// the original is not modified
+	if cfg.DatabaseConfig.Password != "secret" || cfg.StorageConfig.S3Config.SecretAccessKey != "s3-secret" {
+		t.Error("expected the original config not to be modified")
+	}
+}
+
+func TestConfigString(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.DatabaseConfig.Password = "secret"
+
+	s := cfg.String()
+	if strings.Contains(s, "secret") {
+		t.Errorf("expected the password to be masked in:\n%s", s)
+	}
+	if !strings.Contains(s, "password: '***'") && !strings.Contains(s, `password: "***"`) {
+		t.Errorf("expected the masked password in:\n%s", s)
+	}
+
+	var nilConfig *Config
+	if nilConfig.String() != "<nil>" {
+		t.Errorf("expected <nil>, got %q", nilConfig.String())
+	}
+}
+
+func TestConfigStringZeroValue(t *testing.T) {
+	// must not panic on a config without the defaults
+	if s := (&Config{}).String(); s == "" {
+		t.Error("expected the YAML of the zero config, got an empty string")
+	}
+}
@@ -0,0 +1,131 @@
+package pkg
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/viper"
+)
+
+// Reload applies the changes of the reloadable fields in the next configuration to the current one, such as a new
+// log level, and returns the result as a new Config. The fields with the `reloadable:"true"` tag are reloadable;
+// the tag on a section, such as `logging`, makes all of its fields reloadable.
+//
+// The changes of the other fields, such as `http_server.port`, require a restart, so they are ignored and returned,
+// and logged as well. The result is validated, as the reloadable fields can depend on the others, see Validate.
+// Neither the current nor the next configuration is modified.
+func Reload(current, next *Config, opts ...HandleOption) (*Config, []Change, error) {
+	reloaded := current.Clone()
+	copyReloadable(reflect.ValueOf(reloaded).Elem(), reflect.ValueOf(next.Clone()).Elem())
+
+	// what differs now is what couldn't be reloaded
+	ignored := Diff(reloaded, next)
+	for _, change := range ignored {
+		// the values are not logged, as they can be secrets
+		log.Printf("Ignoring the change of %s, which requires a restart", change.Path)
+	}
+
+	if err := Validate(reloaded, opts...); err != nil {
+		return nil, ignored, err
+	}
+	return reloaded, ignored, nil
+}
+
+// copyReloadable copies the fields with the `reloadable` tag from src to dst, recursively. The source must not be
+// shared, as the slices, maps and pointers are copied as they are.
+func copyReloadable(dst, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Tag.Get("reloadable") == "true" {
+			dst.Field(i).Set(src.Field(i))
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct {
+			copyReloadable(dst.Field(i), src.Field(i))
+		}
+	}
+}
+
+// ReloadOnSignal reloads the configuration when the process receives SIGHUP, such as from `kill -HUP`, and returns
+// a function that stops it. On every signal, the config file used by Viper is read again and loaded like LoadConfig
+// does with the given options, so the overlays, the environment variables, the flags and the programmatic defaults
+// still apply, and only its reloadable changes are applied to the configuration in the store, see Reload. onChange is
+// called with the old and the new configuration, and can be nil.
+//
+// If the config can't be read or is not valid, the error is logged and the configuration in the store is kept.
+func ReloadOnSignal(v *viper.Viper, store *Store, onChange func(old, new *Config), opts ...LoadOption) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-signals:
+			}
+
+			old, next, err := reloadConfig(v, store, opts)
+			if err != nil {
+				log.Printf("Ignoring the reload, keeping the old config: %v", err)
+				continue
+			}
+			if onChange != nil {
+				onChange(old, next)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
+
+// reloadConfig loads the config file used by Viper with the given options and puts it in the store, returning the
+// old and the new configuration. If the store is not empty, only the reloadable changes are applied, see Reload.
+// If the configuration is not valid, the store is not changed.
+//
+// The config file and the overlays are read into a new Viper instance, so that nothing is carried over from the
+// previous loads, such as the value of an environment variable that is unset since.
+func reloadConfig(v *viper.Viper, store *Store, opts []LoadOption) (old, next *Config, err error) {
+	ctx := context.Background()
+	options := newLoadOptions(opts)
+
+	var paths []string
+	if path := v.ConfigFileUsed(); path != "" {
+		paths = append(paths, path)
+	}
+	paths = append(paths, options.overlays...)
+
+	fresh := viper.New()
+	if err := readConfigs(ctx, fresh, paths, false, options); err != nil {
+		return nil, nil, err
+	}
+	next, err = load(ctx, fresh, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	old = store.Load()
+	if old != nil {
+		next, _, err = Reload(old, next, options.handleOpts...)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	store.Store(next)
+	return old, next, nil
+}
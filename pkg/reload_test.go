@@ -0,0 +1,156 @@
+package pkg
+
+import (
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	current := mustLoadYAML(t, "http_server:\n  port: 8080\nlogging:\n  log_level: 2\n")
+	next := mustLoadYAML(t, "http_server:\n  port: 9090\nlogging:\n  log_level: 0\n")
+
+	reloaded, ignored, err := Reload(current, next)
+	if err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+
+	if *reloaded.LoggingConfig.LogLevel != 0 {
+		t.Errorf("expected the reloadable log level to be 0, got %d", *reloaded.LoggingConfig.LogLevel)
+	}
+	if reloaded.HTTPServerConfig.Port != 8080 {
+		t.Errorf("expected the port to stay 8080, got %d", reloaded.HTTPServerConfig.Port)
+	}
+
+	var paths []string
+	for _, change := range ignored {
+		paths = append(paths, change.Path)
+	}
+	if !slices.Equal(paths, []string{"http_server.port"}) {
+		t.Errorf("expected only the port change to be ignored, got %v", paths)
+	}
+
+	if *current.LoggingConfig.LogLevel != 2 || next.HTTPServerConfig.Port != 9090 {
+		t.Error("expected the current and the next config not to be modified")
+	}
+}
+
+func TestReloadInvalid(t *testing.T) {
+	current := defaultConfig(t)
+	next := defaultConfig(t)
+	invalid := int8(9)
+	next.LoggingConfig.LogLevel = &invalid
+
+	if _, _, err := Reload(current, next); err == nil {
+		t.Fatal("expected an error for the invalid log level")
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	// the environment variables still apply on reload
+	t.Setenv("APP_LOGGING_LOG_FORMAT", "pretty")
+
+	v, path := readConfigFile(t, "http_server:\n  port: 8080\nlogging:\n  log_level: 2\n")
+	var store Store
+
+	// the empty store gets the whole config
+	old, next, err := reloadConfig(v, &store, nil)
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	if old != nil || store.Load() != next || next.HTTPServerConfig.Port != 8080 {
+		t.Fatalf("expected the config to be stored as a whole, got old %v and port %d", old, next.HTTPServerConfig.Port)
+	}
+
+	// only the reloadable change is applied
+	if err := os.WriteFile(path, []byte("http_server:\n  port: 9090\nlogging:\n  log_level: 0\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old, next, err = reloadConfig(v, &store, nil)
+	if err != nil {
+		t.Fatalf("failed to reload the config: %v", err)
+	}
+	if *old.LoggingConfig.LogLevel != 2 || store.Load() != next {
+		t.Error("expected the old config to be returned and the new one to be stored")
+	}
+	if *next.LoggingConfig.LogLevel != 0 || next.HTTPServerConfig.Port != 8080 {
+		t.Errorf("expected log level 0 and port 8080, got %d and %d", *next.LoggingConfig.LogLevel, next.HTTPServerConfig.Port)
+	}
+	if next.LoggingConfig.LogFormat != "pretty" {
+		t.Errorf("expected the log format pretty from the environment, got %q", next.LoggingConfig.LogFormat)
+	}
+
+	// the invalid config is not stored
+	if err := os.WriteFile(path, []byte("logging:\n  log_level: 9\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := reloadConfig(v, &store, nil); err == nil {
+		t.Fatal("expected an error for the invalid log level")
+	}
+	if store.Load() != next {
+		t.Error("expected the store to keep the old config")
+	}
+}
+
+func TestReloadConfigOptions(t *testing.T) {
+	v, _ := readConfigFile(t, "http_server:\n  port: 8080\n")
+	var store Store
+
+	_, next, err := reloadConfig(v, &store, []LoadOption{WithDefaults(map[string]interface{}{"logging.log_level": 4})})
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	if *next.LoggingConfig.LogLevel != 4 {
+		t.Errorf("expected log level 4 from the defaults option, got %d", *next.LoggingConfig.LogLevel)
+	}
+}
+
+func TestReloadConfigOverlays(t *testing.T) {
+	v, _ := readConfigFile(t, "logging:\n  log_level: 2\n")
+	overlay := writeFile(t, "overlay.yaml", "logging:\n  log_level: 4\n")
+	var store Store
+
+	_, next, err := reloadConfig(v, &store, []LoadOption{WithOverlays(overlay)})
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	if *next.LoggingConfig.LogLevel != 4 {
+		t.Errorf("expected log level 4 from the overlay, got %d", *next.LoggingConfig.LogLevel)
+	}
+	// the overlays are read into a new Viper instance, so the config file of the given one is kept
+	if v.ConfigFileUsed() == overlay {
+		t.Error("expected the config file of Viper not to be changed")
+	}
+}
+
+func TestReloadOnSignal(t *testing.T) {
+	v, path := readConfigFile(t, "http_server:\n  port: 8080\nlogging:\n  log_level: 2\n")
+	store := NewStore(mustLoadYAML(t, "http_server:\n  port: 8080\nlogging:\n  log_level: 2\n"))
+
+	changes := make(chan *Config, 1)
+	stop := ReloadOnSignal(v, store, func(old, new *Config) {
+		changes <- new
+	})
+	defer stop()
+
+	if err := os.WriteFile(path, []byte("http_server:\n  port: 9090\nlogging:\n  log_level: 0\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case cfg := <-changes:
+		if *cfg.LoggingConfig.LogLevel != 0 || cfg.HTTPServerConfig.Port != 8080 {
+			t.Errorf("expected log level 0 and port 8080, got %d and %d", *cfg.LoggingConfig.LogLevel, cfg.HTTPServerConfig.Port)
+		}
+		if store.Load() != cfg {
+			t.Error("expected the new config to be stored")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the config to be reloaded on SIGHUP")
+	}
+}
@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ResetSection resets the section of the configuration at the given JSON path, such as `logging` or
+// `http_server.tls`, to its defaults. The section is zeroed and the defaults are applied only to it, so the other
+// sections are left untouched. The optional sections that are not set, such as `storage.s3`, are left unset.
+// The whole configuration is validated afterward, see Validate.
+//
+// The options are passed to the defaulting and the validation, see HandleConfig.
+func ResetSection(cfg *Config, section string, opts ...HandleOption) error {
+	value, err := sectionValue(cfg, section)
+	if err != nil {
+		return err
+	}
+
+	// a section behind a nil pointer, such as `storage.s3` when it is not set, is left unset
+	if value.CanSet() {
+		value.Set(reflect.Zero(value.Type()))
+		if err := applyDefaults(value.Addr().Interface(), newHandleOptions(opts)); err != nil {
+			return fmt.Errorf("failed to apply the defaults of %s: %w", section, err)
+		}
+	}
+	return Validate(cfg, opts...)
+}
+
+// sectionValue returns the settable value of the section of the configuration at the given JSON path.
+// An error is returned if there is no such field, or if the field is not a section, such as `http_server.port`.
+//
+// The nil pointers are not allocated, so the value of a section behind a nil pointer is not settable.
+func sectionValue(cfg *Config, section string) (reflect.Value, error) {
+	value, mapKey, err := resolvePath(cfg, section, false)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("unknown config section %q", section)
+	}
+	if value.Kind() == reflect.Ptr && value.Type().Elem().Kind() == reflect.Struct {
+		if value.IsNil() {
+			value = reflect.Zero(value.Type().Elem())
+		} else {
+			value = value.Elem()
+		}
+	}
+	if mapKey.IsValid() || value.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%q is not a config section", section)
+	}
+	return value, nil
+}
@@ -0,0 +1,83 @@
+package pkg
+
+import "testing"
+
+func TestResetSection(t *testing.T) {
+	cfg := mustLoadYAML(t, "http_server:\n  port: 9090\nlogging:\n  log_level: 4\n  log_format: pretty\n")
+
+	if err := ResetSection(cfg, "logging"); err != nil {
+		t.Fatalf("failed to reset the section: %v", err)
+	}
+	if cfg.LoggingConfig.LogLevel == nil || *cfg.LoggingConfig.LogLevel != 2 {
+		t.Errorf("expected the default log level 2, got %v", cfg.LoggingConfig.LogLevel)
+	}
+	if cfg.LoggingConfig.LogFormat != "json" {
+		t.Errorf("expected the default log format json, got %q", cfg.LoggingConfig.LogFormat)
+	}
+	// the other sections are untouched
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected the port to be kept, got %d", cfg.HTTPServerConfig.Port)
+	}
+}
+
+func TestResetSectionNested(t *testing.T) {
+	cfg := mustLoadYAML(t, "http_server:\n  port: 9090\n  tls:\n    min_version: \"1.2\"\n")
+
+	if err := ResetSection(cfg, "http_server.tls"); err != nil {
+		t.Fatalf("failed to reset the section: %v", err)
+	}
+	if cfg.HTTPServerConfig.TLSConfig.MinVersion != "1.3" {
+		t.Errorf("expected the default min version 1.3, got %q", cfg.HTTPServerConfig.TLSConfig.MinVersion)
+	}
+	// the parent section is untouched
+	if cfg.HTTPServerConfig.Port != 9090 {
+		t.Errorf("expected the port to be kept, got %d", cfg.HTTPServerConfig.Port)
+	}
+}
+
+func TestResetSectionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		wantErr string
+	}{
+		{name: "unknown section", section: "unknown", wantErr: `unknown config section "unknown"`},
+		{name: "not a section", section: "http_server.port", wantErr: `"http_server.port" is not a config section`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertErrorContains(t, ResetSection(defaultConfig(t), tt.section), tt.wantErr)
+		})
+	}
+}
+
+func TestResetSectionValidates(t *testing.T) {
+	// the whole config is validated, not only the reset section
+	cfg := defaultConfig(t)
+	cfg.HTTPServerConfig.Port = 70000
+
+	err := ResetSection(cfg, "logging")
+	assertErrorContains(t, err, "http_server.port")
+}
+
+func TestResetSectionPointer(t *testing.T) {
+	// an unset section is left unset
+	cfg := defaultConfig(t)
+	if err := ResetSection(cfg, "storage.s3"); err != nil {
+		t.Fatalf("failed to reset the section: %v", err)
+	}
+	if cfg.StorageConfig.S3Config != nil {
+		t.Errorf("expected the unset section to stay nil, got %+v", cfg.StorageConfig.S3Config)
+	}
+
+	// a set section is reset in place
+	cfg = mustLoadYAML(t, "storage:\n  type: local\n  local:\n    path: /var/lib/app\n")
+	local := cfg.StorageConfig.LocalConfig
+	if err := ResetSection(cfg, "storage.local"); err != nil {
+		t.Fatalf("failed to reset the section: %v", err)
+	}
+	if cfg.StorageConfig.LocalConfig != local || local.Path != "./data" {
+		t.Errorf("expected the default path ./data, got %+v", cfg.StorageConfig.LocalConfig)
+	}
+}
@@ -0,0 +1,20 @@
+package pkg
+
+import (
+	"math"
+	"time"
+)
+
+// Backoff returns the wait before the given retry, starting from `1` for the first retry after the first attempt.
+// The wait is `initial_backoff * multiplier^(attempt-1)`, capped at `max_backoff`. Returns 0 for the attempts below 1.
+func (r RetryConfig) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		return 0
+	}
+	backoff := float64(r.InitialBackoff.Duration()) * math.Pow(r.Multiplier, float64(attempt-1))
+	// the float overflows to +Inf for the large attempts, which is capped as well
+	if maxBackoff := float64(r.MaxBackoff.Duration()); backoff > maxBackoff {
+		return r.MaxBackoff.Duration()
+	}
+	return time.Duration(backoff)
+}
@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRetryConfigBackoff(t *testing.T) {
+	retry := RetryConfig{
+		MaxAttempts:    5,
+		InitialBackoff: Duration(100 * time.Millisecond),
+		MaxBackoff:     Duration(time.Second),
+		Multiplier:     2,
+	}
+
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{attempt: -1, expected: 0},
+		{attempt: 0, expected: 0},
+		{attempt: 1, expected: 100 * time.Millisecond},
+		{attempt: 2, expected: 200 * time.Millisecond},
+		{attempt: 3, expected: 400 * time.Millisecond},
+		{attempt: 4, expected: 800 * time.Millisecond},
+		// capped at the maximum
+		{attempt: 5, expected: time.Second},
+		{attempt: 10000, expected: time.Second},
+	}
+
+	for _, tt := range tests {
+		if backoff := retry.Backoff(tt.attempt); backoff != tt.expected {
+			t.Errorf("expected the backoff %s for the attempt %d, got %s", tt.expected, tt.attempt, backoff)
+		}
+	}
+}
+
+func TestRetryConfigDefaults(t *testing.T) {
+	expected := RetryConfig{
+		MaxAttempts:    3,
+		InitialBackoff: Duration(100 * time.Millisecond),
+		MaxBackoff:     Duration(10 * time.Second),
+		Multiplier:     2,
+	}
+	if retry := defaultConfig(t).DatabaseConfig.RetryConfig; retry != expected {
+		t.Errorf("expected the defaults %+v, got %+v", expected, retry)
+	}
+}
+
+func TestValidateRetryConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(retry *RetryConfig)
+		expected []string
+	}{
+		{name: "defaults", modify: func(retry *RetryConfig) {}},
+		{
+			name:     "no attempts",
+			modify:   func(retry *RetryConfig) { retry.MaxAttempts = -1 },
+			expected: []string{"database.retry.max_attempts"},
+		},
+		{
+			name:     "initial backoff too short",
+			modify:   func(retry *RetryConfig) { retry.InitialBackoff = Duration(time.Microsecond) },
+			expected: []string{"database.retry.initial_backoff"},
+		},
+		{
+			name:     "max backoff shorter than the initial backoff",
+			modify:   func(retry *RetryConfig) { retry.MaxBackoff = Duration(50 * time.Millisecond) },
+			expected: []string{"database.retry.max_backoff"},
+		},
+		{
+			name:     "max backoff equal to the initial backoff",
+			modify:   func(retry *RetryConfig) { retry.MaxBackoff = retry.InitialBackoff },
+			expected: nil,
+		},
+		{
+			name:     "multiplier of 1",
+			modify:   func(retry *RetryConfig) { retry.Multiplier = 1 },
+			expected: []string{"database.retry.multiplier"},
+		},
+		{
+			name:     "fractional multiplier",
+			modify:   func(retry *RetryConfig) { retry.Multiplier = 1.5 },
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			tt.modify(&cfg.DatabaseConfig.RetryConfig)
+
+			paths := validationPaths(Validate(cfg))
+			if !slices.Equal(paths, tt.expected) {
+				t.Errorf("expected the failing fields %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+}
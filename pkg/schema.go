@@ -0,0 +1,429 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/invopop/jsonschema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/aliok/best-go-config-setup/util"
+)
+
+// schemaOptions are the options for GenerateSchema.
+type schemaOptions struct {
+	id              string
+	version         string
+	withoutComments bool
+	inlineRefs      bool
+}
+
+// SchemaOption is an option for GenerateSchema.
+type SchemaOption func(*schemaOptions)
+
+// WithSchemaID sets the `$id` of the schema, such as a canonical URL. Some schema registries require a stable `$id`.
+// By default, the `$id` is derived from the Go package path of Config.
+func WithSchemaID(id string) SchemaOption {
+	return func(o *schemaOptions) {
+		o.id = id
+	}
+}
+
+// WithSchemaVersion sets the `$schema` of the schema, which is the URL of the JSON schema draft version,
+// such as `https://json-schema.org/draft/2020-12/schema`, which is the default.
+func WithSchemaVersion(version string) SchemaOption {
+	return func(o *schemaOptions) {
+		o.version = version
+	}
+}
+
+// WithInlineRefs inlines the definitions in place of the `$ref`s, producing a self-contained schema without `$defs`,
+// for the schema consumers that don't support references. See util.InlineRefs.
+func WithInlineRefs() SchemaOption {
+	return func(o *schemaOptions) {
+		o.inlineRefs = true
+	}
+}
+
+// withoutComments skips reading the Go comments, so that the schema can be generated without the source code of
+// this package, such as in a deployed binary. The schema doesn't have the descriptions then.
+func withoutComments() SchemaOption {
+	return func(o *schemaOptions) {
+		o.withoutComments = true
+	}
+}
+
+// GenerateSchema generates the JSON schema for the configuration.
+//
+// The descriptions in the schema are read from the Go comments in the source code of this package,
+// so this function must be called from the root directory of the module.
+func GenerateSchema(opts ...SchemaOption) ([]byte, error) {
+	options := &schemaOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	reflector := newBaseReflector()
+	if !options.withoutComments {
+		var err error
+		if reflector, err = newReflector(); err != nil {
+			return nil, err
+		}
+	}
+	// generate the JSON schema
+	schema := reflector.Reflect(&Config{})
+	if options.id != "" {
+		schema.ID = jsonschema.ID(options.id)
+	}
+	if options.version != "" {
+		schema.Version = options.version
+	}
+
+	// fix the schema for arrays
+	if err := util.VisitSchema(schema, "array", util.FixArrayDefaultValues); err != nil {
+		return nil, fmt.Errorf("failed to fix array default values: %w", err)
+	}
+
+	// the defaults referencing environment variables, such as `default=$DEFAULT_PORT`, are resolved at generation time
+	if err := visitSchemaFields(schema, reflect.TypeOf(Config{}), applyDefaultRefs); err != nil {
+		return nil, fmt.Errorf("failed to resolve default values: %w", err)
+	}
+
+	// the required fields, as in the `validate` tags, so that the schema and the runtime validation agree
+	if err := applyRequiredRules(schema, reflect.TypeOf(Config{})); err != nil {
+		return nil, fmt.Errorf("failed to apply required rules: %w", err)
+	}
+
+	// the variants of the tagged unions, such as the storage backends, so that only the selected one can be set
+	if err := applyTaggedUnions(schema, reflect.TypeOf(Config{})); err != nil {
+		return nil, fmt.Errorf("failed to apply tagged unions: %w", err)
+	}
+
+	// the `description` tags override the descriptions from the Go comments
+	if err := visitSchemaFields(schema, reflect.TypeOf(Config{}), applyDescriptionTag); err != nil {
+		return nil, fmt.Errorf("failed to apply description tags: %w", err)
+	}
+	if err := visitSchemaFields(schema, reflect.TypeOf(Config{}), applyExampleTag); err != nil {
+		return nil, fmt.Errorf("failed to apply example tags: %w", err)
+	}
+	if err := visitSchemaFields(schema, reflect.TypeOf(Config{}), applyDeprecatedTag); err != nil {
+		return nil, fmt.Errorf("failed to apply deprecated tags: %w", err)
+	}
+	if err := visitSchemaFields(schema, reflect.TypeOf(Config{}), applyEnumDescTag); err != nil {
+		return nil, fmt.Errorf("failed to apply enumdesc tags: %w", err)
+	}
+	if err := visitSchemaFields(schema, reflect.TypeOf(Config{}), applyFormatTag); err != nil {
+		return nil, fmt.Errorf("failed to apply format tags: %w", err)
+	}
+
+	// inline the definitions last, so that the passes above only process each definition once
+	if options.inlineRefs {
+		if err := util.InlineRefs(schema); err != nil {
+			return nil, fmt.Errorf("failed to inline references: %w", err)
+		}
+	}
+
+	// marshal the schema to JSON
+	schemaJSON, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return schemaJSON, nil
+}
+
+// newReflector creates the JSON schema reflector for the configuration, with the Go comments loaded.
+func newReflector() (*jsonschema.Reflector, error) {
+	reflector := newBaseReflector()
+	// treat code comments as JSON schema descriptions
+	if err := reflector.AddGoComments("github.com/aliok/best-go-config-setup", "pkg"); err != nil {
+		return nil, fmt.Errorf("failed to add comments: %w", err)
+	}
+	return reflector, nil
+}
+
+// newBaseReflector creates the JSON schema reflector for the configuration, without the Go comments.
+func newBaseReflector() *jsonschema.Reflector {
+	reflector := new(jsonschema.Reflector)
+	reflector.Mapper = schemaMapper
+	return reflector
+}
+
+// schemaMapper provides the JSON schemas of the custom config types, such as Duration, ByteSize, CIDR and Time.
+func schemaMapper(t reflect.Type) *jsonschema.Schema {
+	switch t {
+	case durationType:
+		return durationSchema()
+	case byteSizeType:
+		return byteSizeSchema()
+	case cidrType:
+		return cidrSchema()
+	case timeType:
+		return timeSchema()
+	default:
+		return nil
+	}
+}
+
+// visitSchemaFields walks the fields of the given struct type recursively, along with their definitions in the
+// schema, and calls the visitor with the property schema of every field, including the struct fields.
+// The traversal stops at the first error returned by the visitor, which is then returned.
+//
+// This makes it possible to post-process the schema based on the struct tags, which the reflector doesn't know about.
+func visitSchemaFields(schema *jsonschema.Schema, t reflect.Type, visitor func(prop *jsonschema.Schema, field reflect.StructField) error) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// the reflector puts the schemas of the structs in the definitions, keyed by the type name
+	def, ok := schema.Definitions[t.Name()]
+	if !ok || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+		prop, ok := def.Properties.Get(name)
+		if !ok {
+			continue
+		}
+
+		if err := visitor(prop, field); err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+		if err := visitSchemaFields(schema, field.Type, visitor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyRequiredRules marks the fields with the required rules in their `validate` tags as required in the definitions
+// of the given struct type and its nested struct types, recursively:
+//
+//   - `required` adds the field to the `required` list of the struct,
+//   - `required_if=Other value` adds an `if`/`then`, which requires the field when the other field has the value,
+//   - `required_unless=Other value` adds an `if`/`else`, which requires the field unless the other field has the value,
+//   - `required_with=Other` adds the field to the `dependentRequired` list of the other field.
+//
+// The rules with multiple fields in the parameter, such as `required_if=A x B y`, are skipped.
+// As the defaults are applied before the validation, the schema describes complete configs, such as the reference
+// config, where the required fields are set to their defaults.
+func applyRequiredRules(schema *jsonschema.Schema, t reflect.Type) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	def, ok := schema.Definitions[t.Name()]
+	if !ok || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	// the conditional rules of the struct, keyed by the condition, in the order of the fields
+	type conditional struct {
+		field, value string
+		unless       bool
+		required     []string
+	}
+	var conditionals []*conditional
+	addConditional := func(name, param string, unless bool) {
+		fieldName, value, ok := strings.Cut(param, " ")
+		if !ok || strings.Contains(value, " ") {
+			return
+		}
+		for _, c := range conditionals {
+			if c.field == fieldName && c.value == value && c.unless == unless {
+				c.required = append(c.required, name)
+				return
+			}
+		}
+		conditionals = append(conditionals, &conditional{field: fieldName, value: value, unless: unless, required: []string{name}})
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+		if _, ok := def.Properties.Get(name); !ok {
+			continue
+		}
+
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			ruleName, param, _ := strings.Cut(rule, "=")
+			switch ruleName {
+			case "required":
+				if !slices.Contains(def.Required, name) {
+					def.Required = append(def.Required, name)
+				}
+			case "required_if":
+				addConditional(name, param, false)
+			case "required_unless":
+				addConditional(name, param, true)
+			case "required_with":
+				other, ok := t.FieldByName(param)
+				if !ok {
+					return fmt.Errorf("%s.%s: unknown field %q in the required_with rule", t.Name(), field.Name, param)
+				}
+				if def.DependentRequired == nil {
+					def.DependentRequired = map[string][]string{}
+				}
+				def.DependentRequired[jsonName(other)] = append(def.DependentRequired[jsonName(other)], name)
+			}
+		}
+
+		if err := applyRequiredRules(schema, field.Type); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range conditionals {
+		other, ok := t.FieldByName(c.field)
+		if !ok {
+			return fmt.Errorf("%s: unknown field %q in the required rules", t.Name(), c.field)
+		}
+		otherName := jsonName(other)
+		otherProp, ok := def.Properties.Get(otherName)
+		if !ok {
+			return fmt.Errorf("%s: field %q of the required rules is not in the schema", t.Name(), c.field)
+		}
+		value, err := util.ParseValue(c.value, otherProp.Type)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.Name(), err)
+		}
+
+		// the other field must be present for the condition to hold, as an absent field matches any `properties`
+		properties := jsonschema.NewProperties()
+		properties.Set(otherName, &jsonschema.Schema{Const: value})
+		condition := &jsonschema.Schema{Properties: properties, Required: []string{otherName}}
+		requirement := &jsonschema.Schema{Required: c.required}
+
+		rule := &jsonschema.Schema{If: condition, Then: requirement}
+		if c.unless {
+			rule = &jsonschema.Schema{If: condition, Else: requirement}
+		}
+		// a struct can have multiple conditions, so they are combined with `allOf`
+		def.AllOf = append(def.AllOf, rule)
+	}
+	return nil
+}
+
+// applyDescriptionTag sets the description of the field to its `description` tag, if set.
+// This is useful when the user-facing description should differ from the Go comment of the field.
+func applyDescriptionTag(prop *jsonschema.Schema, field reflect.StructField) error {
+	if description, ok := field.Tag.Lookup("description"); ok {
+		prop.Description = description
+	}
+	return nil
+}
+
+// applyExampleTag sets the examples of the field to the comma-separated values in its `example` tag, if set.
+// The values are converted to the type of the field, such as `8080` to an integer, see util.ParseValue.
+func applyExampleTag(prop *jsonschema.Schema, field reflect.StructField) error {
+	tag, ok := field.Tag.Lookup("example")
+	if !ok {
+		return nil
+	}
+
+	examples := make([]interface{}, 0)
+	for _, value := range strings.Split(tag, ",") {
+		example, err := util.ParseValue(strings.TrimSpace(value), prop.Type)
+		if err != nil {
+			return err
+		}
+		examples = append(examples, example)
+	}
+	prop.Examples = examples
+	return nil
+}
+
+// applyDeprecatedTag marks the field as deprecated if it has a `deprecated` tag, see WarnDeprecated.
+func applyDeprecatedTag(prop *jsonschema.Schema, field reflect.StructField) error {
+	if _, ok := field.Tag.Lookup("deprecated"); ok {
+		prop.Deprecated = true
+	}
+	return nil
+}
+
+// applyEnumDescTag sets the descriptions of the enum values of the field to the `|`-separated descriptions in its
+// `enumdesc` tag, if set, in the order of the enum values. They are put in the `enumDescriptions` keyword, which is
+// not standard but is shown by the IDEs, such as VS Code, when completing the values.
+func applyEnumDescTag(prop *jsonschema.Schema, field reflect.StructField) error {
+	tag, ok := field.Tag.Lookup("enumdesc")
+	if !ok {
+		return nil
+	}
+
+	descriptions := strings.Split(tag, "|")
+	if len(descriptions) != len(prop.Enum) {
+		return fmt.Errorf("got %d enum descriptions for %d enum values", len(descriptions), len(prop.Enum))
+	}
+	if prop.Extras == nil {
+		prop.Extras = map[string]interface{}{}
+	}
+	prop.Extras["enumDescriptions"] = descriptions
+	return nil
+}
+
+// applyFormatTag sets the format of the field to its `format` tag, if set, such as `uri` or `email`.
+// The IDEs use the format for validating the values.
+func applyFormatTag(prop *jsonschema.Schema, field reflect.StructField) error {
+	if format, ok := field.Tag.Lookup("format"); ok {
+		prop.Format = format
+	}
+	return nil
+}
+
+// SchemaHandler returns an HTTP handler that serves the JSON schema of the configuration, such as at `/config/schema`.
+// This lets the tools fetch the schema of the running version of the application.
+//
+// The schema is generated on the first request and cached. If the Go comments can't be read, such as when the
+// source code is not available, the schema is served without the descriptions.
+func SchemaHandler() http.Handler {
+	var once sync.Once
+	var schemaJSON []byte
+	var schemaErr error
+
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		once.Do(func() {
+			schemaJSON, schemaErr = GenerateSchema()
+			if schemaErr != nil {
+				log.Printf("Serving the config schema without descriptions: %v", schemaErr)
+				schemaJSON, schemaErr = GenerateSchema(withoutComments())
+			}
+		})
+		if schemaErr != nil {
+			http.Error(w, "failed to generate the config schema", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/schema+json")
+		_, _ = w.Write(schemaJSON)
+	})
+}
+
+// GenerateReferenceConfig generates the reference configuration as YAML.
+// The reference configuration is a blank configuration with all the defaults applied.
+// Each field is preceded by a comment, which is the Go comment of the field. See WriteAnnotatedConfig.
+func GenerateReferenceConfig() ([]byte, error) {
+	// create a blank Config instance, then set defaults.
+	// this is the reference configuration.
+	cfg := Config{}
+	if err := HandleConfig(&cfg); err != nil {
+		return nil, fmt.Errorf("error while defaulting or validating the blank config. Are you sure the default values for fields are good?: %w", err)
+	}
+
+	cfgYaml, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config to yaml: %w", err)
+	}
+	return annotate(cfgYaml)
+}
@@ -0,0 +1,377 @@
+package pkg
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/invopop/jsonschema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/aliok/best-go-config-setup/util"
+)
+
+// generateSchema generates the schema with the given options and unmarshals it into a map.
+func generateSchema(t *testing.T, opts ...SchemaOption) map[string]interface{} {
+	t.Helper()
+	schemaJSON, err := GenerateSchema(opts...)
+	if err != nil {
+		t.Fatalf("failed to generate the schema: %v", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(schemaJSON, &schema); err != nil {
+		t.Fatalf("failed to unmarshal the schema: %v", err)
+	}
+	return schema
+}
+
+// schemaDef returns the definition with the given name in the schema.
+func schemaDef(t *testing.T, schema map[string]interface{}, name string) map[string]interface{} {
+	t.Helper()
+	def, ok := schema["$defs"].(map[string]interface{})[name].(map[string]interface{})
+	if !ok {
+		t.Fatalf("definition %s not found in the schema", name)
+	}
+	return def
+}
+
+// schemaProperty returns the property with the given name of the definition with the given name in the schema.
+func schemaProperty(t *testing.T, schema map[string]interface{}, defName, name string) map[string]interface{} {
+	t.Helper()
+	prop, ok := schemaDef(t, schema, defName)["properties"].(map[string]interface{})[name].(map[string]interface{})
+	if !ok {
+		t.Fatalf("property %s not found in the definition %s", name, defName)
+	}
+	return prop
+}
+
+func TestGenerateSchema(t *testing.T) {
+	chdirModuleRoot(t)
+	schema := generateSchema(t)
+
+	prop := schemaProperty(t, schema, "Config", "http_server")
+	if prop["$ref"] != "#/$defs/HTTPServerConfig" {
+		t.Errorf("expected http_server to reference HTTPServerConfig, got %v", prop)
+	}
+	// the descriptions are read from the Go comments
+	if prop["description"] == nil {
+		t.Error("expected http_server to have a description")
+	}
+}
+
+func TestGenerateSchemaWithoutComments(t *testing.T) {
+	// works from any directory, as the source code is not read
+	schema := generateSchema(t, withoutComments())
+
+	prop := schemaProperty(t, schema, "Config", "http_server")
+	if prop["description"] != nil {
+		t.Errorf("expected no description, got %v", prop["description"])
+	}
+}
+
+func TestGenerateReferenceConfig(t *testing.T) {
+	chdirModuleRoot(t)
+	out, err := GenerateReferenceConfig()
+	if err != nil {
+		t.Fatalf("failed to generate the reference config: %v", err)
+	}
+
+	var cfg map[string]interface{}
+	if err := yaml.Unmarshal(out, &cfg); err != nil {
+		t.Fatalf("failed to unmarshal the reference config: %v", err)
+	}
+	httpServer, ok := cfg["http_server"].(map[string]interface{})
+	if !ok || httpServer["port"] != float64(8080) {
+		t.Errorf("expected http_server.port 8080 in the reference config, got %v", cfg["http_server"])
+	}
+}
+
+func TestGenerateSchemaIDAndVersion(t *testing.T) {
+	schema := generateSchema(t, withoutComments(),
+		WithSchemaID("https://config.example/app.schema.json"),
+		WithSchemaVersion("http://json-schema.org/draft-07/schema#"))
+	if schema["$id"] != "https://config.example/app.schema.json" {
+		t.Errorf("expected the $id to be set, got %v", schema["$id"])
+	}
+	if schema["$schema"] != "http://json-schema.org/draft-07/schema#" {
+		t.Errorf("expected the $schema to be set, got %v", schema["$schema"])
+	}
+
+	// the defaults of the reflector
+	schema = generateSchema(t, withoutComments())
+	if schema["$id"] != "https://github.com/aliok/best-go-config-setup/pkg/config" {
+		t.Errorf("expected the $id derived from the package, got %v", schema["$id"])
+	}
+	if schema["$schema"] != "https://json-schema.org/draft/2020-12/schema" {
+		t.Errorf("expected draft 2020-12, got %v", schema["$schema"])
+	}
+}
+
+func TestGenerateSchemaFeatureFlags(t *testing.T) {
+	schema := generateSchema(t, withoutComments())
+
+	prop := schemaProperty(t, schema, "FeatureConfig", "flags")
+	if prop["type"] != "object" {
+		t.Errorf("expected flags to be an object, got %v", prop["type"])
+	}
+	additional, _ := prop["additionalProperties"].(map[string]interface{})
+	if additional["type"] != "boolean" {
+		t.Errorf("expected the values of flags to be booleans, got %v", prop["additionalProperties"])
+	}
+}
+
+func TestApplyDescriptionTag(t *testing.T) {
+	type server struct {
+		Port int    `json:"port" description:"The port to listen on."`
+		Host string `json:"host"`
+	}
+	schema := newBaseReflector().Reflect(&server{})
+	def := schema.Definitions["server"]
+	for pair := def.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		// as read from the Go comments
+		pair.Value.Description = "comment of " + pair.Key
+	}
+
+	if err := visitSchemaFields(schema, reflect.TypeOf(server{}), applyDescriptionTag); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the tag wins over the Go comment
+	if port, _ := def.Properties.Get("port"); port.Description != "The port to listen on." {
+		t.Errorf("expected the description of the tag, got %q", port.Description)
+	}
+	// the fields without the tag keep the Go comment
+	if host, _ := def.Properties.Get("host"); host.Description != "comment of host" {
+		t.Errorf("expected the description of the Go comment, got %q", host.Description)
+	}
+}
+
+func TestGenerateSchemaExamples(t *testing.T) {
+	schema := generateSchema(t, withoutComments())
+
+	prop := schemaProperty(t, schema, "HTTPServerConfig", "port")
+	// the examples are converted to the type of the field, so they are numbers, not strings
+	examples, _ := prop["examples"].([]interface{})
+	if len(examples) != 2 || examples[0] != float64(8080) || examples[1] != float64(9090) {
+		t.Errorf("expected the integer examples [8080 9090], got %#v", prop["examples"])
+	}
+}
+
+func TestApplyExampleTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		propType string
+		tag      string
+		expected []interface{}
+		wantErr  string
+	}{
+		{name: "integers", propType: "integer", tag: "8080, 9090", expected: []interface{}{8080, 9090}},
+		{name: "numbers", propType: "number", tag: "0.1,1", expected: []interface{}{0.1, 1.0}},
+		{name: "booleans", propType: "boolean", tag: "true", expected: []interface{}{true}},
+		{name: "strings", propType: "string", tag: "json,pretty", expected: []interface{}{"json", "pretty"}},
+		{name: "invalid integer", propType: "integer", tag: "8080,abc", wantErr: "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prop := &jsonschema.Schema{Type: tt.propType}
+			field := reflect.StructField{Name: "Field", Tag: reflect.StructTag(`example:"` + tt.tag + `"`)}
+
+			err := applyExampleTag(prop, field)
+			if tt.wantErr != "" {
+				assertErrorContains(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(prop.Examples, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, prop.Examples)
+			}
+		})
+	}
+}
+
+func TestGenerateSchemaDeprecated(t *testing.T) {
+	schema := generateSchema(t, withoutComments())
+
+	if prop := schemaProperty(t, schema, "FeatureConfig", "enabled_features"); prop["deprecated"] != true {
+		t.Errorf("expected enabled_features to be deprecated, got %v", prop["deprecated"])
+	}
+	if prop := schemaProperty(t, schema, "FeatureConfig", "flags"); prop["deprecated"] != nil {
+		t.Errorf("expected flags not to be deprecated, got %v", prop["deprecated"])
+	}
+}
+
+func TestSchemaHandler(t *testing.T) {
+	// the test runs in the package directory, so the schema is served without the descriptions
+	handler := SchemaHandler()
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config/schema", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		if contentType := rec.Header().Get("Content-Type"); contentType != "application/schema+json" {
+			t.Errorf("expected the content type application/schema+json, got %q", contentType)
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &schema); err != nil {
+			t.Fatalf("expected the body to be JSON: %v", err)
+		}
+		schemaProperty(t, schema, "Config", "http_server")
+	}
+}
+
+func TestGenerateSchemaEnumDescriptions(t *testing.T) {
+	schema := generateSchema(t, withoutComments())
+
+	prop := schemaProperty(t, schema, "LoggingConfig", "log_format")
+	enum, _ := prop["enum"].([]interface{})
+	descriptions, _ := prop["enumDescriptions"].([]interface{})
+	// aligned with the enum values
+	if len(enum) != 2 || enum[0] != "json" || enum[1] != "pretty" {
+		t.Fatalf("unexpected enum values %v", prop["enum"])
+	}
+	if len(descriptions) != 2 || descriptions[0] != "structured JSON logs" || descriptions[1] != "human readable logs" {
+		t.Errorf("expected the descriptions in the order of the enum values, got %v", prop["enumDescriptions"])
+	}
+}
+
+func TestApplyEnumDescTagMismatch(t *testing.T) {
+	prop := &jsonschema.Schema{Enum: []interface{}{"json", "pretty"}}
+	field := reflect.StructField{Name: "LogFormat", Tag: `enumdesc:"structured JSON logs"`}
+	assertErrorContains(t, applyEnumDescTag(prop, field), "got 1 enum descriptions for 2 enum values")
+}
+
+func TestGenerateSchemaFormats(t *testing.T) {
+	schema := generateSchema(t, withoutComments())
+
+	tests := []struct {
+		def, name, format string
+	}{
+		{def: "HTTPServerConfig", name: "public_url", format: "uri"},
+		{def: "OIDCConfig", name: "issuer_url", format: "uri"},
+		{def: "TracingConfig", name: "endpoint", format: "uri"},
+	}
+	for _, tt := range tests {
+		if prop := schemaProperty(t, schema, tt.def, tt.name); prop["format"] != tt.format {
+			t.Errorf("expected the format %q for %s.%s, got %v", tt.format, tt.def, tt.name, prop["format"])
+		}
+	}
+
+	// bind_address accepts the hostnames as well, so it has no IP address format
+	if prop := schemaProperty(t, schema, "HTTPServerConfig", "bind_address"); prop["format"] != nil {
+		t.Errorf("expected no format for bind_address, got %v", prop["format"])
+	}
+}
+
+func TestGenerateSchemaInlineRefs(t *testing.T) {
+	schemaJSON, err := GenerateSchema(withoutComments(), WithInlineRefs())
+	if err != nil {
+		t.Fatalf("failed to generate the schema: %v", err)
+	}
+	if strings.Contains(string(schemaJSON), "$ref") || strings.Contains(string(schemaJSON), "$defs") {
+		t.Error("expected no references and definitions in the schema")
+	}
+
+	// the inlined schema still validates the reference config
+	reference, err := os.ReadFile("../default-config.gen.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := util.ValidateAgainstSchema(reference, schemaJSON); err != nil {
+		t.Errorf("expected the reference config to match the inlined schema: %v", err)
+	}
+}
+
+func TestGenerateSchemaRequired(t *testing.T) {
+	schema := generateSchema(t, withoutComments())
+
+	tests := []struct {
+		def, name string
+	}{
+		{def: "HTTPServerConfig", name: "port"},
+		{def: "LoggingConfig", name: "log_format"},
+	}
+	for _, tt := range tests {
+		required, _ := schemaDef(t, schema, tt.def)["required"].([]interface{})
+		if !slices.Contains(required, interface{}(tt.name)) {
+			t.Errorf("expected %s in the required list of %s, got %v", tt.name, tt.def, required)
+		}
+	}
+}
+
+// requiredRulesConfig has a field for each of the required rules that are added to the schema.
+type requiredRulesConfig struct {
+	Name     string `json:"name" validate:"required"`
+	Enabled  bool   `json:"enabled,omitempty"`
+	CertFile string `json:"cert_file,omitempty" validate:"required_if=Enabled true"`
+	Mode     string `json:"mode,omitempty"`
+	Host     string `json:"host,omitempty" validate:"required_unless=Mode local"`
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty" validate:"required_with=User"`
+}
+
+func TestApplyRequiredRules(t *testing.T) {
+	schema := newBaseReflector().Reflect(&requiredRulesConfig{})
+	if err := applyRequiredRules(schema, reflect.TypeOf(requiredRulesConfig{})); err != nil {
+		t.Fatalf("failed to apply the required rules: %v", err)
+	}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr bool
+	}{
+		{name: "complete", doc: `{"name": "a", "host": "h"}`},
+		{name: "required missing", doc: `{"host": "h"}`, wantErr: true},
+		{name: "required_if holds", doc: `{"name": "a", "host": "h", "enabled": true}`, wantErr: true},
+		{name: "required_if satisfied", doc: `{"name": "a", "host": "h", "enabled": true, "cert_file": "c"}`},
+		{name: "required_unless exempted", doc: `{"name": "a", "mode": "local"}`},
+		{name: "required_unless holds", doc: `{"name": "a", "mode": "remote"}`, wantErr: true},
+		{name: "required_with holds", doc: `{"name": "a", "host": "h", "user": "u"}`, wantErr: true},
+		{name: "required_with satisfied", doc: `{"name": "a", "host": "h", "user": "u", "password": "p"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := util.ValidateAgainstSchema([]byte(tt.doc), schemaJSON)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected an error: %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestApplyRequiredRulesUnknownField(t *testing.T) {
+	type config struct {
+		Password string `json:"password,omitempty" validate:"required_with=User"`
+	}
+	schema := newBaseReflector().Reflect(&config{})
+	err := applyRequiredRules(schema, reflect.TypeOf(config{}))
+	assertErrorContains(t, err, `unknown field "User"`)
+}
+
+func TestGenerateMarkdown(t *testing.T) {
+	chdirModuleRoot(t)
+	out, err := util.GenerateMarkdown(&Config{})
+	if err != nil {
+		t.Fatalf("failed to generate the Markdown: %v", err)
+	}
+
+	expected := "| `http_server.port` | `int` | `8080` | Port is the port number for the HTTP server | `required,min=1,max=65535` |\n"
+	if !strings.Contains(string(out), expected) {
+		t.Errorf("expected the row %q, got:\n%s", expected, out)
+	}
+}
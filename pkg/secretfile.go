@@ -0,0 +1,81 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// secretFileSuffix is the suffix of the keys that point to the files to read the values of the string fields from,
+// such as `password_file: /run/secrets/db` for `password`.
+const secretFileSuffix = "_file"
+
+// readSecretFiles replaces the `<name>_file` keys in the settings with the `<name>` keys, whose values are read from
+// the files the `_file` keys point to. This is useful for the secrets mounted as files, such as the Kubernetes
+// secrets, which shouldn't be written into the config files. The trailing newlines of the files are trimmed.
+//
+// Only the string fields of the given struct type can be read from files, recursively. The other `_file` keys, such
+// as `cert_file` of TLSConfig, are left as they are. An error is returned if both the field and its `_file` key are
+// set, or if the file can't be read.
+func readSecretFiles(settings map[string]interface{}, t reflect.Type, prefix string) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" {
+			fields[name] = t.Field(i)
+		}
+	}
+
+	for key, value := range settings {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		if field, ok := fields[key]; ok {
+			if section, ok := value.(map[string]interface{}); ok {
+				if err := readSecretFiles(section, field.Type, path); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		name, ok := strings.CutSuffix(key, secretFileSuffix)
+		if !ok {
+			continue
+		}
+		if field, ok := fields[name]; !ok || field.Type.Kind() != reflect.String {
+			continue
+		}
+
+		file, err := cast.ToStringE(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", path, err)
+		}
+		delete(settings, key)
+		// an empty path, such as from an undefined environment variable, means the file is not used
+		if file == "" {
+			continue
+		}
+		if existing, ok := settings[name]; ok && existing != "" {
+			return fmt.Errorf("both %s and %s are set, only one of them can be used", strings.TrimSuffix(path, secretFileSuffix), path)
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		settings[name] = strings.TrimRight(string(content), "\r\n")
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigSecretFiles(t *testing.T) {
+	secret := writeFile(t, "db-password", "s3cret\n")
+
+	// strict, so that a `_file` key left in the settings would be reported as unknown
+	cfg := mustLoadYAML(t, "database:\n  password_file: "+secret+"\n", WithStrict(true))
+	if cfg.DatabaseConfig.Password != "s3cret" {
+		t.Errorf("expected the password from the file without the trailing newline, got %q", cfg.DatabaseConfig.Password)
+	}
+}
+
+func TestLoadConfigSecretFilesErrors(t *testing.T) {
+	secret := writeFile(t, "db-password", "s3cret")
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "both set",
+			content: "database:\n  password: other\n  password_file: " + secret + "\n",
+			wantErr: "both database.password and database.password_file are set",
+		},
+		{
+			name:    "missing file",
+			content: "database:\n  password_file: " + filepath.Join(t.TempDir(), "missing") + "\n",
+			wantErr: "failed to read database.password_file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfigFromBytes([]byte(tt.content), "yaml")
+			assertErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLoadConfigSecretFilesOnlyStringFields(t *testing.T) {
+	// the `_file` keys of the fields that are not strings are not read
+	_, err := LoadConfigFromBytes([]byte("http_server:\n  port_file: /nonexistent\n"), "yaml", WithStrict(true))
+	assertErrorContains(t, err, "unknown keys in the config: http_server.port_file")
+
+	// an empty path means the file is not used
+	cfg := mustLoadYAML(t, "database:\n  password_file: \"\"\n")
+	if cfg.DatabaseConfig.Password != "" {
+		t.Errorf("expected no password, got %q", cfg.DatabaseConfig.Password)
+	}
+}
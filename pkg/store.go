@@ -0,0 +1,30 @@
+package pkg
+
+import "sync/atomic"
+
+// Store holds the current configuration and allows swapping it atomically.
+// Readers, such as request handlers, can get the latest configuration lock-free using Load.
+// A Load never returns a partially-updated configuration, as the whole *Config is swapped at once.
+// Callers must not modify the Config instances passed to Store or returned by Load.
+//
+// The zero value is an empty store, ready to use.
+type Store struct {
+	cfg atomic.Pointer[Config]
+}
+
+// NewStore creates a Store holding the given configuration.
+func NewStore(cfg *Config) *Store {
+	s := &Store{}
+	s.Store(cfg)
+	return s
+}
+
+// Load returns the current configuration. Returns nil if no configuration is stored yet.
+func (s *Store) Load() *Config {
+	return s.cfg.Load()
+}
+
+// Store replaces the current configuration.
+func (s *Store) Store(cfg *Config) {
+	s.cfg.Store(cfg)
+}
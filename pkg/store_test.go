@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	var store Store
+	if store.Load() != nil {
+		t.Fatal("expected the zero store to be empty")
+	}
+
+	cfg := &Config{}
+	store.Store(cfg)
+	if store.Load() != cfg {
+		t.Error("expected the stored config to be loaded")
+	}
+
+	if NewStore(cfg).Load() != cfg {
+		t.Error("expected NewStore to hold the given config")
+	}
+}
+
+// TestStoreConcurrent is meant to be run with the race detector, `go test -race`.
+func TestStoreConcurrent(t *testing.T) {
+	// every config is consistent: the port is the log level plus 8000
+	newConfig := func(i int) *Config {
+		cfg := &Config{}
+		cfg.HTTPServerConfig.Port = 8000 + i
+		cfg.LoggingConfig.LogLevel = Ptr(int8(i))
+		return cfg
+	}
+	store := NewStore(newConfig(0))
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				store.Store(newConfig(i % 100))
+			}
+		}()
+	}
+
+	var readers sync.WaitGroup
+	errs := make(chan string, 8)
+	for r := 0; r < 8; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				cfg := store.Load()
+				if cfg.HTTPServerConfig.Port != 8000+int(*cfg.LoggingConfig.LogLevel) {
+					errs <- "loaded a partially-updated config"
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	readers.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
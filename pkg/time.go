@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/invopop/jsonschema"
+	"github.com/mitchellh/mapstructure"
+)
+
+// Time is a point in time that is represented as an RFC 3339 timestamp, such as `2030-01-02T15:04:05Z` or
+// `2030-01-02T17:04:05+02:00`, in the config files, in the JSON schema and in the output.
+//
+// time.Time itself can't be used in the config types, as the defaulter walks into its unexported fields.
+// Time is the number of nanoseconds since the Unix epoch instead, so the times between the years 1678 and 2262 are
+// supported, and the time zone offset is not kept; the time is marshalled in UTC. The zero value is an unset time.
+//
+// Use the `future` validation rule for requiring a time in the future, such as `validate:"omitempty,future"`.
+type Time int64
+
+// NewTime returns the given time.Time as a Time. The zero time.Time is the zero Time.
+func NewTime(t time.Time) (Time, error) {
+	if t.IsZero() {
+		return 0, nil
+	}
+	nanos := t.UnixNano()
+	// UnixNano is undefined for the times that don't fit into an int64
+	if !time.Unix(0, nanos).Equal(t) {
+		return 0, fmt.Errorf("time %s is out of range, must be between the years 1678 and 2262", t.Format(time.RFC3339))
+	}
+	return Time(nanos), nil
+}
+
+// Time returns the value as a time.Time in UTC. The zero Time is the zero time.Time.
+func (t Time) Time() time.Time {
+	if t.IsZero() {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(t)).UTC()
+}
+
+// IsZero returns true if the time is not set.
+func (t Time) IsZero() bool {
+	return t == 0
+}
+
+func (t Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Time().Format(time.RFC3339Nano)
+}
+
+// MarshalText marshals the time as an RFC 3339 timestamp. This is used for JSON and YAML as well.
+func (t Time) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText parses an RFC 3339 timestamp. This is used by Viper as well, see Unmarshal.
+// An empty string is the zero Time.
+func (t *Time) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*t = 0
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, string(text))
+	if err != nil {
+		return fmt.Errorf("invalid time %q, must be an RFC 3339 timestamp such as 2030-01-02T15:04:05Z", text)
+	}
+	converted, err := NewTime(parsed)
+	if err != nil {
+		return err
+	}
+	*t = converted
+	return nil
+}
+
+// timeSchema is the JSON schema of Time fields. See durationSchema for why it is not a `JSONSchema()` method.
+func timeSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:   "string",
+		Format: "date-time",
+	}
+}
+
+var timeType = reflect.TypeOf(Time(0))
+
+// timeHookFunc decodes the time.Time values into the Time fields. The YAML parser reads the unquoted timestamps,
+// such as `valid_until: 2030-01-02T15:04:05Z`, as time.Time values instead of strings.
+func timeHookFunc() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if to != timeType || from != reflect.TypeOf(time.Time{}) {
+			return data, nil
+		}
+		return NewTime(data.(time.Time))
+	}
+}
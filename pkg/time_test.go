@@ -0,0 +1,146 @@
+package pkg
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTimeUnmarshalText(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected time.Time
+		valid    bool
+	}{
+		{text: "2030-01-02T15:04:05Z", expected: time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC), valid: true},
+		// the offset is not kept, the time is in UTC
+		{text: "2030-01-02T17:04:05+02:00", expected: time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC), valid: true},
+		{text: "2030-01-02T15:04:05.5Z", expected: time.Date(2030, 1, 2, 15, 4, 5, 5e8, time.UTC), valid: true},
+		{text: "", expected: time.Time{}, valid: true},
+		{text: "2030-01-02", valid: false},
+		{text: "2030-01-02 15:04:05", valid: false},
+		{text: "tomorrow", valid: false},
+		{text: "3000-01-02T15:04:05Z", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			var value Time
+			err := value.UnmarshalText([]byte(tt.text))
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("expected an error, got %s", value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !value.Time().Equal(tt.expected) {
+				t.Errorf("expected %s, got %s", tt.expected, value.Time())
+			}
+		})
+	}
+}
+
+func TestTimeMarshalText(t *testing.T) {
+	value, err := NewTime(time.Date(2030, 1, 2, 17, 4, 5, 0, time.FixedZone("", 2*60*60)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := value.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != "2030-01-02T15:04:05Z" {
+		t.Errorf("expected 2030-01-02T15:04:05Z, got %s", text)
+	}
+
+	var zero Time
+	if text, _ := zero.MarshalText(); len(text) != 0 {
+		t.Errorf("expected the zero time to be empty, got %s", text)
+	}
+	if !zero.Time().IsZero() {
+		t.Errorf("expected the zero time.Time, got %s", zero.Time())
+	}
+}
+
+func TestLoadConfigTime(t *testing.T) {
+	expected := time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+	}{
+		{name: "unquoted", content: "auth:\n  mode: apikey\n  api_key: key\n  api_key_valid_until: 2030-01-02T15:04:05Z\n"},
+		{name: "quoted", content: "auth:\n  mode: apikey\n  api_key: key\n  api_key_valid_until: \"2030-01-02T15:04:05Z\"\n"},
+		{
+			name:    "environment variable",
+			content: "auth:\n  mode: apikey\n  api_key: key\n",
+			env:     map[string]string{"APP_AUTH_API_KEY_VALID_UNTIL": "2030-01-02T15:04:05Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			cfg := mustLoadYAML(t, tt.content)
+			if validUntil := cfg.AuthConfig.APIKeyValidUntil.Time(); !validUntil.Equal(expected) {
+				t.Errorf("expected %s, got %s", expected, validUntil)
+			}
+		})
+	}
+}
+
+func TestLoadConfigInvalidTime(t *testing.T) {
+	_, err := LoadConfigFromBytes([]byte("auth:\n  mode: apikey\n  api_key: key\n  api_key_valid_until: tomorrow\n"), "yaml")
+	assertErrorContains(t, err, "must be an RFC 3339 timestamp")
+}
+
+func TestValidateFuture(t *testing.T) {
+	future, err := NewTime(time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	past, err := NewTime(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		validUntil Time
+		expected   []string
+	}{
+		{name: "unset", validUntil: 0},
+		{name: "future", validUntil: future},
+		{name: "past", validUntil: past, expected: []string{"auth.api_key_valid_until"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.AuthConfig = AuthConfig{Mode: "apikey", APIKey: "key", APIKeyValidUntil: tt.validUntil}
+
+			err := Validate(cfg)
+			paths := validationPaths(err)
+			if !slices.Equal(paths, tt.expected) {
+				t.Errorf("expected the failing fields %v, got %v", tt.expected, paths)
+			}
+			if tt.expected != nil {
+				assertErrorContains(t, err, "must be in the future")
+			}
+		})
+	}
+}
+
+func TestTimeSchema(t *testing.T) {
+	schema := generateSchema(t, withoutComments())
+
+	prop := schemaProperty(t, schema, "AuthConfig", "api_key_valid_until")
+	if prop["type"] != "string" || prop["format"] != "date-time" {
+		t.Errorf("expected a string with the date-time format, got %v", prop)
+	}
+}
@@ -0,0 +1,35 @@
+package pkg
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// tlsVersions maps the TLS version strings used in the configuration to the `crypto/tls` constants.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion converts a TLS version string such as "1.3" to the matching `crypto/tls` constant,
+// such as tls.VersionTLS13. The result can be used as `tls.Config.MinVersion`.
+func ParseTLSVersion(s string) (uint16, error) {
+	version, ok := tlsVersions[s]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version %q, must be one of 1.0, 1.1, 1.2, 1.3", s)
+	}
+	return version, nil
+}
+
+// TLSVersionString is the inverse of ParseTLSVersion. It converts a `crypto/tls` version constant to a string
+// such as "1.3", which is useful for logging. Unknown versions are formatted as hex.
+func TLSVersionString(version uint16) string {
+	for s, v := range tlsVersions {
+		if v == version {
+			return s
+		}
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
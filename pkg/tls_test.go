@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected uint16
+		wantErr  bool
+	}{
+		{input: "1.0", expected: tls.VersionTLS10},
+		{input: "1.1", expected: tls.VersionTLS11},
+		{input: "1.2", expected: tls.VersionTLS12},
+		{input: "1.3", expected: tls.VersionTLS13},
+		{input: "1.4", wantErr: true},
+		{input: "TLS1.3", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			version, err := ParseTLSVersion(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got version 0x%04x", version)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if version != tt.expected {
+				t.Errorf("expected 0x%04x, got 0x%04x", tt.expected, version)
+			}
+		})
+	}
+}
+
+func TestTLSVersionString(t *testing.T) {
+	tests := []struct {
+		version  uint16
+		expected string
+	}{
+		{version: tls.VersionTLS10, expected: "1.0"},
+		{version: tls.VersionTLS11, expected: "1.1"},
+		{version: tls.VersionTLS12, expected: "1.2"},
+		{version: tls.VersionTLS13, expected: "1.3"},
+		{version: 0x0999, expected: "0x0999"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if s := TLSVersionString(tt.version); s != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, s)
+			}
+		})
+	}
+}
+
+func TestTLSVersionRoundTrip(t *testing.T) {
+	for s := range tlsVersions {
+		version, err := ParseTLSVersion(s)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", s, err)
+		}
+		if TLSVersionString(version) != s {
+			t.Errorf("expected %q to round-trip, got %q", s, TLSVersionString(version))
+		}
+	}
+}
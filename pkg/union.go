@@ -0,0 +1,177 @@
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/invopop/jsonschema"
+)
+
+// unionDiscriminator is the value of the `union` tag of the discriminator field of a tagged union.
+const unionDiscriminator = "discriminator"
+
+// A tagged union is a struct with a discriminator field, which selects exactly one of its variants, such as
+// StorageConfig:
+//
+//	type StorageConfig struct {
+//		Type  string              `json:"type" union:"discriminator"`
+//		Local *LocalStorageConfig `json:"local,omitempty" union:"local"`
+//		S3    *S3StorageConfig    `json:"s3,omitempty" union:"s3"`
+//	}
+//
+// The variants are pointers to structs, tagged with the value of the discriminator that selects them.
+// Only the selected variant can be set, see TaggedUnion. When applying the defaults, only the selected variant is
+// created, see resetUnselectedVariants. The schema has a `oneOf` with a subschema per variant, see applyTaggedUnions.
+
+// unionVariant is a variant field of a tagged union.
+type unionVariant struct {
+	field reflect.StructField
+	value string
+}
+
+// unionFields returns the discriminator and the variant fields of the given struct type, in the order of the fields.
+// ok is false if the struct is not a tagged union.
+func unionFields(t reflect.Type) (discriminator reflect.StructField, variants []unionVariant, ok bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, tagged := field.Tag.Lookup("union")
+		switch {
+		case !tagged:
+		case tag == unionDiscriminator:
+			discriminator, ok = field, true
+		default:
+			variants = append(variants, unionVariant{field: field, value: tag})
+		}
+	}
+	return discriminator, variants, ok && len(variants) > 0
+}
+
+// TaggedUnion is a struct-level validation for the tagged unions, which checks that the variant selected by the
+// discriminator is set and the other variants are not. The selected variant is reported with the `required_if`
+// rule and the other ones with the `excluded_unless` rule, such as `excluded_unless=Type s3`.
+//
+// As only one struct-level validation can be registered per type, call it from the struct-level validation of the
+// type if it has other checks.
+func TaggedUnion(sl validator.StructLevel) {
+	current := sl.Current()
+	discriminator, variants, ok := unionFields(current.Type())
+	if !ok {
+		panic(fmt.Sprintf("%s is not a tagged union", current.Type().Name()))
+	}
+
+	selected := current.FieldByIndex(discriminator.Index).String()
+	for _, variant := range variants {
+		value := current.FieldByIndex(variant.field.Index)
+		condition := discriminator.Name + " " + variant.value
+		switch {
+		case variant.value == selected && value.IsNil():
+			sl.ReportError(value.Interface(), jsonName(variant.field), variant.field.Name, "required_if", condition)
+		case variant.value != selected && !value.IsNil():
+			sl.ReportError(value.Interface(), jsonName(variant.field), variant.field.Name, "excluded_unless", condition)
+		}
+	}
+}
+
+// unsetVariant is a variant field of a tagged union that is not set.
+type unsetVariant struct {
+	union   reflect.Value
+	variant unionVariant
+}
+
+// unsetVariants returns the variant fields of the tagged unions in the given struct value that are not set,
+// recursively. The defaulter creates all the nil struct pointers, so these are reset afterward, see
+// resetUnselectedVariants.
+func unsetVariants(v reflect.Value) []unsetVariant {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var unset []unsetVariant
+	if _, variants, ok := unionFields(v.Type()); ok {
+		for _, variant := range variants {
+			if v.FieldByIndex(variant.field.Index).IsNil() {
+				unset = append(unset, unsetVariant{union: v, variant: variant})
+			}
+		}
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).IsExported() {
+			unset = append(unset, unsetVariants(v.Field(i))...)
+		}
+	}
+	return unset
+}
+
+// resetUnselectedVariants resets the given variant fields to nil after applying the defaults, unless they are
+// selected by their discriminators. So, the selected variant is created with its defaults if it is not set, and the
+// other variants stay unset.
+func resetUnselectedVariants(unset []unsetVariant) {
+	for _, u := range unset {
+		discriminator, _, _ := unionFields(u.union.Type())
+		if u.union.FieldByIndex(discriminator.Index).String() != u.variant.value {
+			value := u.union.FieldByIndex(u.variant.field.Index)
+			value.Set(reflect.Zero(value.Type()))
+		}
+	}
+}
+
+// applyTaggedUnions adds a `oneOf` to the definitions of the tagged unions in the given struct type and its nested
+// struct types, recursively. Each subschema of the `oneOf` matches a variant: the discriminator has the value of the
+// variant, the variant is required and the other variants are not allowed.
+//
+// The discriminator is only required by the subschemas of the variants that are not selected by its default, so
+// that the default applies when it is not set.
+func applyTaggedUnions(schema *jsonschema.Schema, t reflect.Type) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	def, ok := schema.Definitions[t.Name()]
+	if !ok || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if err := applyTaggedUnions(schema, t.Field(i).Type); err != nil {
+			return err
+		}
+	}
+
+	discriminator, variants, ok := unionFields(t)
+	if !ok {
+		return nil
+	}
+	discriminatorName := jsonName(discriminator)
+	discriminatorProp, ok := def.Properties.Get(discriminatorName)
+	if !ok {
+		return fmt.Errorf("%s: discriminator %q is not in the schema", t.Name(), discriminatorName)
+	}
+
+	// the same union type can be nested in multiple sections, so its definition can be visited more than once
+	def.OneOf = nil
+	for _, variant := range variants {
+		properties := jsonschema.NewProperties()
+		properties.Set(discriminatorName, &jsonschema.Schema{Const: variant.value})
+		for _, other := range variants {
+			if other.value != variant.value {
+				properties.Set(jsonName(other.field), jsonschema.FalseSchema)
+			}
+		}
+		required := []string{jsonName(variant.field)}
+		// an absent property matches any `properties`, so the default variant matches without the discriminator
+		if discriminatorProp.Default != variant.value {
+			required = append([]string{discriminatorName}, required...)
+		}
+		def.OneOf = append(def.OneOf, &jsonschema.Schema{
+			Properties: properties,
+			Required:   required,
+		})
+	}
+	return nil
+}
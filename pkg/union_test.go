@@ -0,0 +1,202 @@
+package pkg
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/aliok/best-go-config-setup/util"
+)
+
+func TestLoadConfigStorageBackends(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		check   func(t *testing.T, storage StorageConfig)
+	}{
+		{
+			name:    "default backend",
+			content: "",
+			check: func(t *testing.T, storage StorageConfig) {
+				if storage.Type != "local" || storage.LocalConfig == nil || storage.LocalConfig.Path != "./data" {
+					t.Errorf("expected the local backend with its defaults, got %+v", storage)
+				}
+			},
+		},
+		{
+			name:    "local",
+			content: "storage:\n  type: local\n  local:\n    path: /var/lib/app\n",
+			check: func(t *testing.T, storage StorageConfig) {
+				if storage.LocalConfig == nil || storage.LocalConfig.Path != "/var/lib/app" {
+					t.Errorf("expected the local path, got %+v", storage.LocalConfig)
+				}
+			},
+		},
+		{
+			name:    "s3",
+			content: "storage:\n  type: s3\n  s3:\n    bucket: files\n",
+			check: func(t *testing.T, storage StorageConfig) {
+				if storage.S3Config == nil || storage.S3Config.Bucket != "files" || storage.S3Config.Region != "us-east-1" {
+					t.Errorf("expected the s3 backend with the default region, got %+v", storage.S3Config)
+				}
+			},
+		},
+		{
+			name:    "gcs",
+			content: "storage:\n  type: gcs\n  gcs:\n    bucket: files\n",
+			check: func(t *testing.T, storage StorageConfig) {
+				if storage.GCSConfig == nil || storage.GCSConfig.Bucket != "files" {
+					t.Errorf("expected the gcs backend, got %+v", storage.GCSConfig)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := mustLoadYAML(t, tt.content).StorageConfig
+			tt.check(t, storage)
+
+			// the unselected backends are not created by the defaulting
+			set := 0
+			for _, variant := range []bool{storage.LocalConfig != nil, storage.S3Config != nil, storage.GCSConfig != nil} {
+				if variant {
+					set++
+				}
+			}
+			if set != 1 {
+				t.Errorf("expected only the selected backend to be set, got %+v", storage)
+			}
+		})
+	}
+}
+
+func TestValidateStorageMismatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "other backend set",
+			content:  "storage:\n  type: s3\n  s3:\n    bucket: files\n  local:\n    path: /var/lib/app\n",
+			expected: []string{"storage.local"},
+		},
+		{
+			name:     "selected backend without its required fields",
+			content:  "storage:\n  type: gcs\n",
+			expected: []string{"storage.gcs.bucket"},
+		},
+		{
+			name:     "unknown backend",
+			content:  "storage:\n  type: ftp\n",
+			expected: []string{"storage.type"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfigFromBytes([]byte(tt.content), "yaml")
+			if paths := validationPaths(err); !slices.Equal(paths, tt.expected) {
+				t.Errorf("expected the failing fields %v, got %v: %v", tt.expected, paths, err)
+			}
+		})
+	}
+
+	// both rules of a mismatch
+	cfg := defaultConfig(t)
+	cfg.StorageConfig.Type = "s3"
+	errs := validationErrorsOf(Validate(cfg))
+	var rules []string
+	for _, e := range errs {
+		rules = append(rules, e.Path+" "+e.Tag+"="+e.Param)
+	}
+	expected := []string{"storage.local excluded_unless=Type local", "storage.s3 required_if=Type s3"}
+	if !slices.Equal(rules, expected) {
+		t.Errorf("expected %v, got %v", expected, rules)
+	}
+}
+
+type unionTestVariant struct {
+	Name string `json:"name,omitempty"`
+}
+
+type unionTestConfig struct {
+	Type string            `json:"type,omitempty" jsonschema:"default=a" union:"discriminator"`
+	A    *unionTestVariant `json:"a,omitempty" union:"a"`
+	B    *unionTestVariant `json:"b,omitempty" union:"b"`
+}
+
+func TestApplyTaggedUnions(t *testing.T) {
+	schema := newBaseReflector().Reflect(&unionTestConfig{})
+	if err := applyTaggedUnions(schema, reflect.TypeOf(unionTestConfig{})); err != nil {
+		t.Fatalf("failed to apply the tagged unions: %v", err)
+	}
+	// applying twice, as for a union nested in multiple sections, doesn't duplicate the rules
+	if err := applyTaggedUnions(schema, reflect.TypeOf(unionTestConfig{})); err != nil {
+		t.Fatalf("failed to apply the tagged unions: %v", err)
+	}
+	if variants := schema.Definitions["unionTestConfig"].OneOf; len(variants) != 2 {
+		t.Errorf("expected a subschema per variant, got %d", len(variants))
+	}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr bool
+	}{
+		{name: "default variant", doc: `{"type": "a", "a": {}}`},
+		{name: "default variant without the discriminator", doc: `{"a": {}}`},
+		{name: "default variant not set", doc: `{}`, wantErr: true},
+		{name: "other variant", doc: `{"type": "b", "b": {}}`},
+		{name: "other variant without the discriminator", doc: `{"b": {}}`, wantErr: true},
+		{name: "selected variant not set", doc: `{"type": "b"}`, wantErr: true},
+		{name: "both variants", doc: `{"type": "b", "a": {}, "b": {}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := util.ValidateAgainstSchema([]byte(tt.doc), schemaJSON)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected an error: %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerateSchemaStorageUnion(t *testing.T) {
+	schema := generateSchema(t, withoutComments())
+	def := schemaDef(t, schema, "StorageConfig")
+
+	variants, _ := def["oneOf"].([]interface{})
+	if len(variants) != 3 {
+		t.Fatalf("expected a oneOf with a subschema per backend, got %v", def["oneOf"])
+	}
+	// the discriminator is only required to select the variants other than the default one
+	required := map[string][]interface{}{}
+	for _, variant := range variants {
+		variant := variant.(map[string]interface{})
+		properties := variant["properties"].(map[string]interface{})
+		value := properties["type"].(map[string]interface{})["const"].(string)
+		required[value], _ = variant["required"].([]interface{})
+
+		// the other backends are not allowed
+		for _, other := range []string{"local", "s3", "gcs"} {
+			if other != value && properties[other] != false {
+				t.Errorf("expected %s not to be allowed with %s, got %v", other, value, properties[other])
+			}
+		}
+	}
+	expected := map[string][]interface{}{
+		"local": {"local"},
+		"s3":    {"type", "s3"},
+		"gcs":   {"type", "gcs"},
+	}
+	if !reflect.DeepEqual(required, expected) {
+		t.Errorf("expected the required properties %v, got %v", expected, required)
+	}
+}
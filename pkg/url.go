@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultURLTimeout is the timeout of fetching a config from a URL, see WithHTTPClient.
+const DefaultURLTimeout = 30 * time.Second
+
+// WithHTTPClient sets the HTTP client for fetching the configs from URLs, such as one with custom TLS settings.
+// By default, a client with DefaultURLTimeout is used.
+func WithHTTPClient(client *http.Client) LoadOption {
+	return func(o *loadOptions) {
+		o.httpClient = client
+	}
+}
+
+// WithURLConfigType sets the type (yaml, json or toml) of the configs read from URLs. By default, the type is
+// detected from the extension of the URL path, such as `.yaml` in `https://config.example/app.yaml`.
+func WithURLConfigType(configType string) LoadOption {
+	return func(o *loadOptions) {
+		o.urlType = configType
+	}
+}
+
+// LoadConfigURL is like LoadConfig, but fetches the config from the given HTTP(S) URL, such as
+// `https://config.example/app.yaml`, for the centrally managed configs. The fetching stops when the context is
+// cancelled. LoadConfig accepts the URLs as well, both as the main config and as the overlays.
+//
+// The type of the config is detected from the extension of the URL path, see WithURLConfigType.
+// A response with a non-2xx status is an error, which wraps ErrReadConfig like the other read errors.
+func LoadConfigURL(ctx context.Context, rawURL string, opts ...LoadOption) (*Config, error) {
+	if !isConfigURL(rawURL) {
+		return nil, fmt.Errorf("%w: %q is not an http or https URL", ErrReadConfig, rawURL)
+	}
+	cfg, _, err := loadConfig(ctx, rawURL, opts)
+	return cfg, err
+}
+
+// isConfigURL returns true if the config path is an HTTP(S) URL instead of a file path.
+func isConfigURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// readConfigURL fetches the config at the given URL into Viper and returns the number of bytes read.
+// If merge is true, the config is merged on top of the already read config.
+func readConfigURL(ctx context.Context, v *viper.Viper, rawURL string, merge bool, options *loadOptions) (int, error) {
+	log.Printf("Using config from URL: %s", rawURL)
+
+	cfgType := options.urlType
+	if cfgType == "" {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return 0, fmt.Errorf("%w from URL %s: %w", ErrReadConfig, rawURL, err)
+		}
+		if cfgType, err = configType(u.Path); err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrReadConfig, err)
+		}
+	} else if !isSupportedConfigType(cfgType) {
+		return 0, fmt.Errorf("%w: unsupported config type %q for URL, must be one of yaml, json, toml", ErrReadConfig, cfgType)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w from URL %s: %w", ErrReadConfig, rawURL, err)
+	}
+	client := options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: DefaultURLTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w from URL %s: %w", ErrReadConfig, rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	// an error page, such as a 404, must not be parsed as a config
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return 0, fmt.Errorf("%w from URL %s: unexpected status %s", ErrReadConfig, rawURL, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("%w from URL %s: %w", ErrReadConfig, rawURL, err)
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		log.Printf("Config from URL %s is empty, skipping it", rawURL)
+		return len(data), nil
+	}
+
+	v.SetConfigType(cfgType)
+	if merge {
+		err = v.MergeConfig(bytes.NewReader(data))
+	} else {
+		err = v.ReadConfig(bytes.NewReader(data))
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w from URL %s: %w", ErrReadConfig, rawURL, err)
+	}
+	log.Printf("Read config from URL: %s", rawURL)
+	return len(data), nil
+}
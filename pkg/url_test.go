@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newConfigServer starts a test server serving the given configs by their paths.
+func newConfigServer(t *testing.T, configs map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := configs[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLoadConfigURL(t *testing.T) {
+	server := newConfigServer(t, map[string]string{
+		"/app.yaml":     "http_server:\n  port: 9090\n",
+		"/app.json":     `{"http_server": {"port": 9191}}`,
+		"/config":       "http_server:\n  port: 9292\n",
+		"/overlay.yaml": "logging:\n  log_format: pretty\n",
+	})
+
+	tests := []struct {
+		name string
+		path string
+		opts []LoadOption
+		port int
+	}{
+		{name: "yaml", path: "/app.yaml", port: 9090},
+		{name: "json", path: "/app.json", port: 9191},
+		{name: "type from the option", path: "/config", opts: []LoadOption{WithURLConfigType("yaml")}, port: 9292},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfigURL(context.Background(), server.URL+tt.path, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to load the config: %v", err)
+			}
+			if cfg.HTTPServerConfig.Port != tt.port {
+				t.Errorf("expected port %d, got %d", tt.port, cfg.HTTPServerConfig.Port)
+			}
+		})
+	}
+
+	// LoadConfig accepts the URLs as well, as overlays too
+	cfg, err := LoadConfig(server.URL+"/app.yaml", WithOverlays(server.URL+"/overlay.yaml"))
+	if err != nil {
+		t.Fatalf("failed to load the config: %v", err)
+	}
+	if cfg.HTTPServerConfig.Port != 9090 || cfg.LoggingConfig.LogFormat != "pretty" {
+		t.Errorf("expected the port from the main config and the log format from the overlay, got %d and %q",
+			cfg.HTTPServerConfig.Port, cfg.LoggingConfig.LogFormat)
+	}
+}
+
+func TestLoadConfigURLErrors(t *testing.T) {
+	server := newConfigServer(t, map[string]string{"/config": "http_server:\n  port: 9090\n"})
+
+	tests := []struct {
+		name    string
+		url     string
+		opts    []LoadOption
+		wantErr string
+	}{
+		{name: "not a URL", url: "/etc/app.yaml", wantErr: `"/etc/app.yaml" is not an http or https URL`},
+		{name: "not found", url: server.URL + "/missing.yaml", wantErr: "unexpected status 404 Not Found"},
+		{name: "no extension", url: server.URL + "/config", wantErr: `unsupported config file extension ""`},
+		{name: "unsupported type", url: server.URL + "/config", opts: []LoadOption{WithURLConfigType("ini")}, wantErr: `unsupported config type "ini" for URL`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfigURL(context.Background(), tt.url, tt.opts...)
+			assertErrorContains(t, err, tt.wantErr)
+			if !errors.Is(err, ErrReadConfig) {
+				t.Errorf("expected ErrReadConfig, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigURLTimeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(slow.Close)
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	_, err := LoadConfigURL(context.Background(), slow.URL+"/app.yaml", WithHTTPClient(client))
+	if !errors.Is(err, ErrReadConfig) {
+		t.Errorf("expected ErrReadConfig for the timeout, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := LoadConfigURL(ctx, slow.URL+"/app.yaml"); err == nil {
+		t.Error("expected an error for the cancelled context")
+	}
+}
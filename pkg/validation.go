@@ -0,0 +1,474 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+)
+
+// ValidationError is the validation failure of a single configuration field.
+type ValidationError struct {
+	// Path is the JSON path of the field, such as `http_server.port`.
+	Path string
+
+	// Tag is the validation rule that failed, such as `min`.
+	Tag string
+
+	// Param is the parameter of the validation rule, such as `1` for `min=1`. Empty if the rule has no parameter.
+	Param string
+
+	// Value is the value of the field that failed the validation.
+	Value interface{}
+
+	// Message is the human-friendly message of the failure, such as `port must be 1 or greater`, translated by the
+	// translator of the validation, see WithTranslator. Empty if there is no translation for the rule.
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s: %s", e.Path, e.Message)
+	}
+	rule := e.Tag
+	if e.Param != "" {
+		rule += "=" + e.Param
+	}
+	return fmt.Sprintf("%s: failed on the '%s' rule, value: '%v'", e.Path, rule, e.Value)
+}
+
+// newValidator creates a validator with the custom validations for the configuration registered.
+func newValidator() *validator.Validate {
+	validate := validator.New()
+
+	// use the names in the `json` tags in the errors, so that the errors match the config file
+	validate.RegisterTagNameFunc(jsonName)
+
+	// error is only returned for invalid tag names, which can't happen here
+	_ = validate.RegisterValidation("duration_gte", validateDurationGte)
+	_ = validate.RegisterValidation("bytesize_gte", validateByteSizeGte)
+	// `ziplevel` is the name of the log level rule, and `zaplevel` is an alias of it, named after the logging library
+	_ = validate.RegisterValidation("ziplevel", validateZapLevel)
+	_ = validate.RegisterValidation("zaplevel", validateZapLevel)
+	_ = validate.RegisterValidation("bind_address", validateBindAddress)
+	_ = validate.RegisterValidation("future", validateFuture)
+
+	validate.RegisterStructValidation(validateConfig, Config{})
+	validate.RegisterStructValidation(validateTLSConfig, TLSConfig{})
+	validate.RegisterStructValidation(validateCORSConfig, CORSConfig{})
+	validate.RegisterStructValidation(validateDatabaseConfig, DatabaseConfig{})
+	validate.RegisterStructValidation(validateAuthConfig, AuthConfig{})
+	validate.RegisterStructValidation(validateTracingConfig, TracingConfig{})
+	validate.RegisterStructValidation(validateHealthConfig, HealthConfig{})
+	validate.RegisterStructValidation(validateProfilingConfig, ProfilingConfig{})
+	validate.RegisterStructValidation(validateRetryConfig, RetryConfig{})
+	validate.RegisterStructValidation(TaggedUnion, StorageConfig{})
+	validate.RegisterStructValidation(validateFeatureConfig, FeatureConfig{})
+	return validate
+}
+
+// newEnglishTranslator creates the English translator for the validation errors and registers the English
+// translations of the built-in and the custom validations on the validator.
+func newEnglishTranslator(validate *validator.Validate) (ut.Translator, error) {
+	enLocale := en.New()
+	trans, _ := ut.New(enLocale, enLocale).GetTranslator("en")
+
+	if err := entranslations.RegisterDefaultTranslations(validate, trans); err != nil {
+		return nil, err
+	}
+
+	// translations of the custom validations, where {0} is the field name and {1} is the parameter of the rule
+	customTranslations := map[string]string{
+		"duration_gte":                "{0} must be {1} or longer",
+		"bytesize_gte":                "{0} must be {1} or larger",
+		"ziplevel":                    "{0} must be a valid log level",
+		"zaplevel":                    "{0} must be a valid log level",
+		"bind_address":                "{0} must be an IP address or a hostname",
+		"future":                      "{0} must be in the future",
+		"file":                        "{0} must be an existing file",
+		"nowildcard_with_credentials": "{0} can't contain '*' when credentials are allowed",
+		"all_or_none":                 "{0} must be set together with {1}",
+		"port_conflict":               "{0} must not be the same port as {1} on the same address",
+		"known_feature":               "{0} has the unknown feature {1}",
+	}
+	for tag, text := range customTranslations {
+		if err := registerTranslation(validate, trans, tag, text); err != nil {
+			return nil, err
+		}
+	}
+	return trans, nil
+}
+
+// registerTranslation registers the translation text for the given validation tag.
+func registerTranslation(validate *validator.Validate, trans ut.Translator, tag, text string) error {
+	return validate.RegisterTranslation(tag, trans,
+		func(ut ut.Translator) error {
+			return ut.Add(tag, text, true)
+		},
+		func(ut ut.Translator, fe validator.FieldError) string {
+			// error is only returned for unknown translations, which can't happen here
+			t, _ := ut.T(tag, fe.Field(), fe.Param())
+			return t
+		},
+	)
+}
+
+// validationErrors converts the errors returned by the validator to a single error that lists every failing field.
+// Each failing field is reported as a *ValidationError, which can be extracted using errors.As.
+// The messages of the errors are translated using the given translator.
+func validationErrors(err error, trans ut.Translator) error {
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	errs := make([]error, 0, len(fieldErrors))
+	for _, fieldError := range fieldErrors {
+		// Translate falls back to the raw error of the validator when there is no translation for the rule,
+		// in which case the rule is reported instead, see ValidationError.Error
+		message := fieldError.Translate(trans)
+		if message == fieldError.Error() {
+			message = ""
+		}
+		errs = append(errs, &ValidationError{
+			Path:    fieldPath(fieldError),
+			Tag:     fieldError.Tag(),
+			Param:   fieldError.Param(),
+			Value:   fieldError.Value(),
+			Message: message,
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// fieldPath returns the JSON path of the field in the error, such as `http_server.port`.
+// The namespace of the error starts with the name of the root struct, such as `Config.http_server.port`,
+// which is dropped.
+func fieldPath(fieldError validator.FieldError) string {
+	_, path, found := strings.Cut(fieldError.Namespace(), ".")
+	if !found {
+		return fieldError.Namespace()
+	}
+	return path
+}
+
+// validateConfig runs the validations that span multiple sections of the configuration.
+func validateConfig(sl validator.StructLevel) {
+	cfg := sl.Current().Interface().(Config)
+
+	// no two listeners can bind the same address and port.
+	// each conflicting listener is reported once, against the first listener it conflicts with
+	listeners := cfg.listeners()
+	for i, l := range listeners {
+		for _, other := range listeners[:i] {
+			if l.conflictsWith(other) {
+				sl.ReportError(l.port, l.path+".port", l.goPath+".Port", "port_conflict", other.path+".port")
+				break
+			}
+		}
+	}
+
+	// the separate metrics listener must not use the port of the HTTP server, even on another address, as the
+	// metrics on that port would be served by the HTTP server. the overlapping addresses are already reported above
+	if metrics, ok := cfg.metricsListener(); ok && metrics.port == listeners[0].port && !metrics.conflictsWith(listeners[0]) {
+		sl.ReportError(metrics.port, "metrics.port", "MetricsConfig.Port", "nefield", "http_server.port")
+	}
+
+	// the tracing settings are required when the `tracing` feature is enabled.
+	// if tracing itself is enabled, they are already reported by validateTracingConfig.
+	if cfg.FeatureConfig.IsEnabled("tracing") && !cfg.TracingConfig.Enabled {
+		if cfg.TracingConfig.Endpoint == "" {
+			sl.ReportError(cfg.TracingConfig.Endpoint, "tracing.endpoint", "TracingConfig.Endpoint", "required_if", "features tracing")
+		}
+		if cfg.TracingConfig.ServiceName == "" {
+			sl.ReportError(cfg.TracingConfig.ServiceName, "tracing.service_name", "TracingConfig.ServiceName", "required_if", "features tracing")
+		}
+	}
+}
+
+// listener is a network listener of the application, such as the HTTP server, for finding the port conflicts.
+type listener struct {
+	// path and goPath are the paths of the config section, such as `http_server` and `HTTPServerConfig`
+	path        string
+	goPath      string
+	bindAddress string
+	port        int
+}
+
+// listeners returns the enabled listeners in the config. The metrics are served on the HTTP server unless they have
+// a separate listener, and the health endpoints are always served on the HTTP server.
+func (c Config) listeners() []listener {
+	listeners := []listener{
+		{path: "http_server", goPath: "HTTPServerConfig", bindAddress: c.HTTPServerConfig.BindAddress, port: c.HTTPServerConfig.Port},
+	}
+	if metrics, ok := c.metricsListener(); ok {
+		listeners = append(listeners, metrics)
+	}
+	// the missing port of an enabled profiling listener is reported by validateProfilingConfig
+	if c.ProfilingConfig.Enabled && c.ProfilingConfig.Port != 0 {
+		listeners = append(listeners, listener{path: "profiling", goPath: "ProfilingConfig", bindAddress: c.ProfilingConfig.BindAddress, port: c.ProfilingConfig.Port})
+	}
+	if c.GRPCConfig.Enabled {
+		listeners = append(listeners, listener{path: "grpc", goPath: "GRPCConfig", bindAddress: c.GRPCConfig.BindAddress, port: c.GRPCConfig.Port})
+	}
+	return listeners
+}
+
+// metricsListener returns the separate listener of the metrics, if the metrics are enabled and not served on the
+// HTTP server. A disabled metrics endpoint doesn't listen, even if its port is kept in the config.
+func (c Config) metricsListener() (listener, bool) {
+	if c.MetricsConfig.Enabled == nil || !*c.MetricsConfig.Enabled || !c.MetricsConfig.SeparateListener() {
+		return listener{}, false
+	}
+	bindAddress := c.MetricsConfig.BindAddress
+	if bindAddress == "" {
+		bindAddress = c.HTTPServerConfig.BindAddress
+	}
+	return listener{path: "metrics", goPath: "MetricsConfig", bindAddress: bindAddress, port: c.MetricsConfig.Port}, true
+}
+
+// conflictsWith returns true if the listeners would bind the same address and port. The unspecified addresses, such
+// as `0.0.0.0`, bind all the interfaces, so they conflict with any address on the same port.
+func (l listener) conflictsWith(other listener) bool {
+	if l.port != other.port {
+		return false
+	}
+	return l.bindAddress == other.bindAddress || isAllInterfaces(l.bindAddress) || isAllInterfaces(other.bindAddress)
+}
+
+// validateTLSConfig checks that the certificate and key files exist when TLS is enabled.
+// The files are not checked when TLS is disabled, so that a config can keep the paths around while TLS is turned off.
+func validateTLSConfig(sl validator.StructLevel) {
+	tlsConfig := sl.Current().Interface().(TLSConfig)
+	if !tlsConfig.Enabled {
+		// the key belongs to the certificate, so a config kept around while TLS is turned off must have both.
+		// when TLS is enabled, they are already reported by the `required_if` rules
+		AllOrNone("CertFile", "KeyFile")(sl)
+		return
+	}
+
+	// emptiness is already reported by the `required_if` rules
+	if tlsConfig.CertFile != "" && !fileExists(tlsConfig.CertFile) {
+		sl.ReportError(tlsConfig.CertFile, "cert_file", "CertFile", "file", "")
+	}
+	if tlsConfig.KeyFile != "" && !fileExists(tlsConfig.KeyFile) {
+		sl.ReportError(tlsConfig.KeyFile, "key_file", "KeyFile", "file", "")
+	}
+}
+
+// AllOrNone returns a struct-level validation that checks that the given fields of the struct are either all set or
+// all empty, such as a certificate and its key. The fields are given by their Go names. Each empty field of a
+// partially set group is reported with the `all_or_none` rule, with the names of the set fields as the parameter.
+//
+// As only one struct-level validation can be registered per type, the returned function is meant to be called from
+// the struct-level validation of the type, such as validateTLSConfig.
+func AllOrNone(fields ...string) validator.StructLevelFunc {
+	return func(sl validator.StructLevel) {
+		current := sl.Current()
+		var set, unset []reflect.StructField
+		for _, name := range fields {
+			field, ok := current.Type().FieldByName(name)
+			if !ok {
+				panic(fmt.Sprintf("unknown field %q in the all_or_none group of %s", name, current.Type().Name()))
+			}
+			if current.FieldByIndex(field.Index).IsZero() {
+				unset = append(unset, field)
+			} else {
+				set = append(set, field)
+			}
+		}
+		if len(set) == 0 || len(unset) == 0 {
+			return
+		}
+
+		setNames := make([]string, len(set))
+		for i, field := range set {
+			setNames[i] = jsonName(field)
+		}
+		for _, field := range unset {
+			sl.ReportError(current.FieldByIndex(field.Index).Interface(), jsonName(field), field.Name, "all_or_none", strings.Join(setNames, " "))
+		}
+	}
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// validateDurationGte checks that the Duration field is greater than or equal to the parameter,
+// which is a Go duration such as `1s`.
+func validateDurationGte(fl validator.FieldLevel) bool {
+	minimum, err := time.ParseDuration(fl.Param())
+	if err != nil {
+		panic(fmt.Sprintf("invalid duration parameter %q for the duration_gte rule: %v", fl.Param(), err))
+	}
+	return time.Duration(fl.Field().Int()) >= minimum
+}
+
+// validateByteSizeGte checks that the ByteSize field is greater than or equal to the parameter,
+// which is a size string such as `1KB`.
+func validateByteSizeGte(fl validator.FieldLevel) bool {
+	minimum, err := ParseByteSize(fl.Param())
+	if err != nil {
+		panic(fmt.Sprintf("invalid byte size parameter %q for the bytesize_gte rule: %v", fl.Param(), err))
+	}
+	return ByteSize(fl.Field().Int()) >= minimum
+}
+
+// validateFuture checks that the Time field is after the current time.
+func validateFuture(fl validator.FieldLevel) bool {
+	return Time(fl.Field().Int()).Time().After(time.Now())
+}
+
+// validateZapLevel checks that the integer field is a valid zap log level, see ZapLevel.
+func validateZapLevel(fl validator.FieldLevel) bool {
+	_, err := toZapLevel(fl.Field().Int())
+	return err == nil
+}
+
+// validateCORSConfig rejects allowing credentials together with the wildcard origin,
+// which is invalid per the CORS spec.
+func validateCORSConfig(sl validator.StructLevel) {
+	corsConfig := sl.Current().Interface().(CORSConfig)
+	if !corsConfig.AllowCredentials {
+		return
+	}
+
+	for _, origin := range corsConfig.AllowedOrigins {
+		if origin == "*" {
+			sl.ReportError(corsConfig.AllowedOrigins, "allowed_origins", "AllowedOrigins", "nowildcard_with_credentials", "")
+			return
+		}
+	}
+}
+
+// hostnameRegex matches the hostnames as defined in RFC 1123, such as `localhost` or `api.example.com`.
+var hostnameRegex = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// numericRegex matches the dotted numbers, such as `999.1.1.1`.
+var numericRegex = regexp.MustCompile(`^[0-9.]+$`)
+
+// validateBindAddress checks that the string field is an address that can be bound to:
+// an IPv4 address such as `0.0.0.0`, an IPv6 address such as `::` or `[::1]`, or a hostname such as `localhost`.
+func validateBindAddress(fl validator.FieldLevel) bool {
+	address := fl.Field().String()
+
+	// IPv6 addresses are often written in brackets, as in URLs
+	if strings.HasPrefix(address, "[") && strings.HasSuffix(address, "]") {
+		return net.ParseIP(address[1:len(address)-1]) != nil
+	}
+	if net.ParseIP(address) != nil {
+		return true
+	}
+	// numeric-only names, such as `999.1.1.1`, are invalid IPv4 addresses rather than hostnames
+	if numericRegex.MatchString(address) {
+		return false
+	}
+	return len(address) <= 253 && hostnameRegex.MatchString(address)
+}
+
+// validateDatabaseConfig checks that the connection pool settings are consistent.
+func validateDatabaseConfig(sl validator.StructLevel) {
+	dbConfig := sl.Current().Interface().(DatabaseConfig)
+	if dbConfig.MaxIdleConns > dbConfig.MaxOpenConns {
+		sl.ReportError(dbConfig.MaxIdleConns, "max_idle_conns", "MaxIdleConns", "ltefield", "max_open_conns")
+	}
+}
+
+// validateAuthConfig checks that the settings of the selected auth mode are set,
+// and that the settings of the other modes are not set.
+func validateAuthConfig(sl validator.StructLevel) {
+	authConfig := sl.Current().Interface().(AuthConfig)
+
+	// fields of each mode, with their JSON and Go names
+	apiKeyFields := []struct {
+		value, name, fieldName string
+	}{
+		{authConfig.APIKey, "api_key", "APIKey"},
+	}
+	oidcFields := []struct {
+		value, name, fieldName string
+	}{
+		{authConfig.OIDCConfig.IssuerURL, "oidc.issuer_url", "OIDCConfig.IssuerURL"},
+		{authConfig.OIDCConfig.ClientID, "oidc.client_id", "OIDCConfig.ClientID"},
+	}
+
+	for _, f := range apiKeyFields {
+		switch {
+		case authConfig.Mode == "apikey" && f.value == "":
+			sl.ReportError(f.value, f.name, f.fieldName, "required_if", "Mode apikey")
+		case authConfig.Mode != "apikey" && f.value != "":
+			sl.ReportError(f.value, f.name, f.fieldName, "excluded_unless", "Mode apikey")
+		}
+	}
+	if authConfig.Mode != "apikey" && !authConfig.APIKeyValidUntil.IsZero() {
+		sl.ReportError(authConfig.APIKeyValidUntil, "api_key_valid_until", "APIKeyValidUntil", "excluded_unless", "Mode apikey")
+	}
+	for _, f := range oidcFields {
+		switch {
+		case authConfig.Mode == "oidc" && f.value == "":
+			sl.ReportError(f.value, f.name, f.fieldName, "required_if", "Mode oidc")
+		case authConfig.Mode != "oidc" && f.value != "":
+			sl.ReportError(f.value, f.name, f.fieldName, "excluded_unless", "Mode oidc")
+		}
+	}
+}
+
+// validateTracingConfig checks that the endpoint and the service name are set when tracing is enabled,
+// and that the endpoint is a URL. The settings are not checked when tracing is disabled.
+func validateTracingConfig(sl validator.StructLevel) {
+	tracingConfig := sl.Current().Interface().(TracingConfig)
+	if !tracingConfig.Enabled {
+		return
+	}
+
+	if tracingConfig.Endpoint == "" {
+		sl.ReportError(tracingConfig.Endpoint, "endpoint", "Endpoint", "required_if", "Enabled true")
+	} else if !isURL(tracingConfig.Endpoint) {
+		sl.ReportError(tracingConfig.Endpoint, "endpoint", "Endpoint", "url", "")
+	}
+	if tracingConfig.ServiceName == "" {
+		sl.ReportError(tracingConfig.ServiceName, "service_name", "ServiceName", "required_if", "Enabled true")
+	}
+}
+
+// validateHealthConfig checks that the liveness and the readiness endpoints are served at different paths.
+func validateHealthConfig(sl validator.StructLevel) {
+	healthConfig := sl.Current().Interface().(HealthConfig)
+	if healthConfig.ReadinessPath != "" && healthConfig.ReadinessPath == healthConfig.LivenessPath {
+		sl.ReportError(healthConfig.ReadinessPath, "readiness_path", "ReadinessPath", "nefield", "liveness_path")
+	}
+}
+
+// validateProfilingConfig checks that the port is set when profiling is enabled.
+func validateProfilingConfig(sl validator.StructLevel) {
+	profilingConfig := sl.Current().Interface().(ProfilingConfig)
+	if profilingConfig.Enabled && profilingConfig.Port == 0 {
+		sl.ReportError(profilingConfig.Port, "port", "Port", "required_if", "Enabled true")
+	}
+}
+
+// validateRetryConfig checks that the maximum backoff is not shorter than the initial one.
+func validateRetryConfig(sl validator.StructLevel) {
+	retryConfig := sl.Current().Interface().(RetryConfig)
+	if retryConfig.MaxBackoff < retryConfig.InitialBackoff {
+		sl.ReportError(retryConfig.MaxBackoff, "max_backoff", "MaxBackoff", "gtefield", "initial_backoff")
+	}
+}
+
+// isURL returns true if the string is an absolute URL with a host, such as `http://localhost:4318`.
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
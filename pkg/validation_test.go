@@ -0,0 +1,818 @@
+package pkg
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/locales/fr"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	frtranslations "github.com/go-playground/validator/v10/translations/fr"
+)
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.HTTPServerConfig.Port = 70000
+	cfg.LoggingConfig.LogFormat = "xml"
+	cfg.DatabaseConfig.Driver = "oracle"
+
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	paths := validationPaths(err)
+	slices.Sort(paths)
+	expected := []string{"database.driver", "http_server.port", "logging.log_format"}
+	if !slices.Equal(paths, expected) {
+		t.Errorf("expected the failing fields %v, got %v", expected, paths)
+	}
+
+	// the message lists every failing field, one per line
+	for _, path := range expected {
+		if !strings.Contains(err.Error(), path+": ") {
+			t.Errorf("expected %s in the message:\n%s", path, err)
+		}
+	}
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != 3 {
+		t.Errorf("expected 3 lines in the message, got %d:\n%s", len(lines), err)
+	}
+
+	// the first failing field can be extracted with errors.As
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected a *ValidationError")
+	}
+}
+
+func TestValidationErrorFields(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.HTTPServerConfig.Port = 70000
+
+	errs := validationErrorsOf(Validate(cfg))
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d", len(errs))
+	}
+	err := errs[0]
+	if err.Path != "http_server.port" || err.Tag != "max" || err.Param != "65535" || err.Value != 70000 {
+		t.Errorf("unexpected error fields: %+v", err)
+	}
+	if err.Message == "" {
+		t.Error("expected a translated message")
+	}
+}
+
+func TestValidationErrorWithoutMessage(t *testing.T) {
+	err := &ValidationError{Path: "http_server.port", Tag: "max", Param: "65535", Value: 70000}
+	expected := "http_server.port: failed on the 'max=65535' rule, value: '70000'"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestValidateCORSConfig(t *testing.T) {
+	tests := []struct {
+		name             string
+		origins          []string
+		allowCredentials bool
+		wantErr          bool
+	}{
+		{name: "wildcard with credentials", origins: []string{"https://a.example", "*"}, allowCredentials: true, wantErr: true},
+		{name: "wildcard without credentials", origins: []string{"*"}},
+		{name: "explicit origins with credentials", origins: []string{"https://a.example"}, allowCredentials: true},
+		{name: "no origins with credentials", allowCredentials: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.HTTPServerConfig.CORSConfig.AllowedOrigins = tt.origins
+			cfg.HTTPServerConfig.CORSConfig.AllowCredentials = tt.allowCredentials
+
+			err := Validate(cfg)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			errs := validationErrorsOf(err)
+			if len(errs) != 1 || errs[0].Path != "http_server.cors.allowed_origins" || errs[0].Tag != "nowildcard_with_credentials" {
+				t.Fatalf("expected the wildcard origin to be rejected, got %v", err)
+			}
+			if errs[0].Message != "allowed_origins can't contain '*' when credentials are allowed" {
+				t.Errorf("unexpected message %q", errs[0].Message)
+			}
+		})
+	}
+}
+
+func TestCORSConfigDefaults(t *testing.T) {
+	cfg := defaultConfig(t)
+	if expected := []string{"GET", "POST"}; !slices.Equal(cfg.HTTPServerConfig.CORSConfig.AllowedMethods, expected) {
+		t.Errorf("expected the allowed methods %v, got %v", expected, cfg.HTTPServerConfig.CORSConfig.AllowedMethods)
+	}
+
+	schema := generateSchema(t, withoutComments())
+	prop := schemaProperty(t, schema, "CORSConfig", "allowed_methods")
+	if methods, ok := prop["default"].([]interface{}); !ok || len(methods) != 2 || methods[0] != "GET" || methods[1] != "POST" {
+		t.Errorf("expected the default [GET POST] in the schema, got %v", prop["default"])
+	}
+}
+
+func TestValidateAuthConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		auth     AuthConfig
+		expected []string
+	}{
+		{name: "none", auth: AuthConfig{Mode: "none"}},
+		{name: "none with an API key", auth: AuthConfig{Mode: "none", APIKey: "key"}, expected: []string{"auth.api_key"}},
+		{name: "apikey", auth: AuthConfig{Mode: "apikey", APIKey: "key"}},
+		{name: "apikey without the API key", auth: AuthConfig{Mode: "apikey"}, expected: []string{"auth.api_key"}},
+		{
+			name:     "apikey with OIDC settings",
+			auth:     AuthConfig{Mode: "apikey", APIKey: "key", OIDCConfig: OIDCConfig{ClientID: "client"}},
+			expected: []string{"auth.oidc.client_id"},
+		},
+		{
+			name: "oidc",
+			auth: AuthConfig{Mode: "oidc", OIDCConfig: OIDCConfig{IssuerURL: "https://issuer.example", ClientID: "client"}},
+		},
+		{name: "oidc without the settings", auth: AuthConfig{Mode: "oidc"}, expected: []string{"auth.oidc.issuer_url", "auth.oidc.client_id"}},
+		{
+			name:     "oidc with an API key",
+			auth:     AuthConfig{Mode: "oidc", APIKey: "key", OIDCConfig: OIDCConfig{IssuerURL: "https://issuer.example", ClientID: "client"}},
+			expected: []string{"auth.api_key"},
+		},
+		{name: "unknown mode", auth: AuthConfig{Mode: "basic"}, expected: []string{"auth.mode"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.AuthConfig = tt.auth
+
+			paths := validationPaths(Validate(cfg))
+			if !slices.Equal(paths, tt.expected) {
+				t.Errorf("expected the failing fields %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+}
+
+func TestValidateAuthConfigRules(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.AuthConfig = AuthConfig{Mode: "none", APIKey: "key"}
+	errs := validationErrorsOf(Validate(cfg))
+	if len(errs) != 1 || errs[0].Tag != "excluded_unless" || errs[0].Param != "Mode apikey" {
+		t.Errorf("expected the excluded_unless rule for the API key, got %v", errs)
+	}
+
+	cfg.AuthConfig = AuthConfig{Mode: "apikey"}
+	errs = validationErrorsOf(Validate(cfg))
+	if len(errs) != 1 || errs[0].Tag != "required_if" || errs[0].Param != "Mode apikey" {
+		t.Errorf("expected the required_if rule for the API key, got %v", errs)
+	}
+}
+
+func TestValidateBindAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		valid   bool
+	}{
+		{address: "0.0.0.0", valid: true},
+		{address: "127.0.0.1", valid: true},
+		{address: "::", valid: true},
+		{address: "::1", valid: true},
+		{address: "[::]", valid: true},
+		{address: "[::1]", valid: true},
+		{address: "localhost", valid: true},
+		{address: "api.example.com", valid: true},
+		{address: "not an address"},
+		{address: "999.1.1.1"},
+		{address: "[localhost]"},
+		{address: "-leading-hyphen"},
+		{address: "[::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.HTTPServerConfig.BindAddress = tt.address
+
+			errs := validationErrorsOf(Validate(cfg))
+			if tt.valid {
+				if len(errs) != 0 {
+					t.Errorf("expected %q to be valid, got %v", tt.address, errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Path != "http_server.bind_address" || errs[0].Tag != "bind_address" {
+				t.Fatalf("expected %q to be rejected by the bind_address rule, got %v", tt.address, errs)
+			}
+			if errs[0].Message != "bind_address must be an IP address or a hostname" {
+				t.Errorf("unexpected message %q", errs[0].Message)
+			}
+		})
+	}
+}
+
+func TestBindAddressDefault(t *testing.T) {
+	if address := defaultConfig(t).HTTPServerConfig.BindAddress; address != "0.0.0.0" {
+		t.Errorf("expected the default 0.0.0.0, got %q", address)
+	}
+}
+
+func TestValidateTracingConfig(t *testing.T) {
+	ratio := func(r float64) *float64 { return &r }
+	enabled := func(endpoint, serviceName string) TracingConfig {
+		return TracingConfig{Enabled: true, Endpoint: endpoint, ServiceName: serviceName, SampleRatio: ratio(0.1)}
+	}
+
+	tests := []struct {
+		name     string
+		tracing  TracingConfig
+		expected []string
+	}{
+		{name: "disabled without settings", tracing: TracingConfig{SampleRatio: ratio(0.1)}},
+		{name: "enabled", tracing: enabled("http://localhost:4318", "app")},
+		{name: "enabled without settings", tracing: enabled("", ""), expected: []string{"tracing.endpoint", "tracing.service_name"}},
+		{name: "invalid endpoint", tracing: enabled("not a url", "app"), expected: []string{"tracing.endpoint"}},
+		{name: "relative endpoint", tracing: enabled("/traces", "app"), expected: []string{"tracing.endpoint"}},
+		{name: "sample none", tracing: TracingConfig{SampleRatio: ratio(0)}},
+		{name: "sample all", tracing: TracingConfig{SampleRatio: ratio(1)}},
+		{name: "sample ratio below zero", tracing: TracingConfig{SampleRatio: ratio(-0.1)}, expected: []string{"tracing.sample_ratio"}},
+		{name: "sample ratio above one", tracing: TracingConfig{SampleRatio: ratio(1.1)}, expected: []string{"tracing.sample_ratio"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			tt.tracing.RetryConfig = cfg.TracingConfig.RetryConfig
+			cfg.TracingConfig = tt.tracing
+
+			paths := validationPaths(Validate(cfg))
+			if !slices.Equal(paths, tt.expected) {
+				t.Errorf("expected the failing fields %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+}
+
+func TestTracingConfigDefaults(t *testing.T) {
+	tracing := defaultConfig(t).TracingConfig
+	if tracing.SampleRatio == nil || *tracing.SampleRatio != 0.1 {
+		t.Errorf("expected the default sample ratio 0.1, got %v", tracing.SampleRatio)
+	}
+	// an explicit zero is kept, as the ratio is a pointer
+	cfg := mustLoadYAML(t, "tracing:\n  sample_ratio: 0\n")
+	if cfg.TracingConfig.SampleRatio == nil || *cfg.TracingConfig.SampleRatio != 0 {
+		t.Errorf("expected the sample ratio 0, got %v", cfg.TracingConfig.SampleRatio)
+	}
+}
+
+func TestValidationErrorMessages(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(cfg *Config)
+		expected string
+	}{
+		{
+			name:     "built-in rule",
+			modify:   func(cfg *Config) { cfg.HTTPServerConfig.Port = 70000 },
+			expected: "http_server.port: port must be 65,535 or less",
+		},
+		{
+			name:     "oneof rule",
+			modify:   func(cfg *Config) { cfg.LoggingConfig.LogFormat = "xml" },
+			expected: "logging.log_format: log_format must be one of [json pretty]",
+		},
+		{
+			name:     "custom rule",
+			modify:   func(cfg *Config) { cfg.HTTPServerConfig.BindAddress = "not an address" },
+			expected: "http_server.bind_address: bind_address must be an IP address or a hostname",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			tt.modify(cfg)
+			err := Validate(cfg)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestWithTranslator(t *testing.T) {
+	frLocale := fr.New()
+	trans, _ := ut.New(frLocale, frLocale).GetTranslator("fr")
+	registerFrench := func(validate *validator.Validate) error {
+		return frtranslations.RegisterDefaultTranslations(validate, trans)
+	}
+
+	cfg := defaultConfig(t)
+	cfg.HTTPServerConfig.Port = 70000
+	cfg.HTTPServerConfig.BindAddress = "not an address"
+
+	errs := validationErrorsOf(Validate(cfg, WithValidator(registerFrench), WithTranslator(trans)))
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	for _, err := range errs {
+		switch err.Path {
+		case "http_server.port":
+			if !strings.HasPrefix(err.Message, "port doit être") {
+				t.Errorf("expected a French message, got %q", err.Message)
+			}
+		case "http_server.bind_address":
+			// the custom rules have no French translation, so they are reported as they are
+			if err.Message != "" {
+				t.Errorf("expected no message for the untranslated rule, got %q", err.Message)
+			}
+		}
+	}
+}
+
+func TestValidateTracingRequiredByFeature(t *testing.T) {
+	tests := []struct {
+		name     string
+		features FeatureConfig
+		tracing  TracingConfig
+		expected []string
+	}{
+		{name: "feature disabled", features: FeatureConfig{EnabledFeatures: []string{"feature1"}}},
+		{
+			name:     "feature enabled in the list",
+			features: FeatureConfig{EnabledFeatures: []string{"tracing"}},
+			expected: []string{"tracing.endpoint", "tracing.service_name"},
+		},
+		{
+			name:     "feature enabled in the flags",
+			features: FeatureConfig{Flags: map[string]bool{"tracing": true}},
+			expected: []string{"tracing.endpoint", "tracing.service_name"},
+		},
+		{
+			name:     "feature disabled in the flags",
+			features: FeatureConfig{EnabledFeatures: []string{"tracing"}, Flags: map[string]bool{"tracing": false}},
+		},
+		{
+			name:     "feature enabled with the settings",
+			features: FeatureConfig{EnabledFeatures: []string{"tracing"}},
+			tracing:  TracingConfig{Endpoint: "http://localhost:4318", ServiceName: "app"},
+		},
+		{
+			name:     "feature enabled without the service name",
+			features: FeatureConfig{EnabledFeatures: []string{"tracing"}},
+			tracing:  TracingConfig{Endpoint: "http://localhost:4318"},
+			expected: []string{"tracing.service_name"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.FeatureConfig = tt.features
+			cfg.TracingConfig.Endpoint = tt.tracing.Endpoint
+			cfg.TracingConfig.ServiceName = tt.tracing.ServiceName
+
+			paths := validationPaths(Validate(cfg))
+			if !slices.Equal(paths, tt.expected) {
+				t.Errorf("expected the failing fields %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+}
+
+func TestValidateHealthConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		liveness  string
+		readiness string
+		expected  []string
+	}{
+		{name: "defaults", liveness: "/healthz", readiness: "/readyz"},
+		{name: "nested paths", liveness: "/health/live", readiness: "/health/ready"},
+		{name: "liveness without slash", liveness: "healthz", readiness: "/readyz", expected: []string{"health.liveness_path"}},
+		{name: "readiness without slash", liveness: "/healthz", readiness: "readyz", expected: []string{"health.readiness_path"}},
+		{name: "same paths", liveness: "/health", readiness: "/health", expected: []string{"health.readiness_path"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.HealthConfig.LivenessPath = tt.liveness
+			cfg.HealthConfig.ReadinessPath = tt.readiness
+
+			paths := validationPaths(Validate(cfg))
+			if !slices.Equal(paths, tt.expected) {
+				t.Errorf("expected the failing fields %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+}
+
+func TestHealthConfigDefaults(t *testing.T) {
+	health := defaultConfig(t).HealthConfig
+	if health.Enabled == nil || !*health.Enabled {
+		t.Errorf("expected health to be enabled by default, got %v", health.Enabled)
+	}
+	if health.LivenessPath != "/healthz" || health.ReadinessPath != "/readyz" {
+		t.Errorf("expected the default paths /healthz and /readyz, got %q and %q", health.LivenessPath, health.ReadinessPath)
+	}
+
+	// an explicit false is kept, as enabled is a pointer
+	cfg := mustLoadYAML(t, "health:\n  enabled: false\n")
+	if cfg.HealthConfig.Enabled == nil || *cfg.HealthConfig.Enabled {
+		t.Errorf("expected health to be disabled, got %v", cfg.HealthConfig.Enabled)
+	}
+}
+
+func TestValidateAllOrNone(t *testing.T) {
+	tests := []struct {
+		name     string
+		certFile string
+		keyFile  string
+		expected []string
+	}{
+		{name: "none set", expected: nil},
+		{name: "both set", certFile: "/tls/cert.pem", keyFile: "/tls/key.pem", expected: nil},
+		{name: "only cert", certFile: "/tls/cert.pem", expected: []string{"http_server.tls.key_file"}},
+		{name: "only key", keyFile: "/tls/key.pem", expected: []string{"http_server.tls.cert_file"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// TLS is disabled, so the files are not checked for existence
+			cfg := defaultConfig(t)
+			cfg.HTTPServerConfig.TLSConfig.CertFile = tt.certFile
+			cfg.HTTPServerConfig.TLSConfig.KeyFile = tt.keyFile
+
+			paths := validationPaths(Validate(cfg))
+			if !slices.Equal(paths, tt.expected) {
+				t.Errorf("expected the failing fields %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+}
+
+func TestValidateAllOrNoneError(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.HTTPServerConfig.TLSConfig.CertFile = "/tls/cert.pem"
+
+	errs := validationErrorsOf(Validate(cfg))
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d", len(errs))
+	}
+	err := errs[0]
+	if err.Tag != "all_or_none" || err.Param != "cert_file" {
+		t.Errorf("unexpected error fields: %+v", err)
+	}
+	if !strings.Contains(err.Message, "must be set together with cert_file") {
+		t.Errorf("unexpected message %q", err.Message)
+	}
+}
+
+func TestValidateTLSConfig(t *testing.T) {
+	certFile := writeFile(t, "cert.pem", "cert")
+	keyFile := writeFile(t, "key.pem", "key")
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+
+	type failure struct {
+		path string
+		tag  string
+	}
+	tests := []struct {
+		name     string
+		certFile string
+		keyFile  string
+		expected []failure
+	}{
+		{name: "existing files", certFile: certFile, keyFile: keyFile},
+		{name: "no cert", keyFile: keyFile, expected: []failure{{path: "http_server.tls.cert_file", tag: "required_if"}}},
+		{name: "no key", certFile: certFile, expected: []failure{{path: "http_server.tls.key_file", tag: "required_if"}}},
+		{
+			name:     "no files",
+			expected: []failure{{path: "http_server.tls.cert_file", tag: "required_if"}, {path: "http_server.tls.key_file", tag: "required_if"}},
+		},
+		{name: "nonexistent cert", certFile: missing, keyFile: keyFile, expected: []failure{{path: "http_server.tls.cert_file", tag: "file"}}},
+		{name: "nonexistent key", certFile: certFile, keyFile: missing, expected: []failure{{path: "http_server.tls.key_file", tag: "file"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			cfg.HTTPServerConfig.TLSConfig.Enabled = true
+			cfg.HTTPServerConfig.TLSConfig.CertFile = tt.certFile
+			cfg.HTTPServerConfig.TLSConfig.KeyFile = tt.keyFile
+
+			var failures []failure
+			for _, err := range validationErrorsOf(Validate(cfg)) {
+				failures = append(failures, failure{path: err.Path, tag: err.Tag})
+			}
+			if !slices.Equal(failures, tt.expected) {
+				t.Errorf("expected the failures %v, got %v", tt.expected, failures)
+			}
+		})
+	}
+}
+
+func TestAllOrNoneUnknownField(t *testing.T) {
+	type group struct {
+		A string
+	}
+	v := validator.New()
+	v.RegisterStructValidation(AllOrNone("A", "B"), group{})
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for the unknown field")
+		}
+	}()
+	_ = v.Struct(group{A: "a"})
+}
+
+func TestValidateWebSocketConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(ws *WebSocketConfig)
+		expected []string
+	}{
+		{name: "defaults", modify: func(ws *WebSocketConfig) {}},
+		{
+			name:     "negative read buffer size",
+			modify:   func(ws *WebSocketConfig) { ws.ReadBufferSize = -1 },
+			expected: []string{"http_server.websocket.read_buffer_size"},
+		},
+		{
+			name:     "negative write buffer size",
+			modify:   func(ws *WebSocketConfig) { ws.WriteBufferSize = -1 },
+			expected: []string{"http_server.websocket.write_buffer_size"},
+		},
+		{
+			name:     "handshake timeout too short",
+			modify:   func(ws *WebSocketConfig) { ws.HandshakeTimeout = Duration(time.Microsecond) },
+			expected: []string{"http_server.websocket.handshake_timeout"},
+		},
+		{
+			name:     "minimum handshake timeout",
+			modify:   func(ws *WebSocketConfig) { ws.HandshakeTimeout = Duration(time.Millisecond) },
+			expected: nil,
+		},
+		{
+			name:     "empty max message size",
+			modify:   func(ws *WebSocketConfig) { ws.MaxMessageSize = 0 },
+			expected: []string{"http_server.websocket.max_message_size"},
+		},
+		{
+			name:     "minimum max message size",
+			modify:   func(ws *WebSocketConfig) { ws.MaxMessageSize = 1 },
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			tt.modify(&cfg.HTTPServerConfig.WebSocketConfig)
+
+			paths := validationPaths(Validate(cfg))
+			if !slices.Equal(paths, tt.expected) {
+				t.Errorf("expected the failing fields %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+}
+
+func TestWebSocketConfigDefaults(t *testing.T) {
+	ws := defaultConfig(t).HTTPServerConfig.WebSocketConfig
+	expected := WebSocketConfig{
+		ReadBufferSize:   4096,
+		WriteBufferSize:  4096,
+		HandshakeTimeout: Duration(10 * time.Second),
+		MaxMessageSize:   1000 * 1000,
+	}
+	if ws != expected {
+		t.Errorf("expected the defaults %+v, got %+v", expected, ws)
+	}
+
+	cfg := mustLoadYAML(t, "http_server:\n  websocket:\n    enabled: true\n    handshake_timeout: 5s\n    max_message_size: 64KiB\n")
+	ws = cfg.HTTPServerConfig.WebSocketConfig
+	if !ws.Enabled || ws.HandshakeTimeout != Duration(5*time.Second) || ws.MaxMessageSize != 64*1024 {
+		t.Errorf("unexpected websocket config %+v", ws)
+	}
+}
+
+func TestValidatePortConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(cfg *Config)
+		expected []string
+	}{
+		{name: "defaults", modify: func(cfg *Config) {}},
+		{
+			name:     "metrics on the main port",
+			modify:   func(cfg *Config) { cfg.MetricsConfig.Port = 8080 },
+			expected: []string{"metrics.port"},
+		},
+		{
+			name: "metrics on the main port of another address",
+			modify: func(cfg *Config) {
+				cfg.HTTPServerConfig.BindAddress = "127.0.0.1"
+				cfg.MetricsConfig.BindAddress = "10.0.0.1"
+				cfg.MetricsConfig.Port = 8080
+			},
+			expected: []string{"metrics.port"},
+		},
+		{
+			name: "disabled metrics on the main port",
+			modify: func(cfg *Config) {
+				cfg.MetricsConfig.Enabled = Ptr(false)
+				cfg.MetricsConfig.Port = 8080
+			},
+		},
+		{
+			name: "profiling on the main port of all network interfaces",
+			modify: func(cfg *Config) {
+				cfg.ProfilingConfig = ProfilingConfig{Enabled: true, BindAddress: "127.0.0.1", Port: 8080}
+			},
+			expected: []string{"profiling.port"},
+		},
+		{
+			name: "disabled profiling on the main port",
+			modify: func(cfg *Config) {
+				cfg.ProfilingConfig = ProfilingConfig{BindAddress: "127.0.0.1", Port: 8080}
+			},
+		},
+		{
+			name: "grpc on the metrics port",
+			modify: func(cfg *Config) {
+				cfg.MetricsConfig.Port = 9090
+				cfg.GRPCConfig.Enabled = true
+				cfg.GRPCConfig.Port = 9090
+			},
+			expected: []string{"grpc.port"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			tt.modify(cfg)
+
+			paths := validationPaths(Validate(cfg))
+			if !slices.Equal(paths, tt.expected) {
+				t.Errorf("expected the failing fields %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+}
+
+func TestValidatePortConflictError(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.MetricsConfig.Port = 8080
+
+	errs := validationErrorsOf(Validate(cfg))
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d", len(errs))
+	}
+	if errs[0].Tag != "port_conflict" || errs[0].Param != "http_server.port" {
+		t.Errorf("unexpected error fields: %+v", errs[0])
+	}
+}
+
+func TestValidateMetricsConfig(t *testing.T) {
+	type failure struct {
+		path string
+		tag  string
+	}
+	tests := []struct {
+		name     string
+		modify   func(cfg *Config)
+		expected []failure
+	}{
+		{name: "served on the HTTP server", modify: func(cfg *Config) {}},
+		{name: "separate listener", modify: func(cfg *Config) { cfg.MetricsConfig.Port = 9090 }},
+		{
+			name:     "separate listener on the main port",
+			modify:   func(cfg *Config) { cfg.MetricsConfig.Port = 8080 },
+			expected: []failure{{path: "metrics.port", tag: "port_conflict"}},
+		},
+		{
+			name: "separate listener on the main port of another address",
+			modify: func(cfg *Config) {
+				cfg.HTTPServerConfig.BindAddress = "127.0.0.1"
+				cfg.MetricsConfig.BindAddress = "10.0.0.1"
+				cfg.MetricsConfig.Port = 8080
+			},
+			expected: []failure{{path: "metrics.port", tag: "nefield"}},
+		},
+		{
+			name: "disabled on the main port",
+			modify: func(cfg *Config) {
+				cfg.MetricsConfig.Enabled = Ptr(false)
+				cfg.MetricsConfig.Port = 8080
+			},
+		},
+		{
+			name:     "path without a leading slash",
+			modify:   func(cfg *Config) { cfg.MetricsConfig.Path = "metrics" },
+			expected: []failure{{path: "metrics.path", tag: "startswith"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			tt.modify(cfg)
+
+			var failures []failure
+			for _, err := range validationErrorsOf(Validate(cfg)) {
+				failures = append(failures, failure{path: err.Path, tag: err.Tag})
+			}
+			if !slices.Equal(failures, tt.expected) {
+				t.Errorf("expected the failures %v, got %v", tt.expected, failures)
+			}
+		})
+	}
+}
+
+func TestMetricsConfigDefaults(t *testing.T) {
+	metrics := defaultConfig(t).MetricsConfig
+	if metrics.Enabled == nil || !*metrics.Enabled || metrics.Path != "/metrics" || metrics.SeparateListener() {
+		t.Errorf("expected the metrics to be enabled at /metrics on the HTTP server, got %+v", metrics)
+	}
+}
+
+func TestValidateGRPCConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(grpc *GRPCConfig)
+		expected []string
+	}{
+		{name: "defaults", modify: func(grpc *GRPCConfig) {}},
+		{
+			name:     "invalid bind address",
+			modify:   func(grpc *GRPCConfig) { grpc.BindAddress = "not an address" },
+			expected: []string{"grpc.bind_address"},
+		},
+		{
+			name:     "port out of range",
+			modify:   func(grpc *GRPCConfig) { grpc.Port = 70000 },
+			expected: []string{"grpc.port"},
+		},
+		{
+			name:     "receive size too small",
+			modify:   func(grpc *GRPCConfig) { grpc.MaxRecvMsgSize = 512 },
+			expected: []string{"grpc.max_recv_msg_size"},
+		},
+		{
+			name:     "send size too small",
+			modify:   func(grpc *GRPCConfig) { grpc.MaxSendMsgSize = 512 },
+			expected: []string{"grpc.max_send_msg_size"},
+		},
+		{
+			name:     "minimum sizes",
+			modify:   func(grpc *GRPCConfig) { grpc.MaxRecvMsgSize, grpc.MaxSendMsgSize = 1000, 1000 },
+			expected: nil,
+		},
+		{
+			// the disabled gRPC server doesn't listen, so only the enabled one conflicts with the HTTP server
+			name:     "enabled on the main port",
+			modify:   func(grpc *GRPCConfig) { grpc.Enabled, grpc.Port = true, 8080 },
+			expected: []string{"grpc.port"},
+		},
+		{
+			name:     "disabled on the main port",
+			modify:   func(grpc *GRPCConfig) { grpc.Port = 8080 },
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			tt.modify(&cfg.GRPCConfig)
+
+			paths := validationPaths(Validate(cfg))
+			if !slices.Equal(paths, tt.expected) {
+				t.Errorf("expected the failing fields %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+}
+
+func TestGRPCConfigDefaults(t *testing.T) {
+	grpc := defaultConfig(t).GRPCConfig
+	expected := GRPCConfig{BindAddress: "0.0.0.0", Port: 50051, MaxRecvMsgSize: 4000 * 1000, MaxSendMsgSize: 4000 * 1000}
+	if grpc != expected {
+		t.Errorf("expected the defaults %+v, got %+v", expected, grpc)
+	}
+}
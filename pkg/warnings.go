@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Severity is the severity of a Warning.
+type Severity string
+
+const (
+	// SeverityInfo is for the settings that are fine in some environments but not in others, such as binding to all
+	// network interfaces, which is needed in a container but exposes the server on a host.
+	SeverityInfo Severity = "info"
+
+	// SeverityWarning is for the settings that should be changed, such as the deprecated fields.
+	SeverityWarning Severity = "warning"
+)
+
+// Warning is a non-fatal problem in the configuration, as returned by HandleConfigWithWarnings.
+type Warning struct {
+	// Path is the JSON path of the field, such as `http_server.bind_address`.
+	Path string
+
+	// Message describes the problem, such as `binds to all network interfaces`.
+	Message string
+
+	// Severity is the severity of the problem.
+	Severity Severity
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s: %s", w.Severity, w.Path, w.Message)
+}
+
+// HandleConfigWithWarnings is like HandleConfig, but also returns the warnings about the configuration, such as the
+// use of deprecated fields and the suspicious values. The warnings are returned even if there is an error.
+//
+// The deprecated fields are detected from their values before the defaults are applied, so the configuration must
+// not be defaulted already. When loading with Viper, WarnDeprecated is more precise, as it checks the config files.
+func HandleConfigWithWarnings(cfg *Config, opts ...HandleOption) ([]Warning, error) {
+	warnings := deprecatedWarnings(cfg)
+
+	// validate even if defaulting fails, so that the user sees all the problems at once, unless failing fast
+	defaultErr := ApplyDefaults(cfg, opts...)
+	if defaultErr != nil && newHandleOptions(opts).failFast {
+		return warnings, defaultErr
+	}
+	err := errors.Join(defaultErr, Validate(cfg, opts...))
+
+	warnings = append(warnings, valueWarnings(cfg)...)
+	return warnings, err
+}
+
+// deprecatedWarnings returns a warning for each deprecated field that is set in the configuration.
+// See WarnDeprecated for the `deprecated` tag.
+func deprecatedWarnings(cfg *Config) []Warning {
+	var warnings []Warning
+	visitFieldValues(reflect.ValueOf(cfg), "", func(path string, field reflect.StructField, value reflect.Value) {
+		replacement, ok := field.Tag.Lookup("deprecated")
+		if !ok || value.IsZero() {
+			return
+		}
+
+		message := "deprecated"
+		if replacement != "" {
+			message += fmt.Sprintf(", use %s instead", replacement)
+		}
+		warnings = append(warnings, Warning{Path: path, Message: message, Severity: SeverityWarning})
+	})
+	return warnings
+}
+
+// valueWarnings returns the warnings about the suspicious values in the configuration.
+func valueWarnings(cfg *Config) []Warning {
+	var warnings []Warning
+
+	// fine in a container, but exposes the server on all the networks of a host
+	if isAllInterfaces(cfg.HTTPServerConfig.BindAddress) {
+		warnings = append(warnings, Warning{
+			Path:     "http_server.bind_address",
+			Message:  "binds to all network interfaces, use a specific address if the server shouldn't be reachable from every network",
+			Severity: SeverityInfo,
+		})
+	}
+	// the profiling endpoints leak the internals of the application and can be used for a denial of service
+	if cfg.ProfilingConfig.Enabled && isAllInterfaces(cfg.ProfilingConfig.BindAddress) {
+		warnings = append(warnings, Warning{
+			Path:     "profiling.bind_address",
+			Message:  "exposes the profiling endpoints on all network interfaces, use a loopback address such as 127.0.0.1",
+			Severity: SeverityWarning,
+		})
+	}
+	return warnings
+}
+
+// isAllInterfaces returns true if the bind address is the unspecified address, which binds to all network interfaces.
+func isAllInterfaces(bindAddress string) bool {
+	return bindAddress == "0.0.0.0" || bindAddress == "::" || bindAddress == "[::]"
+}
@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHandleConfigWithWarnings(t *testing.T) {
+	allInterfaces := Warning{
+		Path:     "http_server.bind_address",
+		Message:  "binds to all network interfaces, use a specific address if the server shouldn't be reachable from every network",
+		Severity: SeverityInfo,
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(cfg *Config)
+		expected []Warning
+	}{
+		{
+			name:     "defaults",
+			modify:   func(cfg *Config) {},
+			expected: []Warning{allInterfaces},
+		},
+		{
+			name: "specific bind address",
+			modify: func(cfg *Config) {
+				cfg.HTTPServerConfig.BindAddress = "127.0.0.1"
+			},
+			expected: nil,
+		},
+		{
+			name: "deprecated field",
+			modify: func(cfg *Config) {
+				cfg.HTTPServerConfig.BindAddress = "127.0.0.1"
+				cfg.FeatureConfig.EnabledFeatures = []string{"feature1"}
+			},
+			expected: []Warning{
+				{Path: "features.enabled_features", Message: "deprecated, use features.flags instead", Severity: SeverityWarning},
+			},
+		},
+		{
+			name: "profiling on all network interfaces",
+			modify: func(cfg *Config) {
+				cfg.HTTPServerConfig.BindAddress = "::"
+				cfg.ProfilingConfig = ProfilingConfig{Enabled: true, BindAddress: "0.0.0.0", Port: 6060}
+			},
+			expected: []Warning{
+				allInterfaces,
+				{
+					Path:     "profiling.bind_address",
+					Message:  "exposes the profiling endpoints on all network interfaces, use a loopback address such as 127.0.0.1",
+					Severity: SeverityWarning,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{}
+			tt.modify(cfg)
+
+			warnings, err := HandleConfigWithWarnings(cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(warnings, tt.expected) {
+				t.Errorf("expected the warnings %v, got %v", tt.expected, warnings)
+			}
+		})
+	}
+}
+
+func TestHandleConfigWithWarningsError(t *testing.T) {
+	// the warnings are returned along with the error
+	cfg := &Config{}
+	cfg.HTTPServerConfig.Port = 70000
+	cfg.FeatureConfig.EnabledFeatures = []string{"feature1"}
+
+	warnings, err := HandleConfigWithWarnings(cfg)
+	assertErrorContains(t, err, "http_server.port")
+	if len(warnings) == 0 || warnings[0].Path != "features.enabled_features" {
+		t.Errorf("expected the deprecation warning, got %v", warnings)
+	}
+
+	// HandleConfig drops the warnings, but not the error
+	cfg = &Config{}
+	cfg.HTTPServerConfig.Port = 70000
+	assertErrorContains(t, HandleConfig(cfg), "http_server.port")
+}
+
+func TestWarningString(t *testing.T) {
+	warning := Warning{Path: "features.enabled_features", Message: "deprecated", Severity: SeverityWarning}
+	if s := warning.String(); s != "warning: features.enabled_features: deprecated" {
+		t.Errorf("unexpected string %q", s)
+	}
+}
@@ -0,0 +1,48 @@
+package pkg
+
+import (
+	"log"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Watch watches the configuration file used by Viper and updates the store when the file changes.
+//
+// On every change, the config file is read again and loaded like LoadConfig does with the given options, so the
+// overlays, the environment variables, the flags and the programmatic defaults still apply, and it is defaulted and
+// validated. Only if the new configuration is valid, its reloadable changes are applied to the configuration in the
+// store, see Reload, and onChange is called with the old and the new configuration. Otherwise, the error is logged
+// and the old configuration is kept, without calling onChange. onChange can be nil, if the caller only reads the
+// configuration from the store.
+//
+// If the store is empty, the current configuration is loaded into it first. If that configuration is not valid,
+// the store stays empty, and the first valid change is stored as a whole, with old being nil.
+//
+// onChange runs on Viper's watcher goroutine, so it should not block for long.
+func Watch(v *viper.Viper, store *Store, onChange func(old, new *Config), opts ...LoadOption) {
+	var mu sync.Mutex
+
+	if store.Load() == nil {
+		if _, _, err := reloadConfig(v, store, opts); err != nil {
+			log.Printf("Current config is not valid: %v", err)
+		}
+	}
+
+	v.OnConfigChange(func(e fsnotify.Event) {
+		// changes are handled one at a time, so that old and new are consistent for onChange
+		mu.Lock()
+		defer mu.Unlock()
+
+		old, next, err := reloadConfig(v, store, opts)
+		if err != nil {
+			log.Printf("Ignoring config change in %s, keeping the old config: %v", e.Name, err)
+			return
+		}
+		if onChange != nil {
+			onChange(old, next)
+		}
+	})
+	v.WatchConfig()
+}
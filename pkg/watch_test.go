@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// configChange is a call of the onChange function of Watch.
+type configChange struct {
+	old, new *Config
+}
+
+// waitForChange waits for the next config change, failing the test if there is none in a few seconds.
+func waitForChange(t *testing.T, changes <-chan configChange) configChange {
+	t.Helper()
+	select {
+	case change := <-changes:
+		return change
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the config change to be watched")
+		return configChange{}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	v, path := readConfigFile(t, "http_server:\n  port: 8080\nlogging:\n  log_level: 2\n")
+	var store Store
+
+	changes := make(chan configChange, 10)
+	Watch(v, &store, func(old, new *Config) {
+		changes <- configChange{old: old, new: new}
+	})
+	initial := store.Load()
+	if initial == nil || *initial.LoggingConfig.LogLevel != 2 {
+		t.Fatal("expected the current config to be stored")
+	}
+
+	replaceFile(t, path, "http_server:\n  port: 9090\nlogging:\n  log_level: 0\n")
+	change := waitForChange(t, changes)
+	if change.old != initial {
+		t.Error("expected the old config to be the initial one")
+	}
+	if *change.new.LoggingConfig.LogLevel != 0 || store.Load() != change.new {
+		t.Errorf("expected the new config with log level 0 to be stored, got %d", *change.new.LoggingConfig.LogLevel)
+	}
+	// the port is not reloadable, see Reload
+	if change.new.HTTPServerConfig.Port != 8080 {
+		t.Errorf("expected the port to stay 8080, got %d", change.new.HTTPServerConfig.Port)
+	}
+
+	// the invalid config is ignored, without calling onChange
+	previous := change.new
+	replaceFile(t, path, "logging:\n  log_level: 9\n")
+	select {
+	case change := <-changes:
+		t.Fatalf("expected the invalid config to be ignored, got log level %d", *change.new.LoggingConfig.LogLevel)
+	case <-time.After(500 * time.Millisecond):
+	}
+	if store.Load() != previous {
+		t.Error("expected the store to keep the previous config")
+	}
+
+	// the next change is relative to the previous valid config
+	replaceFile(t, path, "logging:\n  log_level: 4\n")
+	change = waitForChange(t, changes)
+	if change.old != previous {
+		t.Errorf("expected the old config to be the previous valid one, got log level %d", *change.old.LoggingConfig.LogLevel)
+	}
+	if *change.new.LoggingConfig.LogLevel != 4 || store.Load() != change.new {
+		t.Errorf("expected the new config with log level 4 to be stored, got %d", *change.new.LoggingConfig.LogLevel)
+	}
+}
+
+func TestWatchUnsetEnv(t *testing.T) {
+	t.Setenv("APP_LOGGING_LOG_FORMAT", "pretty")
+	v, path := readConfigFile(t, "logging:\n  log_level: 2\n")
+	var store Store
+
+	changes := make(chan configChange, 10)
+	Watch(v, &store, func(old, new *Config) {
+		changes <- configChange{old: old, new: new}
+	})
+	if format := store.Load().LoggingConfig.LogFormat; format != "pretty" {
+		t.Fatalf("expected the log format pretty from the environment, got %q", format)
+	}
+
+	// nothing is carried over from the previous load, so the default applies again
+	if err := os.Unsetenv("APP_LOGGING_LOG_FORMAT"); err != nil {
+		t.Fatal(err)
+	}
+	replaceFile(t, path, "logging:\n  log_level: 0\n")
+	change := waitForChange(t, changes)
+	if format := change.new.LoggingConfig.LogFormat; format != "json" {
+		t.Errorf("expected the default log format json after unsetting the environment variable, got %q", format)
+	}
+}
@@ -0,0 +1,94 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// CheckRequiredHaveDefaults returns a problem for each field of the given struct, recursively, that has the
+// `required` validation rule but no default value in its `jsonschema` tag.
+//
+// The reference config is a blank config with the defaults applied, which must be valid. So, such a field fails
+// the validation of the reference config, which is hard to trace back to the missing default.
+// The conditional rules, such as `required_if`, are not checked. The variants of the tagged unions that are not
+// selected by the default of the discriminator are not checked either, as they are not in the reference config.
+func CheckRequiredHaveDefaults(cfg interface{}) []string {
+	var problems []string
+	checkRequiredHaveDefaults(reflect.TypeOf(cfg), "", &problems)
+	return problems
+}
+
+func checkRequiredHaveDefaults(t reflect.Type, prefix string, problems *[]string) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		if hasRule(field.Tag.Get("validate"), "required") && !hasDefault(field.Tag.Get("jsonschema")) {
+			*problems = append(*problems, fmt.Sprintf("field %s is required but has no default; add a jsonschema default or remove required", path))
+		}
+
+		if variant, ok := field.Tag.Lookup("union"); ok && variant != "discriminator" && variant != defaultVariant(t) {
+			continue
+		}
+		checkRequiredHaveDefaults(field.Type, path, problems)
+	}
+}
+
+// defaultVariant returns the default of the discriminator of the tagged union, which is the field with the
+// `union:"discriminator"` tag. Empty if the struct is not a tagged union or the discriminator has no default.
+func defaultVariant(t reflect.Type) string {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("union") != "discriminator" {
+			continue
+		}
+		for _, part := range strings.Split(field.Tag.Get("jsonschema"), ",") {
+			if value, ok := strings.CutPrefix(part, "default="); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// hasRule returns true if the `validate` tag has the given rule, without a parameter.
+func hasRule(validateTag, rule string) bool {
+	for _, r := range strings.Split(validateTag, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDefault returns true if the `jsonschema` tag has a default value.
+func hasDefault(jsonschemaTag string) bool {
+	for _, part := range strings.Split(jsonschemaTag, ",") {
+		if strings.HasPrefix(part, "default=") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package util
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCheckRequiredHaveDefaults(t *testing.T) {
+	type database struct {
+		Host string `json:"host" jsonschema:"default=localhost" validate:"required"`
+		Name string `json:"name" validate:"required"`
+		// the conditional rules are not checked
+		Password string `json:"password" validate:"required_if=Host remote"`
+	}
+	type config struct {
+		Port     int      `json:"port,omitempty" validate:"required,min=1"`
+		Timeout  int      `json:"timeout" jsonschema:"default=10" validate:"required"`
+		Optional string   `json:"optional" validate:"omitempty,url"`
+		Database database `json:"database"`
+		Ignored  string   `json:"-" validate:"required"`
+	}
+
+	problems := CheckRequiredHaveDefaults(&config{})
+	expected := []string{
+		"field port is required but has no default; add a jsonschema default or remove required",
+		"field database.name is required but has no default; add a jsonschema default or remove required",
+	}
+	if !slices.Equal(problems, expected) {
+		t.Errorf("expected %q, got %q", expected, problems)
+	}
+}
+
+func TestCheckRequiredHaveDefaultsNoProblems(t *testing.T) {
+	type config struct {
+		Port int `json:"port" jsonschema:"default=8080" validate:"required"`
+	}
+	if problems := CheckRequiredHaveDefaults(config{}); len(problems) != 0 {
+		t.Errorf("expected no problems, got %q", problems)
+	}
+}
@@ -0,0 +1,122 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// InlineRefs replaces the `$ref`s in the schema with copies of the definitions they point to, and removes the
+// definitions. The result is a self-contained schema without `$ref` and `$defs`, for the schema consumers that
+// don't support references.
+//
+// Only the references to the definitions of the schema itself, such as `#/$defs/Config`, are supported.
+// An error is returned for the other references and for the recursive definitions, which can't be inlined.
+func InlineRefs(schema *jsonschema.Schema) error {
+	inlined, err := inlineRefs(schema, schema.Definitions, map[string]bool{})
+	if err != nil {
+		return err
+	}
+
+	// the root is usually a reference to the definition of the root type, which doesn't have the root keywords
+	inlined.Version = schema.Version
+	inlined.ID = schema.ID
+	inlined.Definitions = nil
+	*schema = *inlined
+	return nil
+}
+
+// inlineRefs returns a copy of the schema with the references replaced by the definitions, recursively.
+// The visiting set holds the definitions being inlined on the current path, for detecting the cycles.
+// The given schema is not modified, as the definitions are shared by all the places that reference them.
+func inlineRefs(schema *jsonschema.Schema, defs jsonschema.Definitions, visiting map[string]bool) (*jsonschema.Schema, error) {
+	// the boolean schemas are marshalled as `true` and `false` only if they are these exact instances
+	if schema == nil || schema == jsonschema.TrueSchema || schema == jsonschema.FalseSchema {
+		return schema, nil
+	}
+
+	out := *schema
+	if schema.Ref != "" {
+		name, ok := strings.CutPrefix(schema.Ref, "#/$defs/")
+		if !ok {
+			return nil, fmt.Errorf("unsupported reference %q", schema.Ref)
+		}
+		def, ok := defs[name]
+		if !ok {
+			return nil, fmt.Errorf("definition not found for reference %q", schema.Ref)
+		}
+		if visiting[name] {
+			return nil, fmt.Errorf("recursive definition %q can't be inlined", name)
+		}
+		visiting[name] = true
+		defer delete(visiting, name)
+
+		out = *def
+		// the keywords next to the reference, such as a field-specific description, override the definition
+		if schema.Description != "" {
+			out.Description = schema.Description
+		}
+		if schema.Default != nil {
+			out.Default = schema.Default
+		}
+	}
+	out.Definitions = nil
+
+	var err error
+	inline := func(s *jsonschema.Schema) *jsonschema.Schema {
+		if err != nil {
+			return nil
+		}
+		var inlined *jsonschema.Schema
+		inlined, err = inlineRefs(s, defs, visiting)
+		return inlined
+	}
+	inlineAll := func(schemas []*jsonschema.Schema) []*jsonschema.Schema {
+		if schemas == nil {
+			return nil
+		}
+		inlined := make([]*jsonschema.Schema, len(schemas))
+		for i, s := range schemas {
+			inlined[i] = inline(s)
+		}
+		return inlined
+	}
+	inlineMap := func(schemas map[string]*jsonschema.Schema) map[string]*jsonschema.Schema {
+		if schemas == nil {
+			return nil
+		}
+		inlined := make(map[string]*jsonschema.Schema, len(schemas))
+		for k, s := range schemas {
+			inlined[k] = inline(s)
+		}
+		return inlined
+	}
+
+	if out.Properties != nil {
+		properties := jsonschema.NewProperties()
+		for pair := out.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			properties.Set(pair.Key, inline(pair.Value))
+		}
+		out.Properties = properties
+	}
+	out.PatternProperties = inlineMap(out.PatternProperties)
+	out.AdditionalProperties = inline(out.AdditionalProperties)
+	out.PropertyNames = inline(out.PropertyNames)
+	out.Items = inline(out.Items)
+	out.PrefixItems = inlineAll(out.PrefixItems)
+	out.Contains = inline(out.Contains)
+	out.AllOf = inlineAll(out.AllOf)
+	out.AnyOf = inlineAll(out.AnyOf)
+	out.OneOf = inlineAll(out.OneOf)
+	out.Not = inline(out.Not)
+	out.If = inline(out.If)
+	out.Then = inline(out.Then)
+	out.Else = inline(out.Else)
+	out.DependentSchemas = inlineMap(out.DependentSchemas)
+	out.ContentSchema = inline(out.ContentSchema)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
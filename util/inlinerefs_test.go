@@ -0,0 +1,87 @@
+package util
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/invopop/jsonschema"
+)
+
+func TestInlineRefs(t *testing.T) {
+	type server struct {
+		Port int `json:"port"`
+	}
+	type config struct {
+		Server server `json:"server" jsonschema:"description=The server."`
+		Admin  server `json:"admin"`
+	}
+
+	schema := new(jsonschema.Reflector).Reflect(&config{})
+	if err := InlineRefs(schema); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "$ref") || strings.Contains(string(out), "$defs") {
+		t.Errorf("expected no references and definitions, got %s", out)
+	}
+	// the root keywords are kept
+	if schema.Version == "" || schema.ID == "" {
+		t.Errorf("expected the $schema and the $id to be kept, got %s", out)
+	}
+
+	serverSchema, _ := schema.Properties.Get("server")
+	if _, ok := serverSchema.Properties.Get("port"); !ok {
+		t.Errorf("expected the definition of server to be inlined, got %s", out)
+	}
+	// the keywords next to the reference override the definition
+	if serverSchema.Description != "The server." {
+		t.Errorf("expected the description of the field, got %q", serverSchema.Description)
+	}
+	// each reference gets its own copy
+	adminSchema, _ := schema.Properties.Get("admin")
+	if adminSchema == serverSchema || adminSchema.Description != "" {
+		t.Errorf("expected a separate copy for admin, got %+v", adminSchema)
+	}
+}
+
+type recursiveNode struct {
+	Children []recursiveNode `json:"children"`
+}
+
+func TestInlineRefsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  *jsonschema.Schema
+		wantErr string
+	}{
+		{
+			name:    "recursive definition",
+			schema:  new(jsonschema.Reflector).Reflect(&recursiveNode{}),
+			wantErr: `recursive definition "recursiveNode" can't be inlined`,
+		},
+		{
+			name:    "external reference",
+			schema:  &jsonschema.Schema{Ref: "https://example.com/schema.json"},
+			wantErr: "unsupported reference",
+		},
+		{
+			name:    "missing definition",
+			schema:  &jsonschema.Schema{Ref: "#/$defs/Missing"},
+			wantErr: "definition not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := InlineRefs(tt.schema)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -1,32 +1,65 @@
 package util
 
 import (
-	"github.com/invopop/jsonschema"
-	"log"
+	"fmt"
 	"strconv"
 	"strings"
+	"unicode"
+
+	"github.com/invopop/jsonschema"
 )
 
 // VisitSchema visits all the schemas in the schema tree and calls the visitor function for the schemas that have the given propType.
-func VisitSchema(schema *jsonschema.Schema, propType string, visitor func(*jsonschema.Schema)) {
+// The schema tree consists of the properties, the definitions, the array items and the additional properties of the schemas.
+// The traversal stops at the first error returned by the visitor, which is then returned.
+func VisitSchema(schema *jsonschema.Schema, propType string, visitor func(*jsonschema.Schema) error) error {
 	if schema.Type == propType {
-		visitor(schema)
+		if err := visitor(schema); err != nil {
+			return err
+		}
 	}
 
 	for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
-		VisitSchema(pair.Value, propType, visitor)
+		if err := VisitSchema(pair.Value, propType, visitor); err != nil {
+			return err
+		}
 	}
 	for _, def := range schema.Definitions {
-		VisitSchema(def, propType, visitor)
+		if err := VisitSchema(def, propType, visitor); err != nil {
+			return err
+		}
+	}
+	// element schemas of arrays, such as the schema of SomeStruct for []SomeStruct
+	if schema.Items != nil {
+		if err := VisitSchema(schema.Items, propType, visitor); err != nil {
+			return err
+		}
+	}
+	// value schemas of maps, such as the schema of SomeStruct for map[string]SomeStruct
+	if schema.AdditionalProperties != nil {
+		if err := VisitSchema(schema.AdditionalProperties, propType, visitor); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // FixArrayDefaultValues fixes the default values of array fields in a JSON schema.
 // go-defaultz expects the default values of array fields to be in the form of a space-separated string as in "a b c" or "1.2 2.5 -21.3".
+// The string items containing spaces can be quoted, as in `"hello world" foo`, see SplitDefaultList.
 // This function converts the default values of array fields to the appropriate type, such as []string{"a", "b", "c"} or []int{1, 2, 3}.
-func FixArrayDefaultValues(schema *jsonschema.Schema) {
+// An error is returned if the default value can't be converted to the item type, such as "a b c" for an integer array.
+//
+// Arrays of objects, such as []Backend, are left untouched. go-defaultz can't default them from a tag either,
+// so their defaults must be provided as real YAML in the config files.
+func FixArrayDefaultValues(schema *jsonschema.Schema) error {
 	if schema.Default == nil {
-		return
+		return nil
+	}
+
+	// the items of object arrays are either inline objects or references to the object definitions
+	if schema.Items == nil || schema.Items.Type == "object" || schema.Items.Ref != "" {
+		return nil
 	}
 
 	var ok bool
@@ -36,16 +69,21 @@ func FixArrayDefaultValues(schema *jsonschema.Schema) {
 	// the first item will be the array as string like []string{"a b c"}
 	var asArray []interface{}
 	if asArray, ok = schema.Default.([]interface{}); !ok {
-		return
+		return nil
 	}
 
 	if len(asArray) == 0 {
-		return
+		return nil
 	}
 
 	var defaultStr string
 	if defaultStr, ok = asArray[0].(string); !ok {
-		return
+		return nil
+	}
+
+	parts, err := SplitDefaultList(defaultStr)
+	if err != nil {
+		return err
 	}
 
 	// now we have the default value as a string
@@ -61,41 +99,114 @@ func FixArrayDefaultValues(schema *jsonschema.Schema) {
 	// https://json-schema.org/draft/2020-12/json-schema-validation#name-type
 	switch schema.Items.Type {
 	case "string":
-		schema.Default = strings.Fields(defaultStr)
+		schema.Default = parts
 	case "integer":
-		parts := strings.Fields(defaultStr)
 		arr := make([]int, 0)
 		for _, part := range parts {
 			i, err := strconv.Atoi(part)
 			if err != nil {
-				log.Fatalf("Failed to convert default value to integer: %v", err)
+				return fmt.Errorf("failed to convert default value %q to integer: %w", defaultStr, err)
 			}
 			arr = append(arr, i)
 		}
 		schema.Default = arr
 	case "number":
-		parts := strings.Fields(defaultStr)
 		arr := make([]float64, 0)
 		for _, part := range parts {
 			f, err := strconv.ParseFloat(part, 64)
 			if err != nil {
-				log.Fatalf("Failed to convert default value to float64: %v", err)
+				return fmt.Errorf("failed to convert default value %q to float64: %w", defaultStr, err)
 			}
 			arr = append(arr, f)
 		}
 		schema.Default = arr
 	case "boolean":
-		parts := strings.Fields(defaultStr)
 		arr := make([]bool, 0)
 		for _, part := range parts {
 			b, err := strconv.ParseBool(part)
 			if err != nil {
-				log.Fatalf("Failed to convert default value to bool: %v", err)
+				return fmt.Errorf("failed to convert default value %q to bool: %w", defaultStr, err)
 			}
 			arr = append(arr, b)
 		}
 		schema.Default = arr
 	default:
-		log.Fatalf("Unsupported array item type: %v", schema.Items.Type)
+		return fmt.Errorf("unsupported array item type: %v", schema.Items.Type)
+	}
+	return nil
+}
+
+// SplitDefaultList splits the space-separated default value of an array field into its items, such as
+// `a b c` into "a", "b" and "c". Unlike strings.Fields, the items can be quoted with double quotes to contain
+// spaces, such as `"hello world" foo` into "hello world" and "foo". A backslash escapes the next character,
+// such as `\"` for a literal double quote or `\\` for a literal backslash.
+//
+// An error is returned for an unterminated quote or a trailing backslash.
+func SplitDefaultList(s string) ([]string, error) {
+	parts := make([]string, 0)
+	var current strings.Builder
+	// inItem tells if an item is being read, so that an empty quoted item, `""`, is kept
+	inItem, inQuotes, escaped := false, false, false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped, inItem = true, true
+		case r == '"':
+			inQuotes, inItem = !inQuotes, true
+		case unicode.IsSpace(r) && !inQuotes:
+			if inItem {
+				parts = append(parts, current.String())
+				current.Reset()
+				inItem = false
+			}
+		default:
+			current.WriteRune(r)
+			inItem = true
+		}
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("invalid list %q: trailing backslash", s)
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("invalid list %q: unterminated quote", s)
+	}
+	if inItem {
+		parts = append(parts, current.String())
+	}
+	return parts, nil
+}
+
+// ParseValue converts the string value to the Go type matching the given JSON schema type, such as an int for
+// "integer" or a bool for "boolean". This is useful for the values in the struct tags, which are always strings.
+// An error is returned if the value can't be converted, or if the type is not a primitive type.
+func ParseValue(value, schemaType string) (interface{}, error) {
+	switch schemaType {
+	case "string":
+		return value, nil
+	case "integer":
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert value %q to integer: %w", value, err)
+		}
+		return i, nil
+	case "number":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert value %q to float64: %w", value, err)
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert value %q to bool: %w", value, err)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("unsupported type for value %q: %v", value, schemaType)
 	}
 }
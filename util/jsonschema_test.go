@@ -0,0 +1,212 @@
+package util
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/invopop/jsonschema"
+)
+
+// arraySchema returns the schema of an array field with the given item type and the given default value, as the
+// reflector generates it from a `jsonschema:"default=..."` tag.
+func arraySchema(itemType, defaultValue string) *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:    "array",
+		Items:   &jsonschema.Schema{Type: itemType},
+		Default: []interface{}{defaultValue},
+	}
+}
+
+func TestFixArrayDefaultValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   *jsonschema.Schema
+		expected interface{}
+		wantErr  string
+	}{
+		{name: "strings", schema: arraySchema("string", "a b c"), expected: []string{"a", "b", "c"}},
+		{name: "integers", schema: arraySchema("integer", "1 2 -3"), expected: []int{1, 2, -3}},
+		{name: "numbers", schema: arraySchema("number", "1.2 2.5 -21.3"), expected: []float64{1.2, 2.5, -21.3}},
+		{name: "booleans", schema: arraySchema("boolean", "true false"), expected: []bool{true, false}},
+		{name: "strings into integers", schema: arraySchema("integer", "a b c"), wantErr: `failed to convert default value "a b c" to integer`},
+		{name: "strings into numbers", schema: arraySchema("number", "1.2 x"), wantErr: "to float64"},
+		{name: "strings into booleans", schema: arraySchema("boolean", "yes"), wantErr: "to bool"},
+		{name: "unsupported item type", schema: arraySchema("null", "a"), wantErr: "unsupported array item type"},
+		{name: "no default", schema: &jsonschema.Schema{Type: "array", Items: &jsonschema.Schema{Type: "integer"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := FixArrayDefaultValues(tt.schema)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.schema.Default, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, tt.schema.Default)
+			}
+		})
+	}
+}
+
+func TestVisitSchemaReturnsTheError(t *testing.T) {
+	type config struct {
+		Ports []int `json:"ports" jsonschema:"default=a b c"`
+	}
+	schema := new(jsonschema.Reflector).Reflect(&config{})
+
+	err := VisitSchema(schema, "array", FixArrayDefaultValues)
+	if err == nil || !strings.Contains(err.Error(), `"a b c" to integer`) {
+		t.Fatalf("expected the conversion error, got %v", err)
+	}
+}
+
+func TestVisitSchemaStopsAtTheFirstError(t *testing.T) {
+	type config struct {
+		A []string `json:"a"`
+		B []string `json:"b"`
+	}
+	schema := new(jsonschema.Reflector).Reflect(&config{})
+
+	visited := 0
+	errStop := errors.New("stop")
+	err := VisitSchema(schema, "array", func(*jsonschema.Schema) error {
+		visited++
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("expected the error of the visitor, got %v", err)
+	}
+	if visited != 1 {
+		t.Errorf("expected 1 visit, got %d", visited)
+	}
+}
+
+func TestVisitSchemaArrayItems(t *testing.T) {
+	type backend struct {
+		URL  string   `json:"url"`
+		Tags []string `json:"tags" jsonschema:"default=a b"`
+	}
+	type config struct {
+		Backends []backend           `json:"backends"`
+		Groups   [][]backend         `json:"groups"`
+		ByName   map[string]backend  `json:"by_name"`
+		Inline   []struct{ N []int } `json:"inline"`
+	}
+	// without references, the element schemas are only reachable through the items and the additional properties
+	reflector := &jsonschema.Reflector{DoNotReference: true}
+	schema := reflector.Reflect(&config{})
+
+	var objects int
+	if err := VisitSchema(schema, "object", func(*jsonschema.Schema) error {
+		objects++
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the root, the map of by_name, the backend of each of backends, groups and by_name, and the inline element
+	if objects != 6 {
+		t.Errorf("expected 6 object schemas to be visited, got %d", objects)
+	}
+
+	// the array defaults of the element schemas are fixed as well
+	if err := VisitSchema(schema, "array", FixArrayDefaultValues); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tags := schema.Properties.Value("backends").Items.Properties.Value("tags")
+	if !reflect.DeepEqual(tags.Default, []string{"a", "b"}) {
+		t.Errorf("expected the default of the element schema to be fixed, got %#v", tags.Default)
+	}
+	nested := schema.Properties.Value("groups").Items.Items.Properties.Value("tags")
+	if !reflect.DeepEqual(nested.Default, []string{"a", "b"}) {
+		t.Errorf("expected the default of the nested element schema to be fixed, got %#v", nested.Default)
+	}
+}
+
+func TestFixArrayDefaultValuesObjectArrays(t *testing.T) {
+	type backend struct {
+		URL    string `json:"url"`
+		Weight int    `json:"weight"`
+	}
+	type config struct {
+		Backends []backend `json:"backends"`
+		// the default of an object array can't be applied from the tag, so it is kept as it is
+		Fallbacks []backend `json:"fallbacks" jsonschema:"default=x"`
+	}
+
+	for _, reflector := range []*jsonschema.Reflector{{}, {DoNotReference: true}} {
+		schema := reflector.Reflect(&config{})
+		if err := VisitSchema(schema, "array", FixArrayDefaultValues); err != nil {
+			t.Fatalf("unexpected error with DoNotReference=%v: %v", reflector.DoNotReference, err)
+		}
+
+		def := schema
+		if !reflector.DoNotReference {
+			def = schema.Definitions["config"]
+		}
+		fallbacks := def.Properties.Value("fallbacks")
+		if !reflect.DeepEqual(fallbacks.Default, []interface{}{"x"}) {
+			t.Errorf("expected the default of the object array to be untouched, got %#v", fallbacks.Default)
+		}
+	}
+}
+
+func TestSplitDefaultList(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		wantErr  string
+	}{
+		{name: "simple", input: "a b  c", expected: []string{"a", "b", "c"}},
+		{name: "empty", input: "", expected: []string{}},
+		{name: "quoted item", input: `"hello world" foo`, expected: []string{"hello world", "foo"}},
+		{name: "empty quoted item", input: `"" foo`, expected: []string{"", "foo"}},
+		{name: "quotes inside an item", input: `a"b c"d`, expected: []string{"ab cd"}},
+		{name: "escaped quote", input: `say\"hi\" "a \"quoted\" word"`, expected: []string{`say"hi"`, `a "quoted" word`}},
+		{name: "escaped space", input: `hello\ world foo`, expected: []string{"hello world", "foo"}},
+		{name: "escaped backslash", input: `a\\b`, expected: []string{`a\b`}},
+		{name: "unterminated quote", input: `"hello world`, wantErr: "unterminated quote"},
+		{name: "trailing backslash", input: `hello\`, wantErr: "trailing backslash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := SplitDefaultList(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(parts, tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, parts)
+			}
+		})
+	}
+}
+
+func TestFixArrayDefaultValuesQuotedItems(t *testing.T) {
+	schema := arraySchema("string", `"hello world" foo`)
+	if err := FixArrayDefaultValues(schema); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"hello world", "foo"}; !reflect.DeepEqual(schema.Default, expected) {
+		t.Errorf("expected %q, got %#v", expected, schema.Default)
+	}
+
+	err := FixArrayDefaultValues(arraySchema("string", `"hello world`))
+	if err == nil || !strings.Contains(err.Error(), "unterminated quote") {
+		t.Errorf("expected the unterminated quote error, got %v", err)
+	}
+}
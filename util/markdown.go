@@ -0,0 +1,142 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// GenerateMarkdown generates the documentation of the given config struct as a Markdown table, with a row for every
+// leaf field: the JSON path, such as `http_server.port`, the Go type, the default value from the `jsonschema` tag,
+// the description and the validation rules from the `validate` tag.
+//
+// The descriptions are read from the Go comments of the fields, like the JSON schema, unless overridden with the
+// `description` tag. So, this function must be called from the root directory of the module, which has the go.mod
+// file. An error is returned if the comments can't be read.
+func GenerateMarkdown(cfg interface{}) ([]byte, error) {
+	t := reflect.TypeOf(cfg)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unsupported type %v, must be a struct", t)
+	}
+
+	comments, err := goComments(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("| Path | Type | Default | Description | Validation |\n")
+	buf.WriteString("| --- | --- | --- | --- | --- |\n")
+	writeMarkdownRows(&buf, t, "", comments)
+	return buf.Bytes(), nil
+}
+
+// goComments reads the Go comments of the package of the given type, keyed like `<package path>.<type>.<field>`.
+// The package path is resolved relative to the module in the go.mod file in the current directory.
+func goComments(t reflect.Type) (map[string]string, error) {
+	goMod, err := os.ReadFile("go.mod")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod, must be called from the root directory of the module: %w", err)
+	}
+	var module string
+	for _, line := range strings.Split(string(goMod), "\n") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			module = strings.Trim(strings.TrimSpace(name), `"`)
+			break
+		}
+	}
+	dir, ok := strings.CutPrefix(t.PkgPath(), module+"/")
+	if module == "" || !ok {
+		return nil, fmt.Errorf("package %s is not in the module of the current directory", t.PkgPath())
+	}
+
+	reflector := new(jsonschema.Reflector)
+	if err := reflector.AddGoComments(module, dir); err != nil {
+		return nil, fmt.Errorf("failed to add comments: %w", err)
+	}
+	return reflector.CommentMap, nil
+}
+
+// writeMarkdownRows writes a table row for every leaf field of the given struct type, recursively.
+func writeMarkdownRows(buf *bytes.Buffer, t reflect.Type, prefix string, comments map[string]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			writeMarkdownRows(buf, fieldType, path, comments)
+			continue
+		}
+
+		description, ok := field.Tag.Lookup("description")
+		if !ok {
+			description = comments[t.PkgPath()+"."+t.Name()+"."+field.Name]
+		}
+		var defaultValue string
+		for _, part := range strings.Split(field.Tag.Get("jsonschema"), ",") {
+			if value, ok := strings.CutPrefix(part, "default="); ok {
+				defaultValue = value
+			}
+		}
+
+		fmt.Fprintf(buf, "| %s | %s | %s | %s | %s |\n",
+			markdownCode(path),
+			markdownCode(typeName(fieldType)),
+			markdownCode(defaultValue),
+			markdownCell(description),
+			markdownCode(field.Tag.Get("validate")),
+		)
+	}
+}
+
+// typeName returns the name of the type without the package, such as `Duration` or `[]CIDR`.
+func typeName(t reflect.Type) string {
+	if t.PkgPath() != "" {
+		return t.Name()
+	}
+	// composite types, such as `[]pkg.CIDR`, have the package name in their string
+	name := t.String()
+	if (t.Kind() == reflect.Slice || t.Kind() == reflect.Map) && t.Elem().PkgPath() != "" {
+		name = strings.ReplaceAll(name, t.Elem().String(), t.Elem().Name())
+	}
+	return name
+}
+
+// markdownCell escapes the text for a table cell, where the newlines and the pipes would break the table.
+func markdownCell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// markdownCode formats the text as inline code in a table cell. Empty text is left empty.
+func markdownCode(text string) string {
+	if text == "" {
+		return ""
+	}
+	return "`" + markdownCell(text) + "`"
+}
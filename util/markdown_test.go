@@ -0,0 +1,84 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// MarkdownTestServer is exported, as the Go comments are only read for the exported types.
+type MarkdownTestServer struct {
+	// Port is the port to listen on
+	Port int `json:"port,omitempty" jsonschema:"default=8080" validate:"required,min=1,max=65535"`
+
+	// Mode is overridden by the tag.
+	Mode string `json:"mode,omitempty" description:"the mode | a or b" jsonschema:"default=a,enum=a,enum=b"`
+}
+
+type MarkdownTestDuration int64
+
+type MarkdownTestConfig struct {
+	// Server is a section, which has no row of its own.
+	Server MarkdownTestServer `json:"server"`
+
+	// Optional is a section behind a pointer.
+	Optional *MarkdownTestServer `json:"optional,omitempty"`
+
+	// Timeouts is a list of durations,
+	// with a comment on multiple lines.
+	Timeouts []MarkdownTestDuration `json:"timeouts,omitempty"`
+
+	Ignored string `json:"-"`
+}
+
+// chdirModuleRoot changes the working directory to the root directory of the module for the test, as the Go
+// comments are read relative to it, see GenerateMarkdown. The tests calling it can't run in parallel.
+func chdirModuleRoot(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Dir(wd)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestGenerateMarkdown(t *testing.T) {
+	chdirModuleRoot(t)
+
+	out, err := GenerateMarkdown(&MarkdownTestConfig{})
+	if err != nil {
+		t.Fatalf("failed to generate the Markdown: %v", err)
+	}
+
+	expected := "| Path | Type | Default | Description | Validation |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| `server.port` | `int` | `8080` | Port is the port to listen on | `required,min=1,max=65535` |\n" +
+		"| `server.mode` | `string` | `a` | the mode \\| a or b |  |\n" +
+		"| `optional.port` | `int` | `8080` | Port is the port to listen on | `required,min=1,max=65535` |\n" +
+		"| `optional.mode` | `string` | `a` | the mode \\| a or b |  |\n" +
+		"| `timeouts` | `[]MarkdownTestDuration` |  | Timeouts is a list of durations, with a comment on multiple lines. |  |\n"
+	if string(out) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+func TestGenerateMarkdownErrors(t *testing.T) {
+	// the comments are read relative to the root directory of the module
+	_, err := GenerateMarkdown(&MarkdownTestConfig{})
+	if err == nil || !strings.Contains(err.Error(), "failed to read go.mod") {
+		t.Errorf("expected an error about go.mod, got %v", err)
+	}
+
+	_, err = GenerateMarkdown("not a struct")
+	if err == nil || !strings.Contains(err.Error(), "must be a struct") {
+		t.Errorf("expected an error about the type, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	jsv "github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"sigs.k8s.io/yaml"
+)
+
+// ValidateAgainstSchema validates the given document against the given JSON schema.
+// The document can be YAML or JSON, the schema must be JSON.
+//
+// This is used to check that the generated reference config agrees with the generated schema,
+// such as catching a default value that violates a constraint of its own field.
+// The returned error lists the invalid values with their dotted paths, such as `http_server.port`.
+func ValidateAgainstSchema(doc, schema []byte) error {
+	// the validator works on JSON values, so convert the YAML document to JSON first.
+	// JSON is valid YAML, so JSON documents are converted as-is.
+	docJSON, err := yaml.YAMLToJSON(doc)
+	if err != nil {
+		return fmt.Errorf("failed to convert document to JSON: %w", err)
+	}
+	docValue, err := jsv.UnmarshalJSON(bytes.NewReader(docJSON))
+	if err != nil {
+		return fmt.Errorf("failed to parse document: %w", err)
+	}
+
+	schemaValue, err := jsv.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	// the URL is only used to refer to the schema while compiling, the `$id` in the schema still takes effect
+	const schemaURL = "configuration-schema.json"
+	compiler := jsv.NewCompiler()
+	if err := compiler.AddResource(schemaURL, schemaValue); err != nil {
+		return fmt.Errorf("failed to add schema: %w", err)
+	}
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return fmt.Errorf("failed to compile schema: %w", err)
+	}
+
+	if err := compiled.Validate(docValue); err != nil {
+		var validationErr *jsv.ValidationError
+		if errors.As(err, &validationErr) {
+			err = schemaErrors(validationErr)
+		}
+		return fmt.Errorf("document doesn't match the schema: %w", err)
+	}
+	return nil
+}
+
+// schemaErrors flattens the tree of the schema validation errors into the errors of the values, qualified with their
+// dotted paths in the document, such as `http_server.port: got string, want integer`. The intermediate errors, such
+// as the ones of the `allOf` groups, only repeat their causes, so they are skipped.
+func schemaErrors(err *jsv.ValidationError) error {
+	printer := message.NewPrinter(language.English)
+	var errs []error
+	var visit func(err *jsv.ValidationError)
+	visit = func(err *jsv.ValidationError) {
+		if len(err.Causes) > 0 {
+			for _, cause := range err.Causes {
+				visit(cause)
+			}
+			return
+		}
+		path := strings.Join(err.InstanceLocation, ".")
+		if path == "" {
+			path = "(root)"
+		}
+		text := err.ErrorKind.LocalizedString(printer)
+		// the properties that can't be set, such as the unselected variants of a `oneOf`, have the `false` schema
+		if _, ok := err.ErrorKind.(*kind.FalseSchema); ok {
+			text = "not allowed"
+		}
+		errs = append(errs, fmt.Errorf("%s: %s", path, text))
+	}
+	visit(err)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const portSchema = `{
+  "type": "object",
+  "properties": {
+    "http_server": {
+      "type": "object",
+      "properties": {
+        "port": {"type": "integer", "minimum": 1, "maximum": 65535}
+      }
+    }
+  }
+}`
+
+func TestValidateAgainstSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		schema  string
+		wantErr string
+	}{
+		{name: "valid YAML", doc: "http_server:\n  port: 8080\n", schema: portSchema},
+		{name: "valid JSON", doc: `{"http_server": {"port": 8080}}`, schema: portSchema},
+		{name: "below the minimum", doc: "http_server:\n  port: 0\n", schema: portSchema, wantErr: "http_server.port: minimum"},
+		{name: "wrong type", doc: "http_server:\n  port: abc\n", schema: portSchema, wantErr: "http_server.port: got string, want integer"},
+		{name: "invalid document", doc: "http_server: [", schema: portSchema, wantErr: "failed to convert document to JSON"},
+		{name: "invalid schema", doc: "http_server:\n  port: 8080\n", schema: "{", wantErr: "failed to parse schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAgainstSchema([]byte(tt.doc), []byte(tt.schema))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGeneratedReferenceConfigMatchesSchema(t *testing.T) {
+	doc, err := os.ReadFile("../default-config.gen.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := os.ReadFile("../configuration-schema.gen.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateAgainstSchema(doc, schema); err != nil {
+		t.Errorf("the generated reference config doesn't match the generated schema: %v", err)
+	}
+}